@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/steabert/gopus/opus"
+)
+
+// analyze_packet_size_bin is the width in bytes of the bins of the packet
+// size histogram.
+const analyze_packet_size_bin = 64
+
+type analyzeResult struct {
+	Path string `json:"path"`
+	*opus.Analysis
+	Error string `json:"error,omitempty"`
+}
+
+func analyze(args []string) error {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "output the statistics as JSON")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		usage()
+		return errors.New("no arguments, expected at least 1 file to analyze")
+	}
+
+	failed := 0
+	results := make([]analyzeResult, 0, flags.NArg())
+	for i, path := range flags.Args() {
+		result := analyzeResult{Path: path}
+		result.Analysis, err = opus.ParseOptions{Recover: true}.Analyze(path)
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+
+		if !*asJSON {
+			if i > 0 {
+				fmt.Println()
+			}
+			printAnalysis(result)
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files could not be analyzed", failed, len(results))
+	}
+
+	return nil
+}
+
+func printAnalysis(result analyzeResult) {
+	if result.Error != "" {
+		fmt.Printf("[ERROR] %s, %s\n", result.Path, result.Error)
+		return
+	}
+
+	a := result.Analysis
+	rate := "CBR"
+	if a.VBR {
+		rate = "VBR"
+	}
+
+	fmt.Printf("%s\n", result.Path)
+	fmt.Printf("  duration:    %v\n", a.Duration)
+	fmt.Printf("  packets:     %d (%d DTX, %d malformed, %d stereo)\n", a.Packets, a.DTX, a.Malformed, a.Stereo)
+	fmt.Printf("  bitrate:     %.1f kb/s %s\n", float64(a.AverageBitrate())/1000, rate)
+
+	modes := make([]string, 0, len(a.Modes))
+	for m := opus.ModeSILK; m <= opus.ModeCELT; m++ {
+		if a.Modes[m] > 0 {
+			modes = append(modes, fmt.Sprintf("%v %s", m, percentage(a.Modes[m], a.Packets)))
+		}
+	}
+	fmt.Printf("  mode:        %s\n", strings.Join(modes, ", "))
+
+	bandwidths := make([]string, 0, len(a.Bandwidths))
+	for b := opus.BandwidthNarrowband; b <= opus.BandwidthFullband; b++ {
+		if a.Bandwidths[b] > 0 {
+			bandwidths = append(bandwidths, fmt.Sprintf("%v %s", b, percentage(a.Bandwidths[b], a.Packets)))
+		}
+	}
+	fmt.Printf("  bandwidth:   %s\n", strings.Join(bandwidths, ", "))
+
+	frameSizes := make([]string, 0, len(a.FrameSizes))
+	for _, size := range slices.Sorted(maps.Keys(a.FrameSizes)) {
+		duration := time.Duration(size) * time.Second / opus.DecodeSampleRate
+		frameSizes = append(frameSizes, fmt.Sprintf("%v %s", duration, percentage(a.FrameSizes[size], a.Packets)))
+	}
+	fmt.Printf("  frame size:  %s\n", strings.Join(frameSizes, ", "))
+
+	bins := make(map[int]int64)
+	for size, count := range a.PacketSizes {
+		bins[size/analyze_packet_size_bin] += count
+	}
+	fmt.Printf("  packet size:\n")
+	for _, bin := range slices.Sorted(maps.Keys(bins)) {
+		fmt.Printf("    %4d-%-4d bytes %s\n", bin*analyze_packet_size_bin, (bin+1)*analyze_packet_size_bin-1, percentage(bins[bin], a.Packets))
+	}
+
+	// The bitrate over time is shown for every 10 seconds.
+	fmt.Printf("  bitrate over time (kb/s per 10s):\n   ")
+	for i := 0; i < len(a.Bitrate); i += 10 {
+		window := a.Bitrate[i:min(i+10, len(a.Bitrate))]
+		total := 0
+		for _, bits := range window {
+			total += bits
+		}
+		fmt.Printf(" %.0f", float64(total)/float64(len(window))/1000)
+	}
+	fmt.Println()
+
+	for _, warning := range a.Warnings {
+		fmt.Printf("  [WARN] %s\n", warning)
+	}
+	if a.Packets > 0 && a.Bandwidth() < opus.BandwidthSuperWideband {
+		fmt.Printf("  [WARN] mostly %v audio, this is a low-bandwidth encode\n", a.Bandwidth())
+	}
+	if a.Packets > 0 && a.Mode() == opus.ModeSILK {
+		fmt.Printf("  [WARN] mostly SILK frames, this is a speech (VoIP-grade) encode\n")
+	}
+}
+
+func percentage(count, total int64) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(count)*100/float64(total))
+}
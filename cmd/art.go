@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/steabert/gopus/opus"
+)
+
+func art(args []string) error {
+	flags := flag.NewFlagSet("art", flag.ContinueOnError)
+	extract := flags.String("extract", "", "write the embedded pictures to `dir`")
+	add := flags.String("add", "", "embed the picture in `image`")
+	replace := flags.String("replace", "", "embed the picture in `image`, replacing pictures of the same type")
+	pictureType := flags.String("type", "front cover", "the picture `type` to add or replace")
+	description := flags.String("desc", "", "the `description` of the picture to add or replace")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		usage()
+		return errors.New("expected 1 file")
+	}
+	path := flags.Arg(0)
+
+	if *add != "" || *replace != "" {
+		if *add != "" && *replace != "" {
+			return errors.New("-add and -replace cannot be combined")
+		}
+
+		t, err := opus.ParsePictureType(*pictureType)
+		if err != nil {
+			return err
+		}
+
+		return embedPicture(path, *add+*replace, t, *description, *replace != "")
+	}
+
+	info, err := opus.ParseInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s, %v", path, err)
+	}
+
+	pictures, err := info.Comments.Pictures()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[WARN] %s, %v\n", path, err)
+	}
+
+	for i, picture := range pictures {
+		if *extract == "" {
+			printPicture(i, &picture)
+			continue
+		}
+
+		if picture.IsURL() {
+			fmt.Printf("skipping picture %d, it links to %s\n", i, picture.Data)
+			continue
+		}
+
+		base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		name := filepath.Join(*extract, fmt.Sprintf("%s.%d%s", base, i, pictureExtension(picture.MIME)))
+		err = os.WriteFile(name, picture.Data, 0644)
+		if err != nil {
+			return err
+		}
+		fmt.Println(name)
+	}
+
+	return nil
+}
+
+func embedPicture(path, image string, t opus.PictureType, description string, replace bool) error {
+	data, err := os.ReadFile(image)
+	if err != nil {
+		return err
+	}
+
+	info, err := opus.ParseInfo(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s, %v", path, err)
+	}
+
+	picture := opus.NewPicture(t, description, data)
+	if replace {
+		info.Comments.SetPicture(picture)
+	} else {
+		info.Comments.AddPicture(picture)
+	}
+
+	err = opus.UpdateTags(path, info.Comments)
+	if err != nil {
+		return fmt.Errorf("failed to update %s, %v", path, err)
+	}
+
+	return nil
+}
+
+func printPicture(i int, picture *opus.Picture) {
+	fmt.Printf("picture %d: %v, %s", i, picture.Type, picture.MIME)
+	if picture.Width != 0 && picture.Height != 0 {
+		fmt.Printf(", %dx%d", picture.Width, picture.Height)
+	}
+	if picture.IsURL() {
+		fmt.Printf(", %s", picture.Data)
+	} else {
+		fmt.Printf(", %d bytes", len(picture.Data))
+	}
+	if picture.Description != "" {
+		fmt.Printf(", %q", picture.Description)
+	}
+	fmt.Println()
+}
+
+// pictureExtension returns the file name extension for a MIME type.
+func pictureExtension(mime string) string {
+	switch mime {
+	case "image/jpeg", "image/jpg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/bmp":
+		return ".bmp"
+	}
+	return ".bin"
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/steabert/gopus/opus"
+	"github.com/steabert/gopus/opus/decoder"
+)
+
+// wav_header_size is the size of the header of a 16-bit PCM WAV file.
+const wav_header_size = 44
+
+func decode(args []string) error {
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	output := flags.String("o", "", "write the audio to `file` as 16-bit WAV")
+	downmix := flags.String("downmix", "", "downmix the audio to `layout`, stereo or mono")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		usage()
+		return errors.New("expected 1 file")
+	}
+	path := flags.Arg(0)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r, err := opus.ParseOptions{Recover: true}.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read %s, %v", path, err)
+	}
+	info := r.Info()
+	channels := int(info.Channels)
+
+	var mix *decoder.Downmix
+	if *downmix != "" {
+		if info.MappingFamily != opus.MappingFamilyRTP && info.MappingFamily != opus.MappingFamilyVorbis {
+			return fmt.Errorf("mapping family %d has no speaker layout to downmix", info.MappingFamily)
+		}
+		switch *downmix {
+		case "stereo":
+			mix, err = decoder.NewStereoDownmix(channels)
+		case "mono":
+			mix, err = decoder.NewMonoDownmix(channels)
+		default:
+			err = fmt.Errorf("unknown downmix layout %q, expected stereo or mono", *downmix)
+		}
+		if err != nil {
+			return err
+		}
+		_, channels = mix.Channels()
+	}
+
+	var w *bufio.Writer
+	var out *os.File
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		// The header is written once the size of the audio is known.
+		_, err = out.Seek(wav_header_size, io.SeekStart)
+		if err != nil {
+			return err
+		}
+		w = bufio.NewWriter(out)
+	}
+
+	pcm := make([]int16, opus.DecodeSampleRate/10*int(info.Channels))
+	mixed := make([]int16, opus.DecodeSampleRate/10*channels)
+	var samples int64
+	for {
+		n, err := r.Read(pcm)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode %s, %v", path, err)
+		}
+
+		frames := pcm[:n*int(info.Channels)]
+		if mix != nil {
+			mix.MixInt16(mixed, frames)
+			frames = mixed[:n*channels]
+		}
+		if w != nil {
+			err = binary.Write(w, binary.LittleEndian, frames)
+			if err != nil {
+				return err
+			}
+		}
+		samples += int64(n)
+	}
+
+	if w != nil {
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+		err = writeWAVHeader(out, channels, samples)
+		if err != nil {
+			return err
+		}
+	}
+
+	c := r.Concealed()
+	fmt.Printf("%s\n", path)
+	for _, warning := range r.Info().Warnings {
+		fmt.Printf("  [WARN] %s\n", warning)
+	}
+	fmt.Printf("  duration:  %v\n", time.Duration(samples)*time.Second/opus.DecodeSampleRate)
+	fmt.Printf("  concealed: %v in %d gaps, %v from FEC\n", c.Duration(), c.Gaps,
+		time.Duration(c.FECSamples)*time.Second/opus.DecodeSampleRate)
+	if c.Samples > 0 && samples > 0 {
+		fmt.Printf("  [WARN] %.2f%% of the audio is concealed\n", float64(c.Samples)*100/float64(samples))
+	}
+
+	return nil
+}
+
+// writeWAVHeader writes the header of a 16-bit PCM WAV file at the start
+// of f, for samples samples per channel.
+func writeWAVHeader(f *os.File, channels int, samples int64) error {
+	data_size := uint32(samples * int64(channels) * 2)
+	header := make([]byte, 0, wav_header_size)
+	header = append(header, "RIFF"...)
+	header = binary.LittleEndian.AppendUint32(header, wav_header_size-8+data_size)
+	header = append(header, "WAVEfmt "...)
+	header = binary.LittleEndian.AppendUint32(header, 16)
+	header = binary.LittleEndian.AppendUint16(header, 1) // PCM
+	header = binary.LittleEndian.AppendUint16(header, uint16(channels))
+	header = binary.LittleEndian.AppendUint32(header, opus.DecodeSampleRate)
+	header = binary.LittleEndian.AppendUint32(header, uint32(opus.DecodeSampleRate*channels*2))
+	header = binary.LittleEndian.AppendUint16(header, uint16(channels*2))
+	header = binary.LittleEndian.AppendUint16(header, 16)
+	header = append(header, "data"...)
+	header = binary.LittleEndian.AppendUint32(header, data_size)
+
+	_, err := f.WriteAt(header, 0)
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/steabert/gopus/opus"
+)
+
+func info(args []string) error {
+	if len(args) == 0 {
+		usage()
+		return errors.New("no arguments, expected at least 1 file")
+	}
+
+	for i, path := range args {
+		if i > 0 {
+			fmt.Println()
+		}
+
+		var info opus.OpusInfo
+		var err error
+		if path == "-" {
+			info, err = opus.ParseOptions{Properties: true}.ParseInfoReader(os.Stdin)
+		} else {
+			info, err = opus.ParseOptions{Properties: true}.ParseInfo(path)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s, %v", path, err)
+		}
+
+		printInfo(path, &info)
+	}
+
+	return nil
+}
+
+func printInfo(path string, info *opus.OpusInfo) {
+	speakers := make([]string, 0, info.Channels)
+	for _, speaker := range info.Speakers() {
+		speakers = append(speakers, speaker.String())
+	}
+
+	fmt.Printf("%s\n", path)
+	fmt.Printf("  duration:        %v\n", info.Duration)
+	fmt.Printf("  bitrate:         %.1f kb/s (peak %.1f kb/s)\n", float64(info.AverageBitrate)/1000, float64(info.PeakBitrate)/1000)
+	fmt.Printf("  audio:           %d bytes, %d packets, %d pages\n", info.AudioBytes, info.AudioPackets, info.AudioPages)
+	fmt.Printf("  channels:        %d (%s)\n", info.Channels, strings.Join(speakers, ", "))
+	fmt.Printf("  mapping family:  %d\n", info.MappingFamily)
+	if info.IsAmbisonic() {
+		fmt.Printf("  ambisonic order: %d (non-diegetic stereo: %v)\n", info.AmbisonicOrder, info.NonDiegetic)
+	}
+	fmt.Printf("  streams:         %d (%d coupled)\n", info.StreamCount, info.CoupledCount)
+	if info.DemixingMatrix != nil {
+		fmt.Printf("  demixing matrix: %d x %d\n", info.Channels, len(info.DemixingMatrix)/int(info.Channels))
+	} else {
+		fmt.Printf("  channel mapping: %v\n", info.ChannelMapping)
+	}
+	fmt.Printf("  sample rate:     %d Hz (input %d Hz)\n", opus.DecodeSampleRate, info.SampleRate)
+	fmt.Printf("  pre-skip:        %d\n", info.PreSkip)
+	fmt.Printf("  output gain:     %.2f dB\n", info.OutputGain)
+	if info.HasTrackGain {
+		fmt.Printf("  track gain:      %.2f dB (playback %.2f dB)\n", info.TrackGain, info.PlaybackGain(opus.GainTrack, 0))
+	}
+	if info.HasAlbumGain {
+		fmt.Printf("  album gain:      %.2f dB (playback %.2f dB)\n", info.AlbumGain, info.PlaybackGain(opus.GainAlbum, 0))
+	}
+	fmt.Printf("  vendor:          %s\n", info.Vendor)
+
+	for _, comment := range info.Comments {
+		if strings.EqualFold(comment.Name, opus.PictureComment) {
+			continue
+		}
+		fmt.Printf("  %v\n", comment)
+	}
+
+	pictures, _ := info.Comments.Pictures()
+	for i, picture := range pictures {
+		fmt.Print("  ")
+		printPicture(i, &picture)
+	}
+}
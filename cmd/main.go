@@ -10,14 +10,50 @@ func usage() {
 	fmt.Println(`gopus is an .opus song library manager.
 
 Usage:
-    gopus add <path>
+    gopus scan [-chained] <path>
 
   where <path> is a directory containing .opus files to
-  be searched and added to the database.
+  be searched and added to the database. With -chained,
+  every link of a chained file is added as its own track.
 
     gopus find [-t title] [-a album] [-c creator] [-p performer]
 
-  where results are filtered based on the provided flags.`)
+  where results are filtered based on the provided flags.
+
+    gopus warnings
+
+  where the files that do not conform to the Ogg Opus
+  specification are listed with the problems found when
+  they were added.
+
+    gopus info <file>...
+
+  where the Opus headers and tags of each file are shown,
+  a file named - is read from standard input.
+
+    gopus verify [-json] <file>...
+
+  where every page of each file is checked for conformance
+  to the Ogg and Ogg Opus specifications.
+
+    gopus analyze [-json] <file>...
+
+  where the audio packets of each file are analyzed, showing
+  histograms of their mode, bandwidth, frame size and size,
+  and the bitrate over time.
+
+    gopus decode [-o file] [-downmix stereo|mono] <file>
+
+  where the audio of the file is decoded, concealing missing
+  or damaged packets, and reported with the amount of audio
+  concealed. With -o, it is written to file as 16-bit WAV.
+
+    gopus art [-extract dir] <file>
+    gopus art -add|-replace <image> [-type type] [-desc text] <file>
+
+  where the embedded pictures of the file are listed, written
+  to dir, or an image is embedded, replacing any pictures of
+  the same type (default: front cover) for -replace.`)
 }
 
 func main() {
@@ -35,6 +71,18 @@ func main() {
 		err = scan(cmdArgs)
 	case "list":
 		err = list(cmdArgs)
+	case "warnings":
+		err = warnings(cmdArgs)
+	case "info":
+		err = info(cmdArgs)
+	case "verify":
+		err = verify(cmdArgs)
+	case "analyze":
+		err = analyze(cmdArgs)
+	case "decode":
+		err = decode(cmdArgs)
+	case "art":
+		err = art(cmdArgs)
 	default:
 		err = errors.New("no command given")
 	}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 
 	"github.com/steabert/gopus/rds"
@@ -9,7 +10,13 @@ import (
 )
 
 func scan(args []string) error {
-	var err error
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	chained := flags.Bool("chained", false, "add every link of a chained file as a recording")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+	args = flags.Args()
 
 	if len(args) == 0 {
 		usage()
@@ -22,7 +29,7 @@ func scan(args []string) error {
 	}
 
 	for _, dir := range args {
-		err = worker.WalkDirInsert(dir)
+		err = worker.WalkDirInsert(dir, *chained)
 		if err != nil {
 			return fmt.Errorf("failed to scan directory, %v", err)
 		}
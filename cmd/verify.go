@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/steabert/gopus/opus"
+)
+
+type verifyResult struct {
+	Path string `json:"path"`
+	opus.VerifyReport
+	Error string `json:"error,omitempty"`
+}
+
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "output the report as JSON")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.NArg() == 0 {
+		usage()
+		return errors.New("no arguments, expected at least 1 file to verify")
+	}
+
+	failed := 0
+	results := make([]verifyResult, 0, flags.NArg())
+	for _, path := range flags.Args() {
+		result := verifyResult{Path: path}
+		result.VerifyReport, err = opus.Verify(path)
+		if err != nil {
+			result.Error = err.Error()
+		}
+		if err != nil || !result.OK() {
+			failed++
+		}
+
+		if !*asJSON {
+			printVerifyResult(result)
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(results)
+		if err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed verification", failed, len(results))
+	}
+
+	return nil
+}
+
+func printVerifyResult(result verifyResult) {
+	switch {
+	case result.Error != "":
+		fmt.Printf("[ERROR] %s, %s\n", result.Path, result.Error)
+	case result.OK():
+		fmt.Printf("[OK] %s (%d pages, %d streams)\n", result.Path, result.Pages, result.Streams)
+	default:
+		fmt.Printf("[FAIL] %s (%d problems)\n", result.Path, len(result.Problems))
+		for _, problem := range result.Problems {
+			fmt.Printf("    %v\n", problem)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steabert/gopus/rds"
+	"github.com/steabert/gopus/worker"
+)
+
+func warnings(args []string) error {
+	if len(args) != 0 {
+		usage()
+		return errors.New("unexpected arguments, expected none")
+	}
+
+	err := rds.Open("ro")
+	if err != nil {
+		return fmt.Errorf("failed to open database, %v", err)
+	}
+
+	warnings, err := worker.ListWarnings()
+	if err != nil {
+		return err
+	}
+
+	for _, warning := range warnings {
+		fmt.Printf("[WARN] %s, %s\n", warning.Path, warning.Message)
+	}
+
+	return nil
+}
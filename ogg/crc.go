@@ -0,0 +1,52 @@
+package ogg
+
+import (
+	bin "encoding/binary"
+)
+
+// The Ogg checksum is a 32-bit CRC with generator polynomial 0x04c11db7,
+// an initial value of 0, and no final XOR or bit reflection. It is computed
+// over the entire page (header and body) with the CRC_checksum field set
+// to zero.
+
+const ogg_crc_polynomial = 0x04c11db7
+
+// crcTables holds the lookup tables for computing the CRC eight bytes at a
+// time ("slicing-by-8"). crcTables[0] is the regular byte-wise table, and
+// crcTables[k] advances a byte through k additional zero bytes.
+var crcTables = func() (tables [8][256]uint32) {
+	for i := range tables[0] {
+		r := uint32(i) << 24
+		for range 8 {
+			if r&0x80000000 != 0 {
+				r = (r << 1) ^ ogg_crc_polynomial
+			} else {
+				r <<= 1
+			}
+		}
+		tables[0][i] = r
+	}
+	for k := 1; k < len(tables); k++ {
+		for i := range tables[k] {
+			r := tables[k-1][i]
+			tables[k][i] = (r << 8) ^ tables[0][r>>24]
+		}
+	}
+	return tables
+}()
+
+// crcUpdate adds the bytes in p to the running checksum crc.
+func crcUpdate(crc uint32, p []byte) uint32 {
+	t := &crcTables
+	for len(p) >= 8 {
+		hi := crc ^ bin.BigEndian.Uint32(p[0:])
+		lo := bin.BigEndian.Uint32(p[4:])
+		crc = t[7][hi>>24] ^ t[6][(hi>>16)&0xff] ^ t[5][(hi>>8)&0xff] ^ t[4][hi&0xff] ^
+			t[3][lo>>24] ^ t[2][(lo>>16)&0xff] ^ t[1][(lo>>8)&0xff] ^ t[0][lo&0xff]
+		p = p[8:]
+	}
+	for _, b := range p {
+		crc = (crc << 8) ^ t[0][byte(crc>>24)^b]
+	}
+	return crc
+}
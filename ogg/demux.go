@@ -0,0 +1,135 @@
+package ogg
+
+import (
+	"io"
+)
+
+// PacketHandler consumes the packets of a logical bitstream.
+type PacketHandler func(packet *Packet) error
+
+// Demuxer separates the interleaved logical bitstreams of an OGG stream by
+// serial number.
+type Demuxer struct {
+	// NewStream is called with the first packet of every logical bitstream
+	// found on a "Beginning Of Stream" page. It returns the handler that
+	// receives the packets of that stream, or nil to ignore the stream.
+	NewStream func(packet *Packet) PacketHandler
+
+	pages    *PageReader
+	page     Page
+	streams  map[uint32]*demuxStream
+	order    []uint32
+	current  *demuxStream
+	handlers map[uint32]PacketHandler
+}
+
+type demuxStream struct {
+	a     assembler
+	ended bool
+}
+
+// NewDemuxer returns a Demuxer reading pages from r.
+func NewDemuxer(r io.Reader) *Demuxer {
+	return &Demuxer{
+		pages:    NewPageReader(r),
+		streams:  make(map[uint32]*demuxStream),
+		handlers: make(map[uint32]PacketHandler),
+	}
+}
+
+// Streams returns the serial numbers of the logical bitstreams seen so far,
+// in the order their first page appeared.
+func (d *Demuxer) Streams() []uint32 {
+	return d.order
+}
+
+// Page returns the last page read.
+func (d *Demuxer) Page() *Page {
+	return &d.page
+}
+
+// Pages returns the underlying page reader, for example to enable its
+// recovery mode or to query page offsets.
+func (d *Demuxer) Pages() *PageReader {
+	return d.pages
+}
+
+// ReadPacket reads the next packet of any logical bitstream, in the order
+// in which packets are completed in the OGG stream. The packet's serial
+// number identifies its stream. Pages of streams whose "Beginning Of
+// Stream" page was never seen are ignored.
+//
+// It returns io.EOF when there are no more packets, and
+// io.ErrUnexpectedEOF when the stream ends in the middle of a packet.
+func (d *Demuxer) ReadPacket(packet *Packet) error {
+	for {
+		if d.current != nil && d.current.a.next(packet) {
+			return nil
+		}
+
+		err := d.pages.ReadPage(&d.page)
+		if err == io.EOF {
+			for _, serial := range d.order {
+				stream := d.streams[serial]
+				if stream.a.inPacket {
+					return io.ErrUnexpectedEOF
+				}
+			}
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+
+		stream, found := d.streams[d.page.SerialNumber]
+		if !found || (stream.ended && d.page.FirstPage) {
+			if !d.page.FirstPage {
+				continue
+			}
+			if !found {
+				d.order = append(d.order, d.page.SerialNumber)
+			}
+			stream = &demuxStream{}
+			d.streams[d.page.SerialNumber] = stream
+		}
+
+		stream.a.addPage(&d.page)
+		if d.page.LastPage {
+			stream.ended = true
+		}
+		d.current = stream
+	}
+}
+
+// Run reads every packet of the OGG stream and routes it to the handler
+// of its logical bitstream, as returned by NewStream. It stops at the end
+// of the stream or at the first error returned by a handler.
+func (d *Demuxer) Run() error {
+	var packet Packet
+	for {
+		err := d.ReadPacket(&packet)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		handler, found := d.handlers[packet.SerialNumber]
+		if packet.FirstPacket || !found {
+			handler = nil
+			if d.NewStream != nil && packet.FirstPacket {
+				handler = d.NewStream(&packet)
+			}
+			d.handlers[packet.SerialNumber] = handler
+		}
+		if handler == nil {
+			continue
+		}
+
+		err = handler(&packet)
+		if err != nil {
+			return err
+		}
+	}
+}
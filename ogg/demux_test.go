@@ -0,0 +1,104 @@
+package ogg
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// interleavedStream returns a stream of two logical bitstreams, serials 1
+// and 2, with their pages interleaved, and the pages of serial 3 without
+// its "Beginning Of Stream" page. Every page holds one packet, whose
+// first byte is its serial number and second byte its index.
+func interleavedStream(t *testing.T) []byte {
+	t.Helper()
+	var streams [3]bytes.Buffer
+	for serial := range uint32(3) {
+		w := NewWriter(&streams[serial], serial+1)
+		for i := range 3 {
+			err := w.WritePacket([]byte{byte(serial + 1), byte(i)}, int64(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.Flush()
+		}
+		w.Close()
+	}
+
+	first := splitPages(t, streams[0].Bytes())
+	second := splitPages(t, streams[1].Bytes())
+	unknown := splitPages(t, streams[2].Bytes())[1:]
+	var stream []byte
+	for i := range 3 {
+		stream = append(stream, first[i]...)
+		stream = append(stream, second[i]...)
+		if i < len(unknown) {
+			stream = append(stream, unknown[i]...)
+		}
+	}
+	return stream
+}
+
+func TestDemuxerRun(t *testing.T) {
+	d := NewDemuxer(bytes.NewReader(interleavedStream(t)))
+	var streams []uint32
+	received := make(map[uint32][]byte)
+	d.NewStream = func(packet *Packet) PacketHandler {
+		if !packet.FirstPacket || packet.Data[1] != 0 {
+			t.Fatalf("stream %d begins with packet %x", packet.SerialNumber, packet.Data)
+		}
+		streams = append(streams, packet.SerialNumber)
+		serial := packet.SerialNumber
+		return func(packet *Packet) error {
+			if packet.SerialNumber != serial || packet.Data[0] != byte(serial) {
+				t.Fatalf("packet %x of stream %d routed to stream %d", packet.Data, packet.SerialNumber, serial)
+			}
+			received[serial] = append(received[serial], packet.Data[1])
+			return nil
+		}
+	}
+	err := d.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(streams) != 2 || streams[0] != 1 || streams[1] != 2 {
+		t.Fatalf("streams %v, expected [1 2]", streams)
+	}
+	for _, serial := range []uint32{1, 2} {
+		if !bytes.Equal(received[serial], []byte{0, 1, 2}) {
+			t.Fatalf("stream %d received packets %v", serial, received[serial])
+		}
+	}
+	// The stream without a "Beginning Of Stream" page is ignored.
+	if len(received[3]) != 0 || len(d.Streams()) != 2 {
+		t.Fatalf("unknown stream received packets %v, streams %v", received[3], d.Streams())
+	}
+}
+
+func TestDemuxerRunIgnoreAndFail(t *testing.T) {
+	// A stream without a handler is skipped, and an error of a handler
+	// stops the demuxer.
+	d := NewDemuxer(bytes.NewReader(interleavedStream(t)))
+	errStop := errors.New("stop")
+	packets := 0
+	d.NewStream = func(packet *Packet) PacketHandler {
+		if packet.SerialNumber == 1 {
+			return nil
+		}
+		return func(packet *Packet) error {
+			if packet.SerialNumber != 2 {
+				t.Fatalf("packet of stream %d routed to stream 2", packet.SerialNumber)
+			}
+			packets++
+			if packets == 2 {
+				return errStop
+			}
+			return nil
+		}
+	}
+	err := d.Run()
+	if err != errStop || packets != 2 {
+		t.Fatalf("Run returned %v after %d packets", err, packets)
+	}
+}
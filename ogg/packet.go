@@ -0,0 +1,193 @@
+package ogg
+
+import (
+	"io"
+)
+
+// Packet is a single packet of a logical bitstream, reassembled from the
+// lacing values of one or more pages.
+type Packet struct {
+	Data []byte
+	// GranulePosition is the granule position of the page on which the
+	// packet ends if it is the last packet to end on that page, and -1
+	// otherwise.
+	GranulePosition int64
+	SerialNumber    uint32
+	// FirstPacket is set for the first packet of a logical bitstream,
+	// i.e. the packet starting on the "Beginning Of Stream" page.
+	FirstPacket bool
+	// LastPacket is set for the last packet of a logical bitstream,
+	// i.e. the last packet ending on the "End Of Stream" page.
+	LastPacket bool
+	// Gap is set when pages of the logical bitstream are missing before
+	// the packet, as seen from their sequence numbers. Packets may have
+	// been lost with them.
+	Gap bool
+}
+
+// assembler reassembles the packets of a single logical bitstream from
+// its pages.
+type assembler struct {
+	data        []byte
+	inPacket    bool
+	firstPacket bool
+	packets     []Packet
+
+	// sequence is the sequence number of the last page added, and gap is
+	// set when pages are missing since the last completed packet.
+	sequence uint32
+	started  bool
+	gap      bool
+}
+
+// addPage splits a page into packets, appending every packet that ends
+// on the page to the queue of completed packets.
+func (a *assembler) addPage(page *Page) {
+	segment, offset := 0, 0
+
+	if a.started && !page.FirstPage && page.SequenceNumber != a.sequence+1 {
+		// Pages are missing, the packet in progress cannot be completed
+		// and the continued segments of this page belong to a packet
+		// whose start is lost.
+		a.data = nil
+		a.inPacket = false
+		a.gap = true
+	}
+	a.sequence = page.SequenceNumber
+	a.started = true
+
+	if page.Continued && !a.inPacket {
+		// The start of this packet was never seen (for example after
+		// seeking), drop the continued segments.
+		for segment < len(page.Segments) {
+			lacing_value := int(page.Segments[segment])
+			segment++
+			offset += lacing_value
+			if lacing_value < 255 {
+				break
+			}
+		}
+	} else if !page.Continued && a.inPacket {
+		// The page holding the rest of the packet is missing, drop the
+		// incomplete packet.
+		a.data = nil
+		a.inPacket = false
+	}
+
+	completed := len(a.packets)
+	for segment < len(page.Segments) {
+		if !a.inPacket {
+			a.firstPacket = page.FirstPage && segment == 0
+			a.inPacket = true
+		}
+
+		lacing_value := int(page.Segments[segment])
+		a.data = append(a.data, page.Body[offset:offset+lacing_value]...)
+		segment++
+		offset += lacing_value
+
+		if lacing_value < 255 {
+			a.packets = append(a.packets, Packet{
+				Data:            a.data,
+				GranulePosition: -1,
+				SerialNumber:    page.SerialNumber,
+				FirstPacket:     a.firstPacket,
+				Gap:             a.gap,
+			})
+			a.data = nil
+			a.inPacket = false
+			a.gap = false
+		}
+	}
+
+	if len(a.packets) > completed {
+		last := &a.packets[len(a.packets)-1]
+		last.GranulePosition = page.GranulePosition
+		last.LastPacket = page.LastPage
+	}
+}
+
+// next pops the oldest completed packet from the queue.
+func (a *assembler) next(packet *Packet) bool {
+	if len(a.packets) == 0 {
+		return false
+	}
+	*packet = a.packets[0]
+	a.packets[0] = Packet{}
+	a.packets = a.packets[1:]
+	return true
+}
+
+// PacketReader reads the packets of a single logical bitstream from an
+// OGG stream. It follows the serial number of the first page it reads,
+// skipping pages of other multiplexed streams. Once that stream has ended,
+// it follows the next stream that begins (a chained stream).
+type PacketReader struct {
+	pages   *PageReader
+	page    Page
+	a       assembler
+	serial  uint32
+	started bool
+	ended   bool
+}
+
+// NewPacketReader returns a PacketReader reading pages from r.
+func NewPacketReader(r io.Reader) *PacketReader {
+	return &PacketReader{pages: NewPageReader(r)}
+}
+
+// Pages returns the underlying page reader, for example to enable its
+// recovery mode or to query page offsets.
+func (pr *PacketReader) Pages() *PageReader {
+	return pr.pages
+}
+
+// Page returns the last page read.
+func (pr *PacketReader) Page() *Page {
+	return &pr.page
+}
+
+// ReadPacket reads the next packet. It returns io.EOF when there are no
+// more packets, and io.ErrUnexpectedEOF when the stream ends in the middle
+// of a packet. Packets with parts on missing pages are dropped, and the
+// next packet has Gap set.
+func (pr *PacketReader) ReadPacket(packet *Packet) error {
+	for !pr.a.next(packet) {
+		err := pr.nextPage()
+		if err == io.EOF && pr.a.inPacket {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		pr.a.addPage(&pr.page)
+	}
+	return nil
+}
+
+// nextPage reads pages until one belonging to the followed logical
+// bitstream is found.
+func (pr *PacketReader) nextPage() error {
+	for {
+		err := pr.pages.ReadPage(&pr.page)
+		if err != nil {
+			return err
+		}
+
+		if !pr.started || (pr.ended && pr.page.FirstPage) {
+			pr.serial = pr.page.SerialNumber
+			pr.started = true
+			pr.ended = false
+		}
+
+		if pr.page.SerialNumber != pr.serial {
+			continue
+		}
+
+		if pr.page.LastPage {
+			pr.ended = true
+		}
+
+		return nil
+	}
+}
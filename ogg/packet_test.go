@@ -0,0 +1,120 @@
+package ogg
+
+import (
+	"bytes"
+	bin "encoding/binary"
+	"io"
+	"testing"
+)
+
+// encodeTestPage returns the bytes of a page with its checksum. The lacing
+// values of the page are the sizes of the segments given.
+func encodeTestPage(page Page, segments ...[]byte) []byte {
+	b := []byte("OggS\x00")
+	var header_type byte
+	if page.Continued {
+		header_type |= 0x01
+	}
+	if page.FirstPage {
+		header_type |= 0x02
+	}
+	if page.LastPage {
+		header_type |= 0x04
+	}
+	b = append(b, header_type)
+	b = bin.LittleEndian.AppendUint64(b, uint64(page.GranulePosition))
+	b = bin.LittleEndian.AppendUint32(b, page.SerialNumber)
+	b = bin.LittleEndian.AppendUint32(b, page.SequenceNumber)
+	b = bin.LittleEndian.AppendUint32(b, 0)
+	b = append(b, byte(len(segments)))
+	for _, segment := range segments {
+		b = append(b, byte(len(segment)))
+	}
+	for _, segment := range segments {
+		b = append(b, segment...)
+	}
+	bin.LittleEndian.PutUint32(b[22:], crcUpdate(0, b))
+	return b
+}
+
+func TestPacketReaderMissingPage(t *testing.T) {
+	// Packet 1 spans pages 1 to 3, page 2 is removed.
+	fill := func(size int, b byte) []byte {
+		return bytes.Repeat([]byte{b}, size)
+	}
+	pages := [][]byte{
+		encodeTestPage(Page{SequenceNumber: 0, FirstPage: true}, fill(10, 0)),
+		encodeTestPage(Page{SequenceNumber: 1, GranulePosition: -1}, fill(255, 1), fill(255, 1)),
+		encodeTestPage(Page{SequenceNumber: 2, GranulePosition: -1, Continued: true}, fill(255, 1), fill(255, 1)),
+		encodeTestPage(Page{SequenceNumber: 3, GranulePosition: 2, Continued: true}, fill(100, 1), fill(20, 2)),
+		encodeTestPage(Page{SequenceNumber: 4, GranulePosition: 3, LastPage: true}, fill(30, 3)),
+	}
+	stream := bytes.Join(append(pages[:2:2], pages[3:]...), nil)
+
+	pr := NewPacketReader(bytes.NewReader(stream))
+	var packet Packet
+	err := pr.ReadPacket(&packet)
+	if err != nil || len(packet.Data) != 10 || packet.Gap {
+		t.Fatalf("packet 0: %v, %d bytes, gap %v", err, len(packet.Data), packet.Gap)
+	}
+	for _, expected := range []struct {
+		data []byte
+		gap  bool
+	}{{fill(20, 2), true}, {fill(30, 3), false}} {
+		err = pr.ReadPacket(&packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(packet.Data, expected.data) || packet.Gap != expected.gap {
+			t.Fatalf("expected %d bytes of %d, got %x with gap %v", len(expected.data), expected.data[0], packet.Data, packet.Gap)
+		}
+	}
+	err = pr.ReadPacket(&packet)
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestPacketReaderChained(t *testing.T) {
+	// The sequence numbers of the second link start over.
+	var stream []byte
+	for serial := range uint32(2) {
+		for i := range uint32(3) {
+			page := Page{SerialNumber: serial, SequenceNumber: i, GranulePosition: int64(i), FirstPage: i == 0, LastPage: i == 2}
+			stream = append(stream, encodeTestPage(page, []byte{byte(i)})...)
+		}
+	}
+
+	pr := NewPacketReader(bytes.NewReader(stream))
+	var packet Packet
+	for i := range 6 {
+		err := pr.ReadPacket(&packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet.SerialNumber != uint32(i/3) || packet.FirstPacket != (i%3 == 0) || packet.Gap {
+			t.Fatalf("packet %d: serial %d, first %v, gap %v", i, packet.SerialNumber, packet.FirstPacket, packet.Gap)
+		}
+	}
+}
+
+func BenchmarkPacketReader(b *testing.B) {
+	stream := benchmarkStream(b)
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var packet Packet
+	for range b.N {
+		pr := NewPacketReader(bytes.NewReader(stream))
+		for {
+			err := pr.ReadPacket(&packet)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -1,10 +1,10 @@
 package ogg
 
 import (
+	bin "encoding/binary"
 	"errors"
+	"fmt"
 	"io"
-
-	"github.com/steabert/gopus/binary"
 )
 
 const (
@@ -12,8 +12,27 @@ const (
 	ogg_page_header_magic_sig = 0x5367674f // "OggS"
 )
 
+// ErrChecksum is returned (wrapped in a ChecksumError) when the CRC
+// computed over a page does not match its CRC_checksum field.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// ChecksumError describes a page whose checksum does not match its contents.
+type ChecksumError struct {
+	Expected uint32
+	Computed uint32
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("%v, expected %08x, computed %08x", ErrChecksum, e.Expected, e.Computed)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return ErrChecksum
+}
+
 type Page struct {
 	Body            []byte
+	Segments        []byte
 	GranulePosition int64
 	SerialNumber    uint32
 	SequenceNumber  uint32
@@ -24,12 +43,57 @@ type Page struct {
 	Complete        bool
 }
 
-// ParsePage parses a single page of an OGG stream.
+// ParsePage parses a single page of an OGG stream and verifies its
+// checksum. On a mismatch the page is still filled in and a *ChecksumError
+// is returned.
 func ParsePage(r io.Reader, page *Page) error {
-	var err error
+	return parsePage(r, page, true)
+}
+
+// ParsePageUnchecked parses a single page of an OGG stream without
+// verifying its checksum, for tools that salvage damaged streams.
+func ParsePageUnchecked(r io.Reader, page *Page) error {
+	return parsePage(r, page, false)
+}
+
+func parsePage(r io.Reader, page *Page, verify bool) error {
+	// The header and segment table are read into a fixed buffer first,
+	// so the page can be read into a single allocation of the right size.
+	header := [ogg_page_header_size + ogg_max_page_segments]byte{}
+	_, err := io.ReadFull(r, header[:ogg_page_header_size])
+	if err != nil {
+		return err
+	}
 
-	br := binary.NewReader(r)
+	err = checkHeader(header[:])
+	if err != nil {
+		return err
+	}
+
+	header_size := ogg_page_header_size + int(header[ogg_page_header_size-1])
+	_, err = io.ReadFull(r, header[ogg_page_header_size:header_size])
+	if err != nil {
+		return err
+	}
+
+	page_size := header_size
+	for _, lacing_value := range header[ogg_page_header_size:header_size] {
+		page_size += int(lacing_value)
+	}
+
+	b := make([]byte, page_size)
+	copy(b, header[:header_size])
+	_, err = io.ReadFull(r, b[header_size:])
+	if err != nil {
+		return err
+	}
 
+	return decodePage(b, page, verify)
+}
+
+// decodePage decodes the page held in b, which must contain the complete
+// page. The page's Segments and Body point into b.
+func decodePage(b []byte, page *Page, verify bool) error {
 	//	0                   1                   2                   3
 	//	0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1| Byte
 	//
@@ -50,52 +114,70 @@ func ParsePage(r io.Reader, page *Page) error {
 	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
 	// | ...                                                           | 28-
 	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
-	capture_pattern := br.ReadUint32()
-	version := br.ReadUint8()
-	header_type := br.ReadUint8()
-	granule_position := br.ReadUint64()
-	serial_number := br.ReadUint32()
-	sequence_number := br.ReadUint32()
-	crc_checksum := br.ReadUint32()
-	page_segments := br.ReadUint8()
-
-	if br.Err() != nil {
-		return br.Err()
+	if len(b) < ogg_page_header_size {
+		return io.ErrUnexpectedEOF
 	}
 
-	if capture_pattern != ogg_page_header_magic_sig {
-		return errors.New("expected magic string OggS")
+	err := checkHeader(b)
+	if err != nil {
+		return err
 	}
 
-	if version != 0 {
-		return errors.New("expected version to be 0")
+	header_type := b[5]
+	granule_position := bin.LittleEndian.Uint64(b[6:])
+	serial_number := bin.LittleEndian.Uint32(b[14:])
+	sequence_number := bin.LittleEndian.Uint32(b[18:])
+	crc_checksum := bin.LittleEndian.Uint32(b[22:])
+	page_segments := int(b[26])
+
+	if len(b) < ogg_page_header_size+page_segments {
+		return io.ErrUnexpectedEOF
+	}
+	segment_table := b[ogg_page_header_size : ogg_page_header_size+page_segments]
+
+	page_size := len(segment_table)
+	for _, lacing_value := range segment_table {
+		page_size += int(lacing_value)
+	}
+	if len(b) != ogg_page_header_size+page_size {
+		return io.ErrUnexpectedEOF
 	}
 
-	page.Continued = (header_type & 0x01) == 0x01
-	page.FirstPage = (header_type & 0x02) == 0x02
-	page.LastPage = (header_type & 0x04) == 0x04
+	page.Continued = (header_type & ogg_header_continued) != 0
+	page.FirstPage = (header_type & ogg_header_first_page) != 0
+	page.LastPage = (header_type & ogg_header_last_page) != 0
 	page.GranulePosition = int64(granule_position)
 	page.SerialNumber = serial_number
 	page.SequenceNumber = sequence_number
 	page.Checksum = crc_checksum
-
-	segment_table := make([]byte, page_segments)
-	_, err = io.ReadFull(r, segment_table)
-	if err != nil {
-		return err
+	page.Segments = segment_table
+	page.Body = b[ogg_page_header_size+page_segments:]
+	page.Complete = page_segments > 0 && segment_table[page_segments-1] < 255
+
+	if verify {
+		// The checksum is computed with the checksum field zeroed.
+		crc := crcUpdate(0, b[:22])
+		crc = crcUpdate(crc, []byte{0, 0, 0, 0})
+		crc = crcUpdate(crc, b[26:])
+		if crc != crc_checksum {
+			return &ChecksumError{Expected: crc_checksum, Computed: crc}
+		}
 	}
 
-	page.Complete = segment_table[len(segment_table)-1] < 255
+	return nil
+}
 
-	page_size := 0
-	for _, lacing_value := range segment_table {
-		page_size += int(lacing_value)
+// checkHeader checks the capture pattern and version of a page header.
+func checkHeader(b []byte) error {
+	capture_pattern := bin.LittleEndian.Uint32(b[0:])
+	version := b[4]
+
+	if capture_pattern != ogg_page_header_magic_sig {
+		return errors.New("expected magic string OggS")
 	}
 
-	page.Body = make([]byte, page_size)
-	_, err = io.ReadFull(r, page.Body)
-	if err != nil {
-		return err
+	if version != 0 {
+		return errors.New("expected version to be 0")
 	}
 
 	return nil
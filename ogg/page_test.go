@@ -0,0 +1,84 @@
+package ogg
+
+import (
+	"bytes"
+	bin "encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestParsePageChecksum(t *testing.T) {
+	b := encodeTestPage(Page{SerialNumber: 7, SequenceNumber: 3, GranulePosition: 960}, []byte("opus"), []byte("data"))
+	checksum := bin.LittleEndian.Uint32(b[22:])
+
+	var page Page
+	err := ParsePage(bytes.NewReader(b), &page)
+	if err != nil || page.Checksum != checksum || string(page.Body) != "opusdata" {
+		t.Fatalf("intact page: %v, checksum %08x, body %q", err, page.Checksum, page.Body)
+	}
+
+	// A byte of the body and a byte of the granule position.
+	for _, offset := range []int{len(b) - 1, 6} {
+		damaged := bytes.Clone(b)
+		damaged[offset] ^= 0x01
+
+		err := ParsePage(bytes.NewReader(damaged), &page)
+		var checksum_err *ChecksumError
+		if !errors.As(err, &checksum_err) || !errors.Is(err, ErrChecksum) {
+			t.Fatalf("byte %d: expected a *ChecksumError, got %v", offset, err)
+		}
+		if checksum_err.Expected != checksum || checksum_err.Computed == checksum {
+			t.Fatalf("byte %d: expected %08x, computed %08x", offset, checksum_err.Expected, checksum_err.Computed)
+		}
+
+		var unchecked Page
+		err = ParsePageUnchecked(bytes.NewReader(damaged), &unchecked)
+		if err != nil {
+			t.Fatalf("byte %d: unchecked: %v", offset, err)
+		}
+		if unchecked.SerialNumber != 7 || unchecked.SequenceNumber != 3 || len(unchecked.Body) != 8 {
+			t.Fatalf("byte %d: unchecked page %+v", offset, unchecked)
+		}
+	}
+}
+
+// benchmarkStream returns a stream of 1000 pages of about 1 kB, with 4
+// packets each.
+func benchmarkStream(b *testing.B) []byte {
+	b.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	for i := range 4000 {
+		err := w.WritePacket(bytes.Repeat([]byte{byte(i)}, 250+i%10), int64(i))
+		if err != nil {
+			b.Fatal(err)
+		}
+		if i%4 == 3 {
+			w.Flush()
+		}
+	}
+	w.Close()
+	return buf.Bytes()
+}
+
+func BenchmarkParsePage(b *testing.B) {
+	stream := benchmarkStream(b)
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var page Page
+	for range b.N {
+		r := bytes.NewReader(stream)
+		for {
+			err := ParsePage(r, &page)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
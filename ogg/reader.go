@@ -0,0 +1,196 @@
+package ogg
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	ogg_max_page_size = ogg_page_header_size + ogg_max_page_segments*(1+ogg_max_lacing_value)
+)
+
+var capturePattern = []byte("OggS")
+
+// PageReader reads consecutive pages of an OGG stream and keeps track of
+// their byte offsets. Pages are parsed in place from a reusable buffer.
+type PageReader struct {
+	// Recover enables recovery mode. Instead of failing on data that is not
+	// a valid page (a missing capture pattern, a wrong version or a checksum
+	// mismatch), the reader scans forward for the next capture pattern that
+	// starts a valid page and continues from there. Skipped and Offset
+	// report the damaged region.
+	Recover bool
+	// Damaged, if set, is called in recovery mode for every region of
+	// damaged data skipped, with its byte offset and size and the error
+	// that caused the first page of the region to be rejected, such as a
+	// *ChecksumError.
+	Damaged func(offset, size int64, err error)
+
+	r       *bufio.Reader
+	offset  int64
+	page    int64
+	skipped int64
+	total   int64
+}
+
+// NewPageReader returns a PageReader reading pages from r.
+func NewPageReader(r io.Reader) *PageReader {
+	return &PageReader{r: bufio.NewReaderSize(r, ogg_max_page_size)}
+}
+
+// Reset discards any buffered data and continues reading pages from r,
+// which is positioned at the given byte offset of the stream.
+func (pr *PageReader) Reset(r io.Reader, offset int64) {
+	pr.r.Reset(r)
+	pr.offset = offset
+	pr.page = offset
+	pr.skipped = 0
+}
+
+// Offset returns the byte offset of the last page read.
+func (pr *PageReader) Offset() int64 {
+	return pr.page
+}
+
+// Skipped returns the number of bytes skipped in recovery mode before the
+// last page read (or before the end of the stream). The skipped region
+// ends at Offset.
+func (pr *PageReader) Skipped() int64 {
+	return pr.skipped
+}
+
+// TotalSkipped returns the number of bytes skipped in recovery mode since
+// the reader was created.
+func (pr *PageReader) TotalSkipped() int64 {
+	return pr.total
+}
+
+// ReadPage reads the next page. It returns io.EOF at the end of the stream.
+//
+// ReadPage does not allocate: the page's Segments and Body point into an
+// internal buffer and are only valid until the next call.
+func (pr *PageReader) ReadPage(page *Page) error {
+	pr.skipped = 0
+	var damage error
+	for {
+		pr.page = pr.offset
+
+		b, err := pr.peekPage()
+		if err == io.EOF {
+			pr.reportDamage(damage)
+			return io.EOF
+		}
+		if err == nil {
+			err = decodePage(b, page, true)
+			if err == nil || !pr.Recover {
+				pr.reportDamage(damage)
+				pr.discard(len(b))
+				return err
+			}
+		} else if err != io.ErrUnexpectedEOF || !pr.Recover {
+			return err
+		}
+
+		// Not a valid page, or a page header claiming more data than is
+		// left, which may be junk that happens to hold a capture pattern.
+		// Scan for the next capture pattern.
+		if damage == nil {
+			damage = err
+		}
+		pr.skip(1)
+		err = pr.skipToCapturePattern()
+		if err != nil {
+			pr.page = pr.offset
+			if err == io.EOF {
+				pr.reportDamage(damage)
+			}
+			return err
+		}
+	}
+}
+
+// reportDamage reports the damaged data skipped before the current
+// position, if any.
+func (pr *PageReader) reportDamage(err error) {
+	if pr.skipped > 0 && pr.Damaged != nil {
+		pr.Damaged(pr.offset-pr.skipped, pr.skipped, err)
+	}
+}
+
+// peekPage returns the bytes of the page at the current position without
+// consuming them. The page is not validated, only its size is determined.
+func (pr *PageReader) peekPage() ([]byte, error) {
+	header, err := pr.peek(ogg_page_header_size)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(header[:4], capturePattern) {
+		if pr.Recover {
+			return header, nil
+		}
+		return nil, errors.New("expected magic string OggS")
+	}
+
+	page_segments := int(header[ogg_page_header_size-1])
+	header, err = pr.peek(ogg_page_header_size + page_segments)
+	if err != nil {
+		return nil, err
+	}
+
+	page_size := len(header)
+	for _, lacing_value := range header[ogg_page_header_size:] {
+		page_size += int(lacing_value)
+	}
+
+	return pr.peek(page_size)
+}
+
+// peek returns the next n bytes, or io.EOF if there is no more data and
+// io.ErrUnexpectedEOF if there is less than n bytes of data.
+func (pr *PageReader) peek(n int) ([]byte, error) {
+	b, err := pr.r.Peek(n)
+	if err == io.EOF {
+		if len(b) == 0 && pr.r.Buffered() == 0 {
+			return nil, io.EOF
+		}
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, err
+}
+
+// skipToCapturePattern skips bytes up to the next capture pattern, or up
+// to the end of the stream, in which case it returns io.EOF.
+func (pr *PageReader) skipToCapturePattern() error {
+	for {
+		_, err := pr.r.Peek(len(capturePattern))
+		if err == io.EOF {
+			pr.skip(pr.r.Buffered())
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+
+		b, _ := pr.r.Peek(pr.r.Buffered())
+		i := bytes.Index(b, capturePattern)
+		if i >= 0 {
+			pr.skip(i)
+			return nil
+		}
+		pr.skip(len(b) - len(capturePattern) + 1)
+	}
+}
+
+func (pr *PageReader) skip(n int) {
+	pr.discard(n)
+	pr.skipped += int64(n)
+	pr.total += int64(n)
+}
+
+func (pr *PageReader) discard(n int) {
+	n, _ = pr.r.Discard(n)
+	pr.offset += int64(n)
+}
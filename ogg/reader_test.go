@@ -0,0 +1,173 @@
+package ogg
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// testStream returns a stream of count pages, with one packet each.
+func testStream(t *testing.T, count int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	for i := range count {
+		err := w.WritePacket(bytes.Repeat([]byte{byte(i)}, 100+i), int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// splitPages returns the pages of a stream.
+func splitPages(t *testing.T, stream []byte) [][]byte {
+	t.Helper()
+	var pages [][]byte
+	for len(stream) > 0 {
+		i := bytes.Index(stream[1:], capturePattern)
+		if i < 0 {
+			i = len(stream) - 1
+		}
+		pages = append(pages, stream[:i+1])
+		stream = stream[i+1:]
+	}
+	return pages
+}
+
+type damage struct {
+	offset, size int64
+	err          error
+}
+
+// readPages reads every page of a stream in recovery mode, and returns the
+// sequence numbers of the pages and the damage reported.
+func readPages(t *testing.T, stream []byte) ([]uint32, []damage) {
+	t.Helper()
+	pr := NewPageReader(bytes.NewReader(stream))
+	pr.Recover = true
+	var damaged []damage
+	pr.Damaged = func(offset, size int64, err error) {
+		damaged = append(damaged, damage{offset, size, err})
+	}
+
+	var sequences []uint32
+	var page Page
+	for {
+		err := pr.ReadPage(&page)
+		if err == io.EOF {
+			return sequences, damaged
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		sequences = append(sequences, page.SequenceNumber)
+	}
+}
+
+func TestPageReaderRecover(t *testing.T) {
+	pages := splitPages(t, testStream(t, 21))
+
+	// A capture pattern followed by a segment table claiming more data
+	// than is left in the stream.
+	fake := append([]byte("OggS\x00\x00"), make([]byte, 20)...)
+	fake = append(fake, 255)
+	fake = append(fake, bytes.Repeat([]byte{255}, 255)...)
+
+	offset := int64(len(bytes.Join(pages[:10], nil)))
+	junk := append([]byte("ID3 junk"), fake...)
+	stream := bytes.Join(append(pages[:10:10], append([][]byte{junk}, pages[10:]...)...), nil)
+
+	sequences, damaged := readPages(t, stream)
+	if len(sequences) != 21 {
+		t.Fatalf("read %d of 21 pages", len(sequences))
+	}
+	if len(damaged) != 1 || damaged[0].offset != offset || damaged[0].size != int64(len(junk)) {
+		t.Fatalf("expected %d bytes of damage at offset %d, got %+v", len(junk), offset, damaged)
+	}
+}
+
+func TestPageReaderRecoverChecksum(t *testing.T) {
+	pages := splitPages(t, testStream(t, 5))
+	offset := int64(len(bytes.Join(pages[:2], nil)))
+	size := int64(len(pages[2]))
+	pages[2] = bytes.Clone(pages[2])
+	pages[2][len(pages[2])-1] ^= 0xff
+	stream := bytes.Join(pages, nil)
+
+	sequences, damaged := readPages(t, stream)
+	if len(sequences) != 4 || sequences[2] != 3 {
+		t.Fatalf("read pages %v", sequences)
+	}
+	if len(damaged) != 1 || damaged[0].offset != offset || damaged[0].size != size {
+		t.Fatalf("expected %d bytes of damage at offset %d, got %+v", size, offset, damaged)
+	}
+	if !errors.Is(damaged[0].err, ErrChecksum) {
+		t.Fatalf("expected a checksum error, got %v", damaged[0].err)
+	}
+
+	// Without recovery, the page fails.
+	pr := NewPageReader(bytes.NewReader(stream))
+	var page Page
+	var err error
+	for err == nil {
+		err = pr.ReadPage(&page)
+	}
+	var checksum *ChecksumError
+	if !errors.As(err, &checksum) || pr.Offset() != offset {
+		t.Fatalf("expected a checksum error at offset %d, got %v at %d", offset, err, pr.Offset())
+	}
+}
+
+func TestPageReaderRecoverTruncated(t *testing.T) {
+	stream := testStream(t, 3)
+	size := int64(50)
+	stream = stream[:len(stream)-int(size)]
+
+	sequences, damaged := readPages(t, stream)
+	if len(sequences) != 2 {
+		t.Fatalf("read pages %v", sequences)
+	}
+	if len(damaged) != 1 || damaged[0].size != int64(len(stream))-damaged[0].offset {
+		t.Fatalf("expected the truncated page to be skipped, got %+v", damaged)
+	}
+
+	pr := NewPageReader(bytes.NewReader(stream))
+	var page Page
+	var err error
+	for err == nil {
+		err = pr.ReadPage(&page)
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func BenchmarkPageReader(b *testing.B) {
+	stream := benchmarkStream(b)
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	r := bytes.NewReader(stream)
+	pr := NewPageReader(r)
+	var page Page
+	for range b.N {
+		r.Reset(stream)
+		pr.Reset(r, 0)
+		for {
+			err := pr.ReadPage(&page)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
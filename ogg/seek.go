@@ -0,0 +1,198 @@
+package ogg
+
+import (
+	"errors"
+	"io"
+)
+
+// ogg_seek_linear_span is the size of the region below which bisection
+// stops and pages are scanned linearly.
+const ogg_seek_linear_span = 2 * ogg_max_page_size
+
+// SeekGranule finds the first page of the logical bitstream with the given
+// serial number whose granule position is at least granule, i.e. the page
+// on which the packet holding that granule ends. It bisects the stream by
+// granule position, resynchronising to a page boundary after every seek.
+// Pages without a granule position (-1) and pages of other streams are
+// skipped. If the stream starts with a Skeleton stream holding a keyframe
+// index for the logical bitstream, bisection starts from the keypoint
+// before the target.
+//
+// The page is stored in page and its byte offset returned, with rs
+// positioned at the start of the page. io.EOF is returned when the target
+// lies beyond the last page of the stream.
+func SeekGranule(rs io.ReadSeeker, serial uint32, granule int64, page *Page) (int64, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	start := skeletonKeypoint(rs, serial, granule)
+
+	pr := NewPageReader(rs)
+	pr.Recover = true
+
+	// The first page at or after lo has a granule position below the
+	// target, or is the page searched for. The page searched for starts
+	// before hi.
+	lo, hi := start, size
+	for hi-lo > ogg_seek_linear_span {
+		mid := lo + (hi-lo)/2
+
+		offset, err := seekNextGranule(rs, pr, mid, serial, page)
+		if err == io.EOF || (err == nil && offset >= hi) {
+			hi = mid
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if page.GranulePosition < granule {
+			lo = offset + pageSize(page)
+		} else {
+			hi = mid
+		}
+	}
+
+	// Scan linearly for the first page reaching the target.
+	_, err = rs.Seek(lo, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	pr.Reset(rs, lo)
+
+	for {
+		offset, err := nextGranule(pr, serial, page)
+		if err != nil {
+			return 0, err
+		}
+
+		if page.GranulePosition >= granule {
+			_, err = rs.Seek(offset, io.SeekStart)
+			return offset, err
+		}
+	}
+}
+
+// skeletonKeypoint returns the offset of the last keypoint before granule
+// in the Skeleton index of the logical bitstream, or 0 if there is none.
+// The time of a granule position is given by the granule rate of the
+// fisbone of the logical bitstream.
+func skeletonKeypoint(rs io.ReadSeeker, serial uint32, granule int64) int64 {
+	_, err := rs.Seek(0, io.SeekStart)
+	if err != nil {
+		return 0
+	}
+	skeleton, err := ReadSkeleton(rs)
+	if err != nil || skeleton == nil {
+		return 0
+	}
+
+	bone, index := skeleton.Bone(serial), skeleton.Index(serial)
+	if bone == nil || index == nil || bone.GranuleRateNumerator <= 0 || bone.GranuleRateDenominator <= 0 {
+		return 0
+	}
+	time := granule * bone.GranuleRateDenominator * index.TimestampDenominator / bone.GranuleRateNumerator
+
+	// The time is rounded down, the keypoint must lie strictly before the
+	// target for the page holding it not to come before the keypoint.
+	keypoint, found := index.Keypoint(time - 1)
+	if !found || keypoint.Offset <= 0 {
+		return 0
+	}
+
+	// An index that no longer matches the stream is not used.
+	_, err = rs.Seek(keypoint.Offset, io.SeekStart)
+	if err != nil {
+		return 0
+	}
+	var page Page
+	err = ParsePage(rs, &page)
+	if err != nil || page.SerialNumber != serial {
+		return 0
+	}
+	return keypoint.Offset
+}
+
+// seekNextGranule seeks to offset and returns the first page of the logical
+// bitstream that has a granule position.
+func seekNextGranule(rs io.ReadSeeker, pr *PageReader, offset int64, serial uint32, page *Page) (int64, error) {
+	_, err := rs.Seek(offset, io.SeekStart)
+	if err != nil {
+		return 0, err
+	}
+	pr.Reset(rs, offset)
+
+	return nextGranule(pr, serial, page)
+}
+
+// nextGranule reads pages until one of the logical bitstream that has a
+// granule position is found, and returns its offset.
+func nextGranule(pr *PageReader, serial uint32, page *Page) (int64, error) {
+	for {
+		err := pr.ReadPage(page)
+		if err != nil {
+			return 0, err
+		}
+
+		if page.SerialNumber == serial && page.GranulePosition != -1 {
+			return pr.Offset(), nil
+		}
+	}
+}
+
+// pageSize returns the number of bytes the page takes up in the stream.
+func pageSize(page *Page) int64 {
+	return int64(ogg_page_header_size + len(page.Segments) + len(page.Body))
+}
+
+// LastGranule returns the granule position of the last page of the logical
+// bitstream with the given serial number that has one. It only reads the
+// end of the stream: windows of increasing size are scanned, moving
+// backward from the end until a page with a granule position is found.
+func LastGranule(rs io.ReadSeeker, serial uint32) (int64, error) {
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	pr := NewPageReader(rs)
+	pr.Recover = true
+
+	var page Page
+	span := int64(ogg_max_page_size)
+	for end > 0 {
+		start := max(0, end-span)
+		_, err = rs.Seek(start, io.SeekStart)
+		if err != nil {
+			return 0, err
+		}
+		pr.Reset(rs, start)
+
+		// Pages starting in this window, but which may extend beyond it.
+		granule := int64(-1)
+		for {
+			err := pr.ReadPage(&page)
+			if err == io.EOF || (err == nil && pr.Offset() >= end) {
+				break
+			}
+			if err != nil {
+				return 0, err
+			}
+
+			if page.SerialNumber == serial && page.GranulePosition != -1 {
+				granule = page.GranulePosition
+			}
+		}
+
+		if granule != -1 {
+			return granule, nil
+		}
+
+		end = start
+		span *= 2
+	}
+
+	return 0, errors.New("no page with a granule position found")
+}
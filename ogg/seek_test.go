@@ -0,0 +1,188 @@
+package ogg
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// testPageInfo is the position of a page in a stream.
+type testPageInfo struct {
+	offset  int64
+	serial  uint32
+	granule int64
+}
+
+// seekTestStream returns a stream of about 2 MB with two logical
+// bitstreams: serial 1 has a header page with granule position 0 and
+// packets of 20 ms, of which every twentieth spans a run of pages without a
+// granule position. Serial 2 is multiplexed in with a page after every
+// page of serial 1, with granule positions far beyond those of serial 1.
+func seekTestStream(t *testing.T) ([]byte, []testPageInfo) {
+	t.Helper()
+	var audio, other bytes.Buffer
+	w := NewWriter(&audio, 1)
+	w.WritePacket([]byte("header"), 0)
+	w.Flush()
+	for i := range 200 {
+		size := 1000
+		if i%20 == 19 {
+			size = 200000
+		}
+		err := w.WritePacket(bytes.Repeat([]byte{byte(i)}, size), int64(i+1)*960)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.Close()
+
+	audio_pages := splitPages(t, audio.Bytes())
+	w = NewWriter(&other, 2)
+	for i := range audio_pages {
+		w.WritePacket([]byte{byte(i)}, 1<<40+int64(i))
+		w.Flush()
+	}
+	w.Close()
+	other_pages := splitPages(t, other.Bytes())
+
+	var stream []byte
+	for i := range audio_pages {
+		stream = append(stream, audio_pages[i]...)
+		stream = append(stream, other_pages[i]...)
+	}
+
+	var pages []testPageInfo
+	pr := NewPageReader(bytes.NewReader(stream))
+	var page Page
+	for {
+		err := pr.ReadPage(&page)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, testPageInfo{pr.Offset(), page.SerialNumber, page.GranulePosition})
+	}
+	return stream, pages
+}
+
+func TestSeekGranule(t *testing.T) {
+	stream, pages := seekTestStream(t)
+	if len(stream) < 8*ogg_seek_linear_span {
+		t.Fatalf("stream of %d bytes is too short to bisect", len(stream))
+	}
+	runs := 0
+	for i := 1; i < len(pages); i++ {
+		if pages[i].serial == 1 && pages[i].granule == -1 {
+			runs++
+		}
+	}
+	if runs < 15 {
+		t.Fatalf("only %d pages without a granule position", runs)
+	}
+
+	// The expected page is the first of serial 1 reaching the target.
+	expected := func(granule int64) (testPageInfo, bool) {
+		for _, page := range pages {
+			if page.serial == 1 && page.granule >= granule {
+				return page, true
+			}
+		}
+		return testPageInfo{}, false
+	}
+
+	targets := []int64{0, 1, 960, 961, 19 * 960, 20 * 960, 20*960 + 1, 55555, 100 * 960, 199*960 + 1, 200 * 960}
+	for _, granule := range targets {
+		want, _ := expected(granule)
+		rs := bytes.NewReader(stream)
+		var page Page
+		offset, err := SeekGranule(rs, 1, granule, &page)
+		if err != nil {
+			t.Fatalf("granule %d: %v", granule, err)
+		}
+		if offset != want.offset || page.SerialNumber != 1 || page.GranulePosition != want.granule {
+			t.Fatalf("granule %d: page at %d with granule %d, expected %d with granule %d",
+				granule, offset, page.GranulePosition, want.offset, want.granule)
+		}
+		position, _ := rs.Seek(0, io.SeekCurrent)
+		if position != offset {
+			t.Fatalf("granule %d: positioned at %d, expected %d", granule, position, offset)
+		}
+	}
+
+	// A target before the first audio page finds the header page.
+	var page Page
+	offset, err := SeekGranule(bytes.NewReader(stream), 1, -100, &page)
+	if err != nil || offset != 0 || page.GranulePosition != 0 {
+		t.Fatalf("target before the audio: page at %d with granule %d, %v", offset, page.GranulePosition, err)
+	}
+
+	// A target past the last page, or of a stream not present.
+	_, err = SeekGranule(bytes.NewReader(stream), 1, 200*960+1, &page)
+	if err != io.EOF {
+		t.Fatalf("target past the last page: expected io.EOF, got %v", err)
+	}
+	_, err = SeekGranule(bytes.NewReader(stream), 3, 0, &page)
+	if err != io.EOF {
+		t.Fatalf("unknown serial: expected io.EOF, got %v", err)
+	}
+}
+
+// trackingReader records the lowest offset read from.
+type trackingReader struct {
+	*bytes.Reader
+	lowest int64
+}
+
+func (r *trackingReader) Read(p []byte) (int, error) {
+	offset, _ := r.Seek(0, io.SeekCurrent)
+	r.lowest = min(r.lowest, offset)
+	return r.Reader.Read(p)
+}
+
+func TestLastGranule(t *testing.T) {
+	stream, _ := seekTestStream(t)
+	segment := bytes.Repeat([]byte{0x55}, 255)
+
+	// The last pages have no granule position, or belong to another
+	// stream, and fit in the first window.
+	tail := encodeTestPage(Page{Continued: true, GranulePosition: -1, SerialNumber: 1}, segment, segment, segment)
+	tail = append(tail, encodeTestPage(Page{GranulePosition: 1 << 50, SerialNumber: 2}, []byte("other"))...)
+	stream = append(stream, tail...)
+
+	r := &trackingReader{bytes.NewReader(stream), int64(len(stream))}
+	granule, err := LastGranule(r, 1)
+	if err != nil || granule != 200*960 {
+		t.Fatalf("last granule %d, %v, expected %d", granule, err, 200*960)
+	}
+	if r.lowest < int64(len(stream)-ogg_max_page_size) {
+		t.Fatalf("read from offset %d of %d bytes", r.lowest, len(stream))
+	}
+
+	// The last page with a granule position is larger than the first
+	// window, which starts inside it.
+	large := make([][]byte, ogg_max_page_segments)
+	for i := range large {
+		large[i] = segment
+	}
+	large[len(large)-1] = segment[:100]
+	offset := len(stream)
+	stream = append(stream, encodeTestPage(Page{GranulePosition: 12345, SerialNumber: 1}, large...)...)
+	stream = append(stream, tail...)
+
+	r = &trackingReader{bytes.NewReader(stream), int64(len(stream))}
+	granule, err = LastGranule(r, 1)
+	if err != nil || granule != 12345 {
+		t.Fatalf("last granule %d, %v, expected 12345", granule, err)
+	}
+	if r.lowest > int64(offset) {
+		t.Fatalf("read from offset %d, expected the window to grow to %d", r.lowest, offset)
+	}
+
+	// A stream without a page with a granule position.
+	_, err = LastGranule(bytes.NewReader(stream), 3)
+	if err == nil {
+		t.Fatal("expected an error for a stream without granule positions")
+	}
+}
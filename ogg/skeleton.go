@@ -0,0 +1,543 @@
+package ogg
+
+import (
+	"bytes"
+	bin "encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	skeleton_head_size_v3   = 64
+	skeleton_head_size_v4   = 80
+	skeleton_bone_size      = 52
+	skeleton_bone_offset    = skeleton_bone_size - 8
+	skeleton_index_size     = 42
+	skeleton_version_major  = 4
+	skeleton_version_minor  = 0
+	skeleton_head_magic_sig = "fishead\x00"
+	skeleton_bone_magic_sig = "fisbone\x00"
+	skeleton_index_sig      = "index\x00"
+)
+
+// SkeletonHead is the "fishead" packet of an Ogg Skeleton stream, which is
+// the first packet of the stream. Times are rational numbers, given as a
+// numerator and denominator.
+type SkeletonHead struct {
+	VersionMajor                uint16
+	VersionMinor                uint16
+	PresentationTimeNumerator   int64
+	PresentationTimeDenominator int64
+	BaseTimeNumerator           int64
+	BaseTimeDenominator         int64
+	UTC                         [20]byte
+	// SegmentLength is the length of the file in bytes (version 4 only).
+	SegmentLength uint64
+	// ContentOffset is the byte offset of the first non-header page
+	// (version 4 only).
+	ContentOffset uint64
+}
+
+// SkeletonBone is a "fisbone" packet of an Ogg Skeleton stream, which
+// describes one of the other logical bitstreams.
+type SkeletonBone struct {
+	SerialNumber           uint32
+	HeaderPackets          uint32
+	GranuleRateNumerator   int64
+	GranuleRateDenominator int64
+	BaseGranule            int64
+	Preroll                uint32
+	GranuleShift           uint8
+	// Headers are the message header fields, e.g. "Content-Type: audio/opus".
+	Headers []string
+}
+
+// Header returns the value of the first message header field with the
+// given (case-insensitive) name.
+func (b *SkeletonBone) Header(name string) string {
+	for _, field := range b.Headers {
+		key, value, found := strings.Cut(field, ":")
+		if found && strings.EqualFold(strings.TrimSpace(key), name) {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// Keypoint is an entry of a Skeleton keyframe index: the byte offset of a
+// page and the presentation time (numerator) from which decoding can
+// start at that page.
+type Keypoint struct {
+	Offset int64
+	Time   int64
+}
+
+// SkeletonIndex is an "index" packet of an Ogg Skeleton 4.0 stream, which
+// lists keypoints for seeking in one of the other logical bitstreams.
+type SkeletonIndex struct {
+	SerialNumber         uint32
+	TimestampDenominator int64
+	FirstSampleTime      int64
+	LastSampleTime       int64
+	Keypoints            []Keypoint
+}
+
+// Keypoint returns the last keypoint at or before the given time
+// (numerator over TimestampDenominator). Seeking can start at its offset,
+// the target is then found by reading forward. It returns false if the
+// time lies before the first keypoint.
+func (idx *SkeletonIndex) Keypoint(time int64) (Keypoint, bool) {
+	i := sort.Search(len(idx.Keypoints), func(i int) bool {
+		return idx.Keypoints[i].Time > time
+	})
+	if i == 0 {
+		return Keypoint{}, false
+	}
+	return idx.Keypoints[i-1], true
+}
+
+// Skeleton holds the packets of an Ogg Skeleton stream.
+type Skeleton struct {
+	Head    SkeletonHead
+	Bones   []SkeletonBone
+	Indexes []SkeletonIndex
+}
+
+// IsSkeleton reports whether a packet is the "fishead" packet that starts
+// an Ogg Skeleton stream.
+func IsSkeleton(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(skeleton_head_magic_sig))
+}
+
+// AddPacket parses a packet of the Skeleton stream and adds it to the
+// skeleton. The empty packet ending the stream is ignored, as are packets
+// of unknown types.
+func (s *Skeleton) AddPacket(data []byte) error {
+	switch {
+	case bytes.HasPrefix(data, []byte(skeleton_head_magic_sig)):
+		return ParseSkeletonHead(data, &s.Head)
+	case bytes.HasPrefix(data, []byte(skeleton_bone_magic_sig)):
+		var bone SkeletonBone
+		err := ParseSkeletonBone(data, &bone)
+		if err != nil {
+			return err
+		}
+		s.Bones = append(s.Bones, bone)
+	case bytes.HasPrefix(data, []byte(skeleton_index_sig)):
+		var index SkeletonIndex
+		err := ParseSkeletonIndex(data, &index)
+		if err != nil {
+			return err
+		}
+		s.Indexes = append(s.Indexes, index)
+	}
+	return nil
+}
+
+// Bone returns the fisbone describing the stream with the given serial
+// number, if any.
+func (s *Skeleton) Bone(serial uint32) *SkeletonBone {
+	for i := range s.Bones {
+		if s.Bones[i].SerialNumber == serial {
+			return &s.Bones[i]
+		}
+	}
+	return nil
+}
+
+// Index returns the keyframe index of the stream with the given serial
+// number, if any.
+func (s *Skeleton) Index(serial uint32) *SkeletonIndex {
+	for i := range s.Indexes {
+		if s.Indexes[i].SerialNumber == serial {
+			return &s.Indexes[i]
+		}
+	}
+	return nil
+}
+
+// ReadSkeleton reads the Skeleton stream at the start of r. It returns nil
+// if the first page of r does not begin a Skeleton stream. Reading stops
+// at the end of the Skeleton stream, or at the first data page of another
+// stream if the Skeleton stream does not end before it.
+func ReadSkeleton(r io.Reader) (*Skeleton, error) {
+	pr := NewPageReader(r)
+	var page Page
+	err := pr.ReadPage(&page)
+	if err != nil {
+		return nil, err
+	}
+	if !page.FirstPage || !IsSkeleton(page.Body) {
+		return nil, nil
+	}
+
+	serial := page.SerialNumber
+	var skeleton Skeleton
+	var a assembler
+	var packet Packet
+	for {
+		if page.SerialNumber == serial {
+			a.addPage(&page)
+			for a.next(&packet) {
+				err := skeleton.AddPacket(packet.Data)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if page.LastPage {
+				return &skeleton, nil
+			}
+		} else if page.GranulePosition > 0 {
+			return &skeleton, nil
+		}
+
+		err := pr.ReadPage(&page)
+		if err == io.EOF {
+			return &skeleton, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ParseSkeletonHead parses a "fishead" packet (version 3 or 4).
+func ParseSkeletonHead(data []byte, head *SkeletonHead) error {
+	//  0                   1                   2                   3
+	//  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1| Byte
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Identifier 'fishead\0'                                        | 0-3
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 4-7
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Version major                 | Version minor                 | 8-11
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Presentationtime numerator                                    | 12-15
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 16-19
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Presentationtime denominator                                  | 20-23
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 24-27
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Basetime numerator                                            | 28-31
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 32-35
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Basetime denominator                                          | 36-39
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 40-43
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | UTC                                                           | 44-47
+	// :                                                               :
+	// |                                                               | 60-63
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Segment length in bytes (version 4)                           | 64-67
+	// |                                                               | 68-71
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Content byte offset (version 4)                               | 72-75
+	// |                                                               | 76-79
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	if !bytes.HasPrefix(data, []byte(skeleton_head_magic_sig)) {
+		return errors.New("expected magic signature 'fishead'")
+	}
+	if len(data) < skeleton_head_size_v3 {
+		return io.ErrUnexpectedEOF
+	}
+
+	head.VersionMajor = bin.LittleEndian.Uint16(data[8:])
+	head.VersionMinor = bin.LittleEndian.Uint16(data[10:])
+	head.PresentationTimeNumerator = int64(bin.LittleEndian.Uint64(data[12:]))
+	head.PresentationTimeDenominator = int64(bin.LittleEndian.Uint64(data[20:]))
+	head.BaseTimeNumerator = int64(bin.LittleEndian.Uint64(data[28:]))
+	head.BaseTimeDenominator = int64(bin.LittleEndian.Uint64(data[36:]))
+	copy(head.UTC[:], data[44:64])
+	head.SegmentLength = 0
+	head.ContentOffset = 0
+
+	switch head.VersionMajor {
+	case 3:
+	case 4:
+		if len(data) < skeleton_head_size_v4 {
+			return io.ErrUnexpectedEOF
+		}
+		head.SegmentLength = bin.LittleEndian.Uint64(data[64:])
+		head.ContentOffset = bin.LittleEndian.Uint64(data[72:])
+	default:
+		return fmt.Errorf("unsupported Skeleton version %d.%d", head.VersionMajor, head.VersionMinor)
+	}
+
+	return nil
+}
+
+// ParseSkeletonBone parses a "fisbone" packet.
+func ParseSkeletonBone(data []byte, bone *SkeletonBone) error {
+	//  0                   1                   2                   3
+	//  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1| Byte
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Identifier 'fisbone\0'                                        | 0-3
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 4-7
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Offset to message header fields                               | 8-11
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Serial number                                                 | 12-15
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Number of header packets                                      | 16-19
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Granulerate numerator                                         | 20-23
+	// |                                                               | 24-27
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Granulerate denominator                                       | 28-31
+	// |                                                               | 32-35
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Basegranule                                                   | 36-39
+	// |                                                               | 40-43
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Preroll                                                       | 44-47
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Granuleshift  | Padding/future use                            | 48-51
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Message header fields ...                                     | 52-
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	if !bytes.HasPrefix(data, []byte(skeleton_bone_magic_sig)) {
+		return errors.New("expected magic signature 'fisbone'")
+	}
+	if len(data) < skeleton_bone_size {
+		return io.ErrUnexpectedEOF
+	}
+
+	headers_offset := 8 + int64(bin.LittleEndian.Uint32(data[8:]))
+	if headers_offset < skeleton_bone_size || headers_offset > int64(len(data)) {
+		return errors.New("invalid offset to message header fields")
+	}
+
+	bone.SerialNumber = bin.LittleEndian.Uint32(data[12:])
+	bone.HeaderPackets = bin.LittleEndian.Uint32(data[16:])
+	bone.GranuleRateNumerator = int64(bin.LittleEndian.Uint64(data[20:]))
+	bone.GranuleRateDenominator = int64(bin.LittleEndian.Uint64(data[28:]))
+	bone.BaseGranule = int64(bin.LittleEndian.Uint64(data[36:]))
+	bone.Preroll = bin.LittleEndian.Uint32(data[44:])
+	bone.GranuleShift = data[48]
+
+	bone.Headers = bone.Headers[:0]
+	for _, field := range strings.Split(string(data[headers_offset:]), "\r\n") {
+		if field != "" {
+			bone.Headers = append(bone.Headers, field)
+		}
+	}
+
+	return nil
+}
+
+// ParseSkeletonIndex parses an "index" packet of a Skeleton 4.0 stream.
+func ParseSkeletonIndex(data []byte, index *SkeletonIndex) error {
+	//  0                   1                   2                   3
+	//  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1| Byte
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Identifier 'index\0'                                          | 0-3
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                               | Serial number                 | 4-7
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                               | Number of keypoints           | 8-11
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                                                               | 12-15
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                               | Timestamp denominator         | 16-19
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// :                                                               :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                               | First sample time numerator   | 24-27
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// :                                                               :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                               | Last sample time numerator    | 32-35
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// :                                                               :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                               | Keypoints ...                 | 40-43
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	//
+	// Every keypoint is a pair of variable-length encoded deltas, from the
+	// previous keypoint, of the byte offset and the time numerator.
+	if !bytes.HasPrefix(data, []byte(skeleton_index_sig)) {
+		return errors.New("expected magic signature 'index'")
+	}
+	if len(data) < skeleton_index_size {
+		return io.ErrUnexpectedEOF
+	}
+
+	index.SerialNumber = bin.LittleEndian.Uint32(data[6:])
+	keypoints := int64(bin.LittleEndian.Uint64(data[10:]))
+	index.TimestampDenominator = int64(bin.LittleEndian.Uint64(data[18:]))
+	index.FirstSampleTime = int64(bin.LittleEndian.Uint64(data[26:]))
+	index.LastSampleTime = int64(bin.LittleEndian.Uint64(data[34:]))
+
+	// Every keypoint takes at least two bytes.
+	data = data[skeleton_index_size:]
+	if keypoints < 0 || keypoints > int64(len(data)/2) {
+		return errors.New("invalid number of keypoints")
+	}
+
+	index.Keypoints = make([]Keypoint, 0, keypoints)
+	var keypoint Keypoint
+	for range keypoints {
+		var offset, time int64
+		var n int
+		offset, n = readVarLength(data)
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		data = data[n:]
+		time, n = readVarLength(data)
+		if n == 0 {
+			return io.ErrUnexpectedEOF
+		}
+		data = data[n:]
+
+		keypoint.Offset += offset
+		keypoint.Time += time
+		index.Keypoints = append(index.Keypoints, keypoint)
+	}
+
+	return nil
+}
+
+// readVarLength decodes a Skeleton variable-length integer: 7 bits per
+// byte, least significant first, with the high bit set on the last byte.
+// It returns the number of bytes read, or 0 if the data ends first.
+func readVarLength(data []byte) (int64, int) {
+	var v int64
+	for i, b := range data {
+		if i >= 10 {
+			break
+		}
+		v |= int64(b&0x7f) << (7 * i)
+		if b&0x80 != 0 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
+
+// appendVarLength appends a Skeleton variable-length integer to b.
+func appendVarLength(b []byte, v int64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v&0x7f))
+		v >>= 7
+	}
+	return append(b, byte(v)|0x80)
+}
+
+// Packet encodes the fishead packet, as version 4.
+func (head *SkeletonHead) Packet() []byte {
+	data := make([]byte, skeleton_head_size_v4)
+	copy(data, skeleton_head_magic_sig)
+	bin.LittleEndian.PutUint16(data[8:], skeleton_version_major)
+	bin.LittleEndian.PutUint16(data[10:], skeleton_version_minor)
+	bin.LittleEndian.PutUint64(data[12:], uint64(head.PresentationTimeNumerator))
+	bin.LittleEndian.PutUint64(data[20:], uint64(head.PresentationTimeDenominator))
+	bin.LittleEndian.PutUint64(data[28:], uint64(head.BaseTimeNumerator))
+	bin.LittleEndian.PutUint64(data[36:], uint64(head.BaseTimeDenominator))
+	copy(data[44:64], head.UTC[:])
+	bin.LittleEndian.PutUint64(data[64:], head.SegmentLength)
+	bin.LittleEndian.PutUint64(data[72:], head.ContentOffset)
+	return data
+}
+
+// Packet encodes the fisbone packet.
+func (bone *SkeletonBone) Packet() []byte {
+	data := make([]byte, skeleton_bone_size)
+	copy(data, skeleton_bone_magic_sig)
+	bin.LittleEndian.PutUint32(data[8:], skeleton_bone_offset)
+	bin.LittleEndian.PutUint32(data[12:], bone.SerialNumber)
+	bin.LittleEndian.PutUint32(data[16:], bone.HeaderPackets)
+	bin.LittleEndian.PutUint64(data[20:], uint64(bone.GranuleRateNumerator))
+	bin.LittleEndian.PutUint64(data[28:], uint64(bone.GranuleRateDenominator))
+	bin.LittleEndian.PutUint64(data[36:], uint64(bone.BaseGranule))
+	bin.LittleEndian.PutUint32(data[44:], bone.Preroll)
+	data[48] = bone.GranuleShift
+	for _, field := range bone.Headers {
+		data = append(data, field...)
+		data = append(data, "\r\n"...)
+	}
+	return data
+}
+
+// Packet encodes the index packet.
+func (index *SkeletonIndex) Packet() []byte {
+	data := make([]byte, skeleton_index_size)
+	copy(data, skeleton_index_sig)
+	bin.LittleEndian.PutUint32(data[6:], index.SerialNumber)
+	bin.LittleEndian.PutUint64(data[10:], uint64(len(index.Keypoints)))
+	bin.LittleEndian.PutUint64(data[18:], uint64(index.TimestampDenominator))
+	bin.LittleEndian.PutUint64(data[26:], uint64(index.FirstSampleTime))
+	bin.LittleEndian.PutUint64(data[34:], uint64(index.LastSampleTime))
+
+	var previous Keypoint
+	for _, keypoint := range index.Keypoints {
+		data = appendVarLength(data, keypoint.Offset-previous.Offset)
+		data = appendVarLength(data, keypoint.Time-previous.Time)
+		previous = keypoint
+	}
+	return data
+}
+
+// SkeletonWriter writes an Ogg Skeleton stream alongside other logical
+// bitstreams. The Skeleton's "Beginning Of Stream" page must be the first
+// page of the file: WriteHead is called before the first pages of the
+// other streams are written, WriteBones after them, and Close after their
+// header pages, but before any of their data pages. Writer.Skeleton does
+// this for a single logical bitstream.
+type SkeletonWriter struct {
+	w *Writer
+}
+
+// NewSkeletonWriter returns a SkeletonWriter writing the Skeleton stream
+// with the given serial number to w.
+func NewSkeletonWriter(w io.Writer, serial uint32) *SkeletonWriter {
+	return &SkeletonWriter{w: NewWriter(w, serial)}
+}
+
+// WriteHead writes the fishead packet on its own page.
+func (sw *SkeletonWriter) WriteHead(head *SkeletonHead) error {
+	return sw.writePacket(head.Packet())
+}
+
+// WriteBones writes the fisbone packets of the skeleton and its keyframe
+// indexes, each on its own page.
+func (sw *SkeletonWriter) WriteBones(skeleton *Skeleton) error {
+	for i := range skeleton.Bones {
+		err := sw.writePacket(skeleton.Bones[i].Packet())
+		if err != nil {
+			return err
+		}
+	}
+	for i := range skeleton.Indexes {
+		err := sw.writePacket(skeleton.Indexes[i].Packet())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the empty packet that ends the Skeleton stream.
+func (sw *SkeletonWriter) Close() error {
+	err := sw.w.WritePacket(nil, 0)
+	if err != nil {
+		return err
+	}
+	return sw.w.Close()
+}
+
+func (sw *SkeletonWriter) writePacket(data []byte) error {
+	err := sw.w.WritePacket(data, 0)
+	if err != nil {
+		return err
+	}
+	return sw.w.Flush()
+}
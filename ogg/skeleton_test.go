@@ -0,0 +1,274 @@
+package ogg
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// testSkeleton returns a skeleton describing an Opus stream with serial
+// number 1, with the given keypoints in milliseconds.
+func testSkeleton(keypoints []Keypoint) *Skeleton {
+	return &Skeleton{
+		Head: SkeletonHead{
+			VersionMajor:                4,
+			PresentationTimeDenominator: 1000,
+			BaseTimeDenominator:         1000,
+			UTC:                         [20]byte{'2', '0', '2', '6'},
+			SegmentLength:               123456789,
+			ContentOffset:               4567,
+		},
+		Bones: []SkeletonBone{{
+			SerialNumber:           1,
+			HeaderPackets:          2,
+			GranuleRateNumerator:   48000,
+			GranuleRateDenominator: 1,
+			Preroll:                3840,
+			Headers:                []string{"Content-Type: audio/opus", "Role: audio/main"},
+		}},
+		Indexes: []SkeletonIndex{{
+			SerialNumber:         1,
+			TimestampDenominator: 1000,
+			FirstSampleTime:      0,
+			LastSampleTime:       4000,
+			Keypoints:            keypoints,
+		}},
+	}
+}
+
+func TestSkeletonPackets(t *testing.T) {
+	// The deltas of the offsets take up to three bytes.
+	keypoints := []Keypoint{{100, 0}, {70000, 1000}, {70001, 1020}, {3000000, 4000}}
+	skeleton := testSkeleton(keypoints)
+
+	data := skeleton.Head.Packet()
+	if !IsSkeleton(data) || len(data) != skeleton_head_size_v4 {
+		t.Fatalf("fishead packet of %d bytes", len(data))
+	}
+	var head SkeletonHead
+	err := ParseSkeletonHead(data, &head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head != skeleton.Head {
+		t.Fatalf("fishead %+v, expected %+v", head, skeleton.Head)
+	}
+
+	var bone SkeletonBone
+	err = ParseSkeletonBone(skeleton.Bones[0].Packet(), &bone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(bone, skeleton.Bones[0]) || bone.Header("content-type") != "audio/opus" {
+		t.Fatalf("fisbone %+v, expected %+v", bone, skeleton.Bones[0])
+	}
+
+	data = skeleton.Indexes[0].Packet()
+	var index SkeletonIndex
+	err = ParseSkeletonIndex(data, &index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(index, skeleton.Indexes[0]) {
+		t.Fatalf("index %+v, expected %+v", index, skeleton.Indexes[0])
+	}
+	// 100 and 0, 69900 and 1000, 1 and 20, 2930000 and 2980.
+	if len(data) != skeleton_index_size+2+5+2+6 {
+		t.Fatalf("index packet of %d bytes", len(data))
+	}
+
+	// Keypoints that do not fit in the packet.
+	for _, n := range []int{1, 3} {
+		err = ParseSkeletonIndex(data[:len(data)-n], &index)
+		if err == nil {
+			t.Fatalf("expected an error for an index truncated by %d bytes", n)
+		}
+	}
+
+	// Version 3 has no segment length and content offset.
+	data = skeleton.Head.Packet()[:skeleton_head_size_v3]
+	data[8] = 3
+	err = ParseSkeletonHead(data, &head)
+	if err != nil || head.VersionMajor != 3 || head.SegmentLength != 0 || head.ContentOffset != 0 {
+		t.Fatalf("version 3 fishead %+v, %v", head, err)
+	}
+}
+
+func TestSkeletonIndexKeypoint(t *testing.T) {
+	index := testSkeleton([]Keypoint{{100, 0}, {2000, 1000}, {5000, 2000}}).Indexes[0]
+	tests := []struct {
+		time   int64
+		offset int64
+		found  bool
+	}{
+		{-1, 0, false},
+		{0, 100, true},
+		{999, 100, true},
+		{1000, 2000, true},
+		{5000, 5000, true},
+	}
+	for _, test := range tests {
+		keypoint, found := index.Keypoint(test.time)
+		if found != test.found || keypoint.Offset != test.offset {
+			t.Errorf("time %d: keypoint %+v, %v", test.time, keypoint, found)
+		}
+	}
+}
+
+func TestWriterSkeleton(t *testing.T) {
+	skeleton := testSkeleton(nil)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	w.Skeleton = skeleton
+	w.SkeletonSerial = 7
+	w.WritePacket([]byte("OpusHead"), 0)
+	w.Flush()
+	w.WritePacket([]byte("OpusTags"), 0)
+	w.Flush()
+	w.WritePacket([]byte("audio"), 960)
+	w.Close()
+
+	// The Skeleton stream starts the file and ends before the data page.
+	type layout struct {
+		serial uint32
+		first  bool
+		last   bool
+	}
+	expected := []layout{{7, true, false}, {1, true, false}, {7, false, false}, {7, false, false}, {1, false, false}, {7, false, true}, {1, false, true}}
+	var pages []layout
+	r := bytes.NewReader(buf.Bytes())
+	for r.Len() > 0 {
+		var page Page
+		err := ParsePage(r, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, layout{page.SerialNumber, page.FirstPage, page.LastPage})
+	}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Fatalf("pages %v, expected %v", pages, expected)
+	}
+
+	read, err := ReadSkeleton(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read.Indexes[0].Keypoints = nil
+	if !reflect.DeepEqual(read, skeleton) {
+		t.Fatalf("skeleton %+v, expected %+v", read, skeleton)
+	}
+
+	// A stream without a Skeleton.
+	read, err = ReadSkeleton(bytes.NewReader(buf.Bytes()[pageOffset(t, buf.Bytes(), 1):]))
+	if read != nil || err != nil {
+		t.Fatalf("skeleton %+v, %v for a stream without one", read, err)
+	}
+}
+
+// pageOffset returns the offset of the nth page of a stream.
+func pageOffset(t *testing.T, stream []byte, n int) int64 {
+	t.Helper()
+	r := bytes.NewReader(stream)
+	var page Page
+	for range n {
+		err := ParsePage(r, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return int64(len(stream) - r.Len())
+}
+
+// readRecorder records the offsets read from.
+type readRecorder struct {
+	*bytes.Reader
+	offsets []int64
+}
+
+func (r *readRecorder) Read(p []byte) (int, error) {
+	offset, _ := r.Seek(0, io.SeekCurrent)
+	r.offsets = append(r.offsets, offset)
+	return r.Reader.Read(p)
+}
+
+func TestSeekGranuleSkeleton(t *testing.T) {
+	stream, pages := seekTestStream(t)
+
+	// Keypoints at the next page of serial 1 after every tenth page with a
+	// granule position, from which packets start at its granule position.
+	// The offsets of the keypoints depend on the size of the Skeleton
+	// stream before them.
+	var prefix []byte
+	for {
+		var keypoints []Keypoint
+		granule_pages := 0
+		for i, page := range pages[:len(pages)-1] {
+			if page.serial != 1 || page.granule == -1 {
+				continue
+			}
+			if granule_pages%10 == 0 {
+				next := i + 1
+				for pages[next].serial != 1 {
+					next++
+				}
+				keypoints = append(keypoints, Keypoint{int64(len(prefix)) + pages[next].offset, page.granule / 48})
+			}
+			granule_pages++
+		}
+
+		var buf bytes.Buffer
+		sw := NewSkeletonWriter(&buf, 7)
+		skeleton := testSkeleton(keypoints)
+		sw.WriteHead(&skeleton.Head)
+		sw.WriteBones(skeleton)
+		sw.Close()
+		size := len(prefix)
+		prefix = buf.Bytes()
+		if len(prefix) == size {
+			break
+		}
+	}
+	stream = append(prefix, stream...)
+
+	for _, granule := range []int64{960, 20*960 + 1, 100 * 960, 190 * 960} {
+		var want testPageInfo
+		for _, page := range pages {
+			if page.serial == 1 && page.granule >= granule {
+				want = page
+				want.offset += int64(len(prefix))
+				break
+			}
+		}
+
+		r := &readRecorder{Reader: bytes.NewReader(stream)}
+		var page Page
+		offset, err := SeekGranule(r, 1, granule, &page)
+		if err != nil {
+			t.Fatalf("granule %d: %v", granule, err)
+		}
+		if offset != want.offset || page.GranulePosition != want.granule {
+			t.Fatalf("granule %d: page at %d with granule %d, expected %d with granule %d",
+				granule, offset, page.GranulePosition, want.offset, want.granule)
+		}
+
+		// Besides the Skeleton at the start, nothing is read before the
+		// keypoint.
+		keypoint, _ := testSkeletonIndex(t, prefix).Keypoint(granule/48 - 1)
+		for _, read := range r.offsets {
+			if read > ogg_seek_linear_span && read < keypoint.Offset {
+				t.Fatalf("granule %d: read at %d, before the keypoint at %d", granule, read, keypoint.Offset)
+			}
+		}
+	}
+}
+
+// testSkeletonIndex returns the index of serial 1 of the Skeleton stream.
+func testSkeletonIndex(t *testing.T, stream []byte) *SkeletonIndex {
+	t.Helper()
+	skeleton, err := ReadSkeleton(bytes.NewReader(stream))
+	if err != nil || skeleton == nil {
+		t.Fatalf("skeleton %+v, %v", skeleton, err)
+	}
+	return skeleton.Index(1)
+}
@@ -0,0 +1,238 @@
+package ogg
+
+import (
+	bin "encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	ogg_max_page_segments = 255
+	ogg_default_page_size = 4096
+	ogg_header_continued  = 0x01
+	ogg_header_first_page = 0x02
+	ogg_header_last_page  = 0x04
+	ogg_max_lacing_value  = 255
+)
+
+// WritePage writes a single page to w. The page's Segments must describe
+// its Body. The checksum is computed and stored in page.Checksum.
+func WritePage(w io.Writer, page *Page) error {
+	if len(page.Segments) > ogg_max_page_segments {
+		return errors.New("too many segments in page")
+	}
+
+	body_size := 0
+	for _, lacing_value := range page.Segments {
+		body_size += int(lacing_value)
+	}
+	if body_size != len(page.Body) {
+		return errors.New("segment table does not match page body")
+	}
+
+	var header_type uint8
+	if page.Continued {
+		header_type |= ogg_header_continued
+	}
+	if page.FirstPage {
+		header_type |= ogg_header_first_page
+	}
+	if page.LastPage {
+		header_type |= ogg_header_last_page
+	}
+
+	header := make([]byte, ogg_page_header_size, ogg_page_header_size+len(page.Segments))
+	bin.LittleEndian.PutUint32(header[0:], ogg_page_header_magic_sig)
+	header[4] = 0
+	header[5] = header_type
+	bin.LittleEndian.PutUint64(header[6:], uint64(page.GranulePosition))
+	bin.LittleEndian.PutUint32(header[14:], page.SerialNumber)
+	bin.LittleEndian.PutUint32(header[18:], page.SequenceNumber)
+	header[26] = uint8(len(page.Segments))
+	header = append(header, page.Segments...)
+
+	crc := crcUpdate(0, header)
+	crc = crcUpdate(crc, page.Body)
+	bin.LittleEndian.PutUint32(header[22:], crc)
+	page.Checksum = crc
+	page.Complete = len(page.Segments) > 0 && page.Segments[len(page.Segments)-1] < 255
+
+	_, err := w.Write(header)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(page.Body)
+	return err
+}
+
+// Writer lays out the packets of a single logical bitstream into pages.
+// Pages are written out when their body reaches PageSize bytes, when the
+// segment table is full, or on demand with Flush.
+type Writer struct {
+	// PageSize is the body size at which a page is written out.
+	PageSize int
+	// Skeleton, if set before the first page is written, is written as an
+	// Ogg Skeleton stream with serial number SkeletonSerial alongside the
+	// logical bitstream. Its fishead page precedes the first page, its
+	// fisbone and index pages follow it, and it ends once the header
+	// packets counted by the fisbone of the logical bitstream are written.
+	Skeleton       *Skeleton
+	SkeletonSerial uint32
+
+	w           io.Writer
+	skeleton    *SkeletonWriter
+	headers     uint32
+	page        Page
+	started     bool
+	continued   bool
+	packetEnded bool
+	closed      bool
+	granule     int64
+}
+
+// NewWriter returns a Writer writing the logical bitstream with the given
+// serial number to w.
+func NewWriter(w io.Writer, serial uint32) *Writer {
+	return &Writer{
+		PageSize: ogg_default_page_size,
+		w:        w,
+		page:     Page{SerialNumber: serial},
+	}
+}
+
+// SequenceNumber returns the sequence number of the next page written.
+func (w *Writer) SequenceNumber() uint32 {
+	return w.page.SequenceNumber
+}
+
+// SetSequenceNumber sets the sequence number of the next page written.
+// A sequence number other than 0 continues an existing logical bitstream,
+// so the next page is not marked as the "Beginning Of Stream".
+func (w *Writer) SetSequenceNumber(sequence uint32) {
+	w.page.SequenceNumber = sequence
+	w.started = sequence != 0
+}
+
+// WritePacket adds a packet to the stream. The granule position applies
+// to the page on which the packet ends, if it is the last packet to end
+// on that page.
+func (w *Writer) WritePacket(data []byte, granule int64) error {
+	if w.closed {
+		return errors.New("write to closed writer")
+	}
+
+	started := false
+	for {
+		if len(w.page.Segments) == ogg_max_page_segments {
+			err := w.writePage(false)
+			if err != nil {
+				return err
+			}
+			// The next page only continues the packet if part of it went
+			// out on this one.
+			w.continued = started
+		}
+
+		n := min(len(data), ogg_max_lacing_value)
+		w.page.Segments = append(w.page.Segments, uint8(n))
+		w.page.Body = append(w.page.Body, data[:n]...)
+		data = data[n:]
+		started = true
+
+		if n < ogg_max_lacing_value {
+			break
+		}
+	}
+
+	w.packetEnded = true
+	w.granule = granule
+
+	if len(w.page.Body) >= w.PageSize {
+		return w.writePage(false)
+	}
+	return nil
+}
+
+// Flush writes out any buffered packets as a page, so that the next packet
+// starts on a new page.
+func (w *Writer) Flush() error {
+	if len(w.page.Segments) == 0 {
+		return nil
+	}
+	return w.writePage(false)
+}
+
+// Close writes out any buffered packets with the "End Of Stream" flag set.
+// If no packets are buffered, an empty "End Of Stream" page is written.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	err := w.writePage(true)
+	w.closed = true
+	return err
+}
+
+func (w *Writer) writePage(last bool) error {
+	if !w.started && w.Skeleton != nil {
+		w.skeleton = NewSkeletonWriter(w.w, w.SkeletonSerial)
+		err := w.skeleton.WriteHead(&w.Skeleton.Head)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.page.Continued = w.continued
+	w.page.FirstPage = !w.started
+	w.page.LastPage = last
+	w.page.GranulePosition = -1
+	if w.packetEnded {
+		w.page.GranulePosition = w.granule
+	}
+
+	err := WritePage(w.w, &w.page)
+	if err != nil {
+		return err
+	}
+
+	if w.skeleton != nil {
+		err = w.writeSkeleton(last)
+		if err != nil {
+			return err
+		}
+	}
+
+	w.started = true
+	w.continued = false
+	w.packetEnded = false
+	w.page.SequenceNumber++
+	w.page.Segments = w.page.Segments[:0]
+	w.page.Body = w.page.Body[:0]
+	return nil
+}
+
+// writeSkeleton writes the pages of the Skeleton stream that follow the
+// page just written: the fisbone and index pages after the first page,
+// and the end of the Skeleton stream after the last header packet.
+func (w *Writer) writeSkeleton(last bool) error {
+	if !w.started {
+		err := w.skeleton.WriteBones(w.Skeleton)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, lacing_value := range w.page.Segments {
+		if lacing_value < ogg_max_lacing_value {
+			w.headers++
+		}
+	}
+	bone := w.Skeleton.Bone(w.page.SerialNumber)
+	if bone != nil && w.headers < bone.HeaderPackets && !last {
+		return nil
+	}
+
+	err := w.skeleton.Close()
+	w.skeleton = nil
+	return err
+}
@@ -0,0 +1,183 @@
+package ogg
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// writePackets writes packets with the given sizes to a new stream, every
+// packet filled with its index, and returns the stream.
+func writePackets(t *testing.T, sizes []int, page_size int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1234)
+	if page_size > 0 {
+		w.PageSize = page_size
+	}
+	for i, size := range sizes {
+		err := w.WritePacket(bytes.Repeat([]byte{byte(i)}, size), int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// checkPages parses every page of a stream with ParsePage and checks the
+// sequence numbers and flags.
+func checkPages(t *testing.T, stream []byte) {
+	t.Helper()
+	r := bytes.NewReader(stream)
+	var page Page
+	for sequence := uint32(0); ; sequence++ {
+		err := ParsePage(r, &page)
+		if err == io.EOF {
+			if sequence == 0 {
+				t.Fatal("no pages")
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("page %d: %v", sequence, err)
+		}
+		if page.SequenceNumber != sequence {
+			t.Fatalf("page %d has sequence number %d", sequence, page.SequenceNumber)
+		}
+		if page.FirstPage != (sequence == 0) {
+			t.Fatalf("page %d has FirstPage %v", sequence, page.FirstPage)
+		}
+		if page.LastPage != (r.Len() == 0) {
+			t.Fatalf("page %d has LastPage %v", sequence, page.LastPage)
+		}
+	}
+}
+
+// checkPackets reads back the packets of a stream written by writePackets.
+func checkPackets(t *testing.T, stream []byte, sizes []int) {
+	t.Helper()
+	pr := NewPacketReader(bytes.NewReader(stream))
+	var packet Packet
+	for i, size := range sizes {
+		err := pr.ReadPacket(&packet)
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if !bytes.Equal(packet.Data, bytes.Repeat([]byte{byte(i)}, size)) {
+			t.Fatalf("packet %d does not match, got %d bytes, expected %d", i, len(packet.Data), size)
+		}
+		if packet.FirstPacket != (i == 0) {
+			t.Fatalf("packet %d has FirstPacket %v", i, packet.FirstPacket)
+		}
+		if packet.GranulePosition != -1 && packet.GranulePosition != int64(i) {
+			t.Fatalf("packet %d has granule position %d", i, packet.GranulePosition)
+		}
+	}
+	if packet.GranulePosition != int64(len(sizes)-1) {
+		t.Fatalf("last packet has granule position %d", packet.GranulePosition)
+	}
+	err := pr.ReadPacket(&packet)
+	if err != io.EOF {
+		t.Fatalf("expected io.EOF after %d packets, got %v", len(sizes), err)
+	}
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	repeat := func(size, count int) []int {
+		sizes := make([]int, count)
+		for i := range sizes {
+			sizes[i] = size
+		}
+		return sizes
+	}
+
+	tests := []struct {
+		name      string
+		sizes     []int
+		page_size int
+	}{
+		{"small packets", []int{1, 2, 3, 100, 7}, 0},
+		{"empty packets", []int{0, 0, 10, 0}, 0},
+		// 300 single segment packets, the 256th starts a new page.
+		{"255 segments", repeat(10, 300), 1 << 20},
+		// A packet of 255 bytes takes two segments, the second empty.
+		{"255 bytes", repeat(255, 300), 1 << 20},
+		{"254 segments and an empty one", []int{254 * 255, 1, 254 * 255, 2}, 1 << 20},
+		// 255 full segments, with the empty one on the next page.
+		{"255 full segments", []int{255 * 255, 3, 255 * 255}, 1 << 20},
+		{"page spanning packet", []int{100, 3 * 255 * 255, 100}, 0},
+		{"page size boundary", repeat(255, 40), 4 * 255},
+		{"mixed", []int{254*255 + 10, 0, 255, 510, 255*255 - 1, 1, 255 * 256}, 1000},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := writePackets(t, test.sizes, test.page_size)
+			checkPages(t, stream)
+			checkPackets(t, stream, test.sizes)
+		})
+	}
+}
+
+func TestWriterContinued(t *testing.T) {
+	// The first 255 packets fill a page, the next page starts with a new
+	// packet and must not be marked as continued.
+	sizes := make([]int, 300)
+	for i := range sizes {
+		sizes[i] = 1
+	}
+	stream := writePackets(t, sizes, 1<<20)
+
+	r := bytes.NewReader(stream)
+	var page Page
+	for i := 0; ; i++ {
+		err := ParsePage(r, &page)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if page.Continued {
+			t.Fatalf("page %d is marked as continued", i)
+		}
+	}
+}
+
+func TestWriterFlush(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1)
+	for i := range 3 {
+		err := w.WritePacket([]byte{byte(i)}, int64(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = w.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if w.SequenceNumber() != 3 {
+		t.Fatalf("wrote %d pages, expected 3", w.SequenceNumber())
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The empty "End Of Stream" page.
+	r := bytes.NewReader(buf.Bytes())
+	var page Page
+	for range 4 {
+		err = ParsePage(r, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !page.LastPage || len(page.Segments) != 0 {
+		t.Fatalf("expected an empty last page, got %d segments", len(page.Segments))
+	}
+}
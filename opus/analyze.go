@@ -0,0 +1,200 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// Analysis holds statistics on the audio packets of an Opus file. The
+// histograms count packets, by the mode, bandwidth and frame size of
+// their TOC byte and by their size in bytes. For a packet that codes
+// several streams, the TOC byte is that of the first stream.
+type Analysis struct {
+	Packets int64 `json:"packets"`
+	// Malformed counts the packets that could not be parsed, they are not
+	// part of the other statistics.
+	Malformed int64 `json:"malformed"`
+	// DTX counts the packets without any frame data, which an encoder
+	// sends during silence with discontinuous transmission.
+	DTX        int64                `json:"dtx"`
+	Stereo     int64                `json:"stereo"`
+	Modes      map[CodingMode]int64 `json:"modes"`
+	Bandwidths map[Bandwidth]int64  `json:"bandwidths"`
+	// FrameSizes is keyed by the number of samples at 48 kHz, PacketSizes
+	// by the number of bytes.
+	FrameSizes  map[int]int64 `json:"frame_sizes"`
+	PacketSizes map[int]int64 `json:"packet_sizes"`
+	// Bitrate is the bitrate in bits per second of every second of audio.
+	Bitrate  []int         `json:"bitrate"`
+	Duration time.Duration `json:"duration"`
+	// VBR is set if the packets of the same frame size differ in size,
+	// not counting DTX packets.
+	VBR bool `json:"vbr"`
+	// Warnings lists the damaged data skipped with the Recover option, and
+	// the truncation of a stream that ends in the middle of a packet.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// AverageBitrate returns the average bitrate in bits per second.
+func (a *Analysis) AverageBitrate() int {
+	total := 0
+	for _, bits := range a.Bitrate {
+		total += bits
+	}
+	if a.Duration <= 0 {
+		return 0
+	}
+	return int(float64(total) / a.Duration.Seconds())
+}
+
+// Bandwidth returns the bandwidth of most packets.
+func (a *Analysis) Bandwidth() Bandwidth {
+	best := BandwidthNarrowband
+	for b := BandwidthNarrowband; b <= BandwidthFullband; b++ {
+		if a.Bandwidths[b] > a.Bandwidths[best] {
+			best = b
+		}
+	}
+	return best
+}
+
+// Mode returns the coding mode of most packets.
+func (a *Analysis) Mode() CodingMode {
+	best := ModeSILK
+	for m := ModeSILK; m <= ModeCELT; m++ {
+		if a.Modes[m] > a.Modes[best] {
+			best = m
+		}
+	}
+	return best
+}
+
+// Analyze reads every audio packet of the Opus file at path.
+func Analyze(path string) (*Analysis, error) {
+	return ParseOptions{}.Analyze(path)
+}
+
+// AnalyzeReader reads every audio packet of the Opus streams read from r.
+func AnalyzeReader(r io.Reader) (*Analysis, error) {
+	return ParseOptions{}.AnalyzeReader(r)
+}
+
+// Analyze reads every audio packet of the Opus file at path.
+func (opts ParseOptions) Analyze(path string) (*Analysis, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return opts.AnalyzeReader(f)
+}
+
+// AnalyzeReader reads every audio packet of the Opus streams read from r.
+// The links of a chained stream are analyzed as a whole. Only the Recover
+// option applies.
+func (opts ParseOptions) AnalyzeReader(r io.Reader) (*Analysis, error) {
+	a := &Analysis{
+		Modes:       make(map[CodingMode]int64),
+		Bandwidths:  make(map[Bandwidth]int64),
+		FrameSizes:  make(map[int]int64),
+		PacketSizes: make(map[int]int64),
+	}
+
+	c := conformance{mode: opts.Mode}
+	d := ogg.NewDemuxer(r)
+	opts.recoverPages(d.Pages(), &c)
+
+	// The number of header packets read of every Opus stream, other
+	// streams are not in the map.
+	headers := make(map[uint32]int)
+	// The number of streams coded in the packets of every Opus stream. The
+	// statistics of a packet are those of its first stream, except for
+	// the size and DTX.
+	streams := make(map[uint32]int)
+	// The size of the first packet of every frame size, to tell VBR from
+	// CBR.
+	sizes := make(map[int]int)
+	var samples int64
+
+	var packet ogg.Packet
+	var opus_packet Packet
+	for {
+		err := d.ReadPacket(&packet)
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// The packets read so far are analyzed.
+			c.warn("stream is truncated, it ends in the middle of a packet")
+			break
+		}
+		if err != nil {
+			a.Warnings = c.warnings
+			return a, fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		serial := packet.SerialNumber
+		if packet.FirstPacket && bytes.HasPrefix(packet.Data, []byte("OpusHead")) {
+			headers[serial] = 0
+			// A stream with an invalid header is analyzed as having
+			// a single Opus stream.
+			var info OpusInfo
+			parseIDHeader(bytes.NewReader(packet.Data), &info, &conformance{mode: Lenient})
+			streams[serial] = max(1, int(info.StreamCount))
+		}
+		count, found := headers[serial]
+		if !found {
+			continue
+		}
+		if count < opus_header_packets {
+			headers[serial]++
+			continue
+		}
+
+		frame_bytes, err := parseMultistreamPacket(packet.Data, streams[serial], &opus_packet)
+		if err != nil {
+			a.Malformed++
+			continue
+		}
+
+		a.Packets++
+		a.Modes[opus_packet.Mode]++
+		a.Bandwidths[opus_packet.Bandwidth]++
+		a.FrameSizes[opus_packet.FrameSize]++
+		a.PacketSizes[len(packet.Data)]++
+		if opus_packet.Stereo {
+			a.Stereo++
+		}
+
+		if frame_bytes == 0 {
+			a.DTX++
+		} else if size, found := sizes[opus_packet.FrameSize]; !found {
+			sizes[opus_packet.FrameSize] = len(packet.Data)
+		} else if size != len(packet.Data) {
+			a.VBR = true
+		}
+
+		second := samples / opus_granule_rate
+		for int64(len(a.Bitrate)) <= second {
+			a.Bitrate = append(a.Bitrate, 0)
+		}
+		a.Bitrate[second] += len(packet.Data) * 8
+		samples += int64(opus_packet.Samples())
+	}
+
+	if len(headers) == 0 {
+		return nil, noStreamError(d.Pages())
+	}
+
+	a.Duration = time.Duration(samples) * time.Second / opus_granule_rate
+	a.Warnings = c.warnings
+
+	return a, nil
+}
@@ -0,0 +1,78 @@
+package opus
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+func TestAnalyzeMultistream(t *testing.T) {
+	// Three channels in a coupled and a mono stream. The packet of the
+	// first stream is self-delimited, two frames of 20 ms.
+	id := "OpusHead\x01\x03\x38\x01\x80\xbb\x00\x00\x00\x00\x01\x02\x01\x00\x01\x02"
+	first := append([]byte{0xfd, 10}, bytes.Repeat([]byte{0x55}, 20)...)
+	second := append([]byte{0xfb, 0x02}, bytes.Repeat([]byte{0x55}, 10)...)
+	packet := append(bytes.Clone(first), second...)
+
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(id), 0)
+	w.Flush()
+	w.WritePacket(testCommentHeader(), 0)
+	w.Flush()
+	for i := range 10 {
+		data := packet
+		if i == 9 {
+			// The second stream has 20 ms, the first 40 ms.
+			data = append(bytes.Clone(first), 0xf8, 0x55)
+		}
+		w.WritePacket(data, int64(i+1)*1920)
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := AnalyzeReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Packets != 9 || a.Malformed != 1 {
+		t.Fatalf("%d packets, %d malformed", a.Packets, a.Malformed)
+	}
+	if a.Stereo != 9 || a.FrameSizes[960] != 9 || a.Modes[ModeCELT] != 9 || a.PacketSizes[len(packet)] != 9 {
+		t.Fatalf("statistics %+v", a)
+	}
+	if a.Duration != 9*40*time.Millisecond {
+		t.Fatalf("duration %v", a.Duration)
+	}
+}
+
+func TestAnalyzeTruncated(t *testing.T) {
+	// The stream ends after the first page of a packet spanning two, the
+	// rest of the packet is still buffered by the writer.
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(testIDHeader), 0)
+	w.Flush()
+	w.WritePacket(testCommentHeader(), 0)
+	w.Flush()
+	for i := range 10 {
+		w.WritePacket(testAudioPacket, int64(i+1)*960)
+	}
+	w.Flush()
+	w.WritePacket(append([]byte{0xfc}, make([]byte, 66000)...), 11*960)
+
+	a, err := AnalyzeReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Packets != 10 || a.Duration != 200*time.Millisecond {
+		t.Fatalf("%d packets of %v", a.Packets, a.Duration)
+	}
+	if len(a.Warnings) != 1 || a.Warnings[0] != "stream is truncated, it ends in the middle of a packet" {
+		t.Fatalf("expected a truncation warning, got %q", a.Warnings)
+	}
+}
@@ -0,0 +1,226 @@
+package opus
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// Link is one Ogg Opus stream of a chained file, in which streams follow
+// one another, each with its own headers (RFC 7845, section 3).
+type Link struct {
+	// Offset is the byte offset of the first page of the link.
+	Offset int64
+	// Start is the time at which the link starts, the total duration of
+	// the links before it.
+	Start time.Duration
+	// Info holds the headers and the audio properties of the link.
+	Info OpusInfo
+}
+
+// ParseLinks parses every link of the chained file at path, in lenient
+// mode.
+func ParseLinks(path string) ([]Link, error) {
+	return ParseOptions{}.ParseLinks(path)
+}
+
+// ParseLinksReader parses every link of the chained stream read from r,
+// in lenient mode.
+func ParseLinksReader(r io.Reader) ([]Link, error) {
+	return ParseOptions{}.ParseLinksReader(r)
+}
+
+// ParseLinksFS parses every link of the named chained file in fsys, in
+// lenient mode.
+func ParseLinksFS(fsys fs.FS, name string) ([]Link, error) {
+	return ParseOptions{}.ParseLinksFS(fsys, name)
+}
+
+// ParseLinks parses every link of the chained file at path.
+func (opts ParseOptions) ParseLinks(path string) ([]Link, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return opts.ParseLinksReader(f)
+}
+
+// ParseLinksFS parses every link of the named chained file in fsys.
+func (opts ParseOptions) ParseLinksFS(fsys fs.FS, name string) ([]Link, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return opts.ParseLinksReader(f)
+}
+
+// ParseLinksReader parses every link of the chained stream read from r.
+// The whole stream is read, so the audio properties of every link are
+// determined. A file that is not chained has a single link.
+//
+// A link starts at every "Beginning Of Stream" page of an Opus stream.
+// Opus streams multiplexed with each other, whose "Beginning Of Stream"
+// pages come before any data, each have a link, which share the start.
+// Other streams, such as a Skeleton stream, are skipped.
+func (opts ParseOptions) ParseLinksReader(r io.Reader) ([]Link, error) {
+	d := ogg.NewDemuxer(r)
+
+	// Damaged data is reported with the link of the next packet read, or
+	// with the last link if no packet of a link follows it.
+	var damage conformance
+	opts.recoverPages(d.Pages(), &damage)
+
+	// parsers holds the links in order, streams the links that have not
+	// ended by serial number. The links of a group are multiplexed, they
+	// start at the same time.
+	var parsers []*linkParser
+	streams := make(map[uint32]*linkParser)
+	var group []*linkParser
+	grouping := false
+	var start time.Duration
+
+	var packet ogg.Packet
+	for {
+		err := d.ReadPacket(&packet)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return links(parsers), fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		if packet.FirstPacket && bytes.HasPrefix(packet.Data, []byte("OpusHead")) {
+			if !grouping {
+				// A new group follows the previous one, which ends with
+				// its longest link.
+				for _, p := range group {
+					p.finish()
+					start = max(start, p.link.Start+p.link.Info.Duration)
+				}
+				for serial := range streams {
+					delete(streams, serial)
+				}
+				group = nil
+				grouping = true
+			}
+
+			p := &linkParser{
+				link:   Link{Offset: d.Pages().Offset(), Start: start},
+				c:      conformance{mode: opts.Mode},
+				serial: packet.SerialNumber,
+			}
+			p.scanner = propertyScanner{info: &p.link.Info, serial: packet.SerialNumber}
+			parsers = append(parsers, p)
+			streams[packet.SerialNumber] = p
+			group = append(group, p)
+		} else if !packet.FirstPacket {
+			grouping = false
+		}
+
+		p, found := streams[packet.SerialNumber]
+		if !found {
+			continue
+		}
+		p.c.warnings = append(p.c.warnings, damage.warnings...)
+		damage.warnings = nil
+
+		err = p.addPacket(&packet, d.Page())
+		if err != nil {
+			return links(parsers), fmt.Errorf("invalid link %d, %v", slices.Index(parsers, p), err)
+		}
+		if packet.LastPacket {
+			p.finish()
+			delete(streams, packet.SerialNumber)
+		}
+	}
+
+	for i, p := range parsers {
+		if p.headers < opus_header_packets {
+			return links(parsers), fmt.Errorf("invalid link %d, %v", i, io.ErrUnexpectedEOF)
+		}
+		p.finish()
+	}
+
+	if len(parsers) == 0 {
+		return nil, noStreamError(d.Pages())
+	}
+	last := &parsers[len(parsers)-1].link.Info
+	last.Warnings = append(last.Warnings, damage.warnings...)
+
+	return links(parsers), nil
+}
+
+// links returns the links of the parsers that are finished.
+func links(parsers []*linkParser) []Link {
+	var result []Link
+	for _, p := range parsers {
+		if p.finished {
+			result = append(result, p.link)
+		}
+	}
+	return result
+}
+
+// linkParser parses the header packets and determines the audio
+// properties of a link.
+type linkParser struct {
+	link     Link
+	c        conformance
+	serial   uint32
+	scanner  propertyScanner
+	finished bool
+	// headers is the number of header packets parsed.
+	headers int
+}
+
+// addPacket adds a packet of the link, read from page.
+func (p *linkParser) addPacket(packet *ogg.Packet, page *ogg.Page) error {
+	if p.headers < opus_header_packets {
+		err := p.parseHeader(page, packet)
+		if err != nil {
+			return err
+		}
+		p.headers++
+	}
+
+	p.scanner.addPacket(packet, page)
+	return nil
+}
+
+// finish completes the audio properties, once.
+func (p *linkParser) finish() {
+	if p.finished {
+		return
+	}
+	p.finished = true
+	p.scanner.finish()
+	p.link.Info.Warnings = p.c.warnings
+}
+
+func (p *linkParser) parseHeader(page *ogg.Page, packet *ogg.Packet) error {
+	info := &p.link.Info
+
+	if p.headers == 0 {
+		err := parseIDHeader(bytes.NewReader(packet.Data), info, &p.c)
+		if err != nil {
+			return fmt.Errorf("invalid identification header, %v", err)
+		}
+		return p.c.checkIDHeaderPage(page)
+	}
+
+	err := parseCommentHeader(bytes.NewReader(packet.Data), info, &p.c)
+	if err != nil {
+		return fmt.Errorf("invalid comment header, %v", err)
+	}
+	return p.c.checkCommentHeaderPage(page, packet)
+}
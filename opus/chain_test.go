@@ -0,0 +1,102 @@
+package opus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+func TestParseLinksChained(t *testing.T) {
+	first := writeTestStream(t, []byte(testIDHeader), testCommentHeader("TITLE=a"), 50)
+	second := writeTestStream(t, []byte(testIDHeader), testCommentHeader("TITLE=b"), 100)
+	stream := append(bytes.Clone(first), second...)
+
+	links, err := ParseLinksReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 {
+		t.Fatalf("expected 2 links, got %d", len(links))
+	}
+	duration := time.Second - 312*time.Second/opus_granule_rate
+	if links[0].Offset != 0 || links[0].Start != 0 || links[0].Info.Duration != duration {
+		t.Fatalf("link 0: offset %d, start %v, duration %v", links[0].Offset, links[0].Start, links[0].Info.Duration)
+	}
+	if links[1].Offset != int64(len(first)) || links[1].Start != duration || links[1].Info.Comments.Value("TITLE") != "b" {
+		t.Fatalf("link 1: offset %d, start %v, comments %q", links[1].Offset, links[1].Start, links[1].Info.Comments)
+	}
+
+	// The properties of a link match those of the stream on its own.
+	info, err := ParseOptions{Properties: true}.ParseInfoReader(bytes.NewReader(second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links[1].Info.AudioPackets != info.AudioPackets || links[1].Info.AudioPages != info.AudioPages ||
+		links[1].Info.AudioBytes != info.AudioBytes || links[1].Info.PeakBitrate != info.PeakBitrate {
+		t.Fatalf("link 1 properties %+v, expected %+v", links[1].Info, info)
+	}
+}
+
+func TestParseLinksMultiplexed(t *testing.T) {
+	// Two Opus streams whose pages are interleaved, followed by a chained
+	// link.
+	var buf bytes.Buffer
+	w1 := ogg.NewWriter(&buf, 1)
+	w2 := ogg.NewWriter(&buf, 2)
+	for _, w := range []*ogg.Writer{w1, w2} {
+		w.WritePacket([]byte(testIDHeader), 0)
+		w.Flush()
+	}
+	for _, w := range []*ogg.Writer{w1, w2} {
+		w.WritePacket(testCommentHeader(), 0)
+		w.Flush()
+	}
+	for i := range 50 {
+		w1.WritePacket(testAudioPacket, int64(i+1)*960)
+		if i < 25 {
+			w2.WritePacket(testAudioPacket, int64(i+1)*960)
+		}
+		if i%10 == 9 {
+			w1.Flush()
+			w2.Flush()
+		}
+	}
+	w1.Close()
+	w2.Close()
+	multiplexed := buf.Len()
+	buf.Write(writeTestStream(t, []byte(testIDHeader), testCommentHeader(), 10))
+
+	links, err := ParseLinksReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 3 {
+		t.Fatalf("expected 3 links, got %d", len(links))
+	}
+	if links[0].Info.AudioPackets != 50 || links[1].Info.AudioPackets != 25 {
+		t.Fatalf("multiplexed links have %d and %d packets", links[0].Info.AudioPackets, links[1].Info.AudioPackets)
+	}
+	if links[1].Start != 0 {
+		t.Fatalf("multiplexed link starts at %v", links[1].Start)
+	}
+	if links[2].Offset != int64(multiplexed) || links[2].Start != links[0].Info.Duration {
+		t.Fatalf("link 2: offset %d, start %v", links[2].Offset, links[2].Start)
+	}
+}
+
+func TestParseLinksInvalid(t *testing.T) {
+	first := writeTestStream(t, []byte(testIDHeader), testCommentHeader(), 10)
+	second := writeTestStream(t, []byte(testIDHeader), []byte("OpusTags"), 10)
+	stream := append(bytes.Clone(first), second...)
+
+	links, err := ParseLinksReader(bytes.NewReader(stream))
+	if err == nil || !strings.Contains(err.Error(), "invalid link 1, invalid comment header") {
+		t.Fatalf("expected an invalid comment header, got %v", err)
+	}
+	if len(links) != 1 {
+		t.Fatalf("expected the first link, got %d links", len(links))
+	}
+}
@@ -0,0 +1,104 @@
+package opus
+
+import (
+	"strings"
+)
+
+// Comment is a single user comment of the form NAME=value. The name keeps
+// its original casing.
+type Comment struct {
+	Name  string
+	Value string
+}
+
+func (c Comment) String() string {
+	return c.Name + "=" + c.Value
+}
+
+// Comments is the list of user comments of a comment header, in their
+// original order. A name can occur more than once, e.g. a track with
+// several artists. Names are compared case-insensitively.
+type Comments []Comment
+
+// Get returns all values of the comments with the given name, in order.
+func (cs Comments) Get(name string) []string {
+	var values []string
+	for _, c := range cs {
+		if strings.EqualFold(c.Name, name) {
+			values = append(values, c.Value)
+		}
+	}
+	return values
+}
+
+// Value returns the value of the first comment with the given name, or
+// an empty string if there is none.
+func (cs Comments) Value(name string) string {
+	for _, c := range cs {
+		if strings.EqualFold(c.Name, name) {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// Has reports whether there is a comment with the given name.
+func (cs Comments) Has(name string) bool {
+	for _, c := range cs {
+		if strings.EqualFold(c.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Add appends a comment.
+func (cs *Comments) Add(name, value string) {
+	*cs = append(*cs, Comment{Name: name, Value: value})
+}
+
+// Set replaces all comments with the given name by the given values. The
+// first value takes the place of the first existing comment, the others
+// follow it. Without existing comments the values are appended.
+func (cs *Comments) Set(name string, values ...string) {
+	result := make(Comments, 0, len(*cs)+len(values))
+	added := false
+	for _, c := range *cs {
+		if !strings.EqualFold(c.Name, name) {
+			result = append(result, c)
+			continue
+		}
+		if !added {
+			for _, value := range values {
+				result = append(result, Comment{Name: c.Name, Value: value})
+			}
+			added = true
+		}
+	}
+	if !added {
+		for _, value := range values {
+			result = append(result, Comment{Name: name, Value: value})
+		}
+	}
+	*cs = result
+}
+
+// Delete removes all comments with the given name.
+func (cs *Comments) Delete(name string) {
+	cs.Set(name)
+}
+
+// Names returns the distinct comment names in order of first appearance,
+// upper-cased.
+func (cs Comments) Names() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, c := range cs {
+		name := strings.ToUpper(c.Name)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names
+}
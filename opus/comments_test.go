@@ -0,0 +1,86 @@
+package opus
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCommentsLookup(t *testing.T) {
+	cs := Comments{{"TITLE", "a"}, {"Artist", "b"}, {"album", "c"}, {"ARTIST", "d"}}
+
+	if values := cs.Get("artist"); !slices.Equal(values, []string{"b", "d"}) {
+		t.Errorf("Get returned %q, expected [b d]", values)
+	}
+	if values := cs.Get("DATE"); values != nil {
+		t.Errorf("Get returned %q for a missing name", values)
+	}
+	if cs.Value("aRtIsT") != "b" || cs.Value("Title") != "a" || cs.Value("DATE") != "" {
+		t.Errorf("Value returned %q, %q and %q", cs.Value("aRtIsT"), cs.Value("Title"), cs.Value("DATE"))
+	}
+	if !cs.Has("ALBUM") || cs.Has("DATE") {
+		t.Errorf("Has returned %v and %v", cs.Has("ALBUM"), cs.Has("DATE"))
+	}
+	if names := cs.Names(); !slices.Equal(names, []string{"TITLE", "ARTIST", "ALBUM"}) {
+		t.Errorf("Names returned %q", names)
+	}
+}
+
+func TestCommentsEdit(t *testing.T) {
+	base := Comments{{"TITLE", "a"}, {"Artist", "b"}, {"ALBUM", "c"}, {"ARTIST", "d"}, {"DATE", "e"}}
+
+	tests := []struct {
+		name     string
+		edit     func(cs *Comments)
+		expected Comments
+	}{
+		{
+			// The values replace the first comment, keeping its casing,
+			// and the other comments with the name are removed.
+			"set multi-valued",
+			func(cs *Comments) { cs.Set("artist", "x", "y", "z") },
+			Comments{{"TITLE", "a"}, {"Artist", "x"}, {"Artist", "y"}, {"Artist", "z"}, {"ALBUM", "c"}, {"DATE", "e"}},
+		},
+		{
+			"set single",
+			func(cs *Comments) { cs.Set("Title", "x") },
+			Comments{{"TITLE", "x"}, {"Artist", "b"}, {"ALBUM", "c"}, {"ARTIST", "d"}, {"DATE", "e"}},
+		},
+		{
+			"set new",
+			func(cs *Comments) { cs.Set("Genre", "x", "y") },
+			Comments{{"TITLE", "a"}, {"Artist", "b"}, {"ALBUM", "c"}, {"ARTIST", "d"}, {"DATE", "e"}, {"Genre", "x"}, {"Genre", "y"}},
+		},
+		{
+			"delete",
+			func(cs *Comments) { cs.Delete("ARTIST") },
+			Comments{{"TITLE", "a"}, {"ALBUM", "c"}, {"DATE", "e"}},
+		},
+		{
+			"delete missing",
+			func(cs *Comments) { cs.Delete("GENRE") },
+			base,
+		},
+		{
+			"add",
+			func(cs *Comments) { cs.Add("artist", "x") },
+			Comments{{"TITLE", "a"}, {"Artist", "b"}, {"ALBUM", "c"}, {"ARTIST", "d"}, {"DATE", "e"}, {"artist", "x"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cs := slices.Clone(base)
+			test.edit(&cs)
+			if !reflect.DeepEqual(cs, test.expected) {
+				t.Fatalf("comments %q, expected %q", cs, test.expected)
+			}
+		})
+	}
+
+	// Editing a copy leaves the original alone.
+	cs := slices.Clone(base)
+	cs.Set("ARTIST", "x")
+	if base[1].Value != "b" || base[3].Value != "d" {
+		t.Fatalf("original comments changed to %q", base)
+	}
+}
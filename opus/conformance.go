@@ -0,0 +1,131 @@
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// Mode selects how streams that do not conform to RFC 7845 are treated.
+type Mode int
+
+const (
+	// Lenient accepts a non-conformant stream as long as it can still be
+	// parsed, recording every violation as a warning.
+	Lenient Mode = iota
+	// Strict rejects a stream that violates a requirement (MUST) of
+	// RFC 7845. Deviations from recommendations (SHOULD) are recorded as
+	// warnings.
+	Strict
+)
+
+func (m Mode) String() string {
+	switch m {
+	case Lenient:
+		return "lenient"
+	case Strict:
+		return "strict"
+	}
+	return fmt.Sprintf("Mode(%d)", int(m))
+}
+
+// ParseOptions configures how Opus streams are parsed.
+type ParseOptions struct {
+	Mode Mode
+	// Properties reads every page of the stream to determine its audio
+	// properties, instead of only the headers and the last page.
+	Properties bool
+	// Recover skips damaged data, such as pages that fail their checksum
+	// or junk between pages, instead of failing on it. The skipped data is
+	// recorded as a warning. In strict mode, damaged data is an error.
+	Recover bool
+}
+
+// conformance collects the conformance problems found while parsing.
+type conformance struct {
+	mode     Mode
+	warnings []string
+}
+
+// violation records the violation of a requirement. In strict mode it is
+// returned as an error, in lenient mode it is recorded as a warning and
+// nil is returned.
+func (c *conformance) violation(format string, args ...any) error {
+	msg := fmt.Sprintf(format, args...)
+	if c.mode == Strict {
+		return errors.New(msg)
+	}
+	c.warnings = append(c.warnings, msg)
+	return nil
+}
+
+// warn records the deviation from a recommendation.
+func (c *conformance) warn(format string, args ...any) {
+	c.warnings = append(c.warnings, fmt.Sprintf(format, args...))
+}
+
+// recoverPages enables the recovery mode of pr if the options ask for it,
+// recording the damaged data skipped as warnings. Damaged data violates
+// RFC 3533, so in strict mode pr keeps failing on it.
+func (opts ParseOptions) recoverPages(pr *ogg.PageReader, c *conformance) {
+	if !opts.Recover || opts.Mode == Strict {
+		return
+	}
+	pr.Recover = true
+	pr.Damaged = func(offset, size int64, err error) {
+		// The pages of the headers may be read twice.
+		msg := fmt.Sprintf("skipped %d bytes of damaged data at offset %d, %v", size, offset, err)
+		if !slices.Contains(c.warnings, msg) {
+			c.warnings = append(c.warnings, msg)
+		}
+	}
+}
+
+// checkIDHeaderPage checks that the identification header is alone on the
+// first page of the stream and that the page has granule position 0
+// (RFC 7845, sections 3 and 4).
+func (c *conformance) checkIDHeaderPage(page *ogg.Page) error {
+	if countPackets(page) != 1 || !page.Complete || page.Continued {
+		err := c.violation("identification header is not alone on the first page (RFC 7845, section 3)")
+		if err != nil {
+			return err
+		}
+	}
+
+	if page.GranulePosition != 0 {
+		return c.violation("identification header page has granule position %d, expected 0 (RFC 7845, section 4)", page.GranulePosition)
+	}
+
+	return nil
+}
+
+// checkCommentHeaderPage checks that the comment header finishes the page
+// on which it completes, and that the page has granule position 0
+// (RFC 7845, sections 3 and 4).
+func (c *conformance) checkCommentHeaderPage(page *ogg.Page, packet *ogg.Packet) error {
+	if packet.GranulePosition == -1 || !page.Complete {
+		err := c.violation("comment header does not finish its page (RFC 7845, section 3)")
+		if err != nil {
+			return err
+		}
+	}
+
+	if page.GranulePosition != 0 {
+		return c.violation("comment header page has granule position %d, expected 0 (RFC 7845, section 4)", page.GranulePosition)
+	}
+
+	return nil
+}
+
+// countPackets returns the number of packets that end on a page.
+func countPackets(page *ogg.Page) int {
+	packets := 0
+	for _, lacing_value := range page.Segments {
+		if lacing_value < 255 {
+			packets++
+		}
+	}
+	return packets
+}
@@ -0,0 +1,260 @@
+package opus
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// writeLayoutStream returns an Ogg Opus stream whose header pages are
+// written by headers, followed by 10 audio packets.
+func writeLayoutStream(t *testing.T, headers func(w *ogg.Writer)) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	headers(w)
+	for i := range 10 {
+		w.WritePacket(testAudioPacket, int64(i+1)*960)
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withIDHeader returns a stream with the given identification header.
+func withIDHeader(id string) func(t *testing.T) []byte {
+	return func(t *testing.T) []byte {
+		return writeTestStream(t, []byte(id), testCommentHeader(), 10)
+	}
+}
+
+// withComments returns a stream with the given comments.
+func withComments(comments ...string) func(t *testing.T) []byte {
+	return func(t *testing.T) []byte {
+		return writeTestStream(t, []byte(testIDHeader), testCommentHeader(comments...), 10)
+	}
+}
+
+// withVendor returns a stream with the given vendor string.
+func withVendor(vendor string) func(t *testing.T) []byte {
+	return func(t *testing.T) []byte {
+		comment := []byte("OpusTags\x00\x00\x00\x00")
+		comment[8] = byte(len(vendor))
+		comment = append(comment, vendor...)
+		comment = append(comment, 0, 0, 0, 0)
+		return writeTestStream(t, []byte(testIDHeader), comment, 10)
+	}
+}
+
+// The conformance checks of RFC 7845. In lenient mode every stream is
+// accepted with a warning, in strict mode the streams that violate a
+// requirement (MUST) are rejected, and those that deviate from a
+// recommendation (SHOULD) are accepted with a warning.
+var conformanceTests = []struct {
+	name   string
+	stream func(t *testing.T) []byte
+	// warning is the expected warning, or the error in strict mode.
+	warning string
+	must    bool
+}{
+	// Section 3: the identification header is alone on the first page
+	// and the comment header finishes its page.
+	{
+		name: "identification header shares its page",
+		stream: func(t *testing.T) []byte {
+			return writeLayoutStream(t, func(w *ogg.Writer) {
+				w.WritePacket([]byte(testIDHeader), 0)
+				w.WritePacket(testCommentHeader(), 0)
+				w.Flush()
+			})
+		},
+		warning: "identification header is not alone on the first page",
+		must:    true,
+	},
+	{
+		name: "comment header shares its page",
+		stream: func(t *testing.T) []byte {
+			return writeLayoutStream(t, func(w *ogg.Writer) {
+				w.WritePacket([]byte(testIDHeader), 0)
+				w.Flush()
+				w.WritePacket(testCommentHeader(), 0)
+				w.WritePacket(testAudioPacket, 0)
+				w.Flush()
+			})
+		},
+		warning: "comment header does not finish its page",
+		must:    true,
+	},
+	// Section 4: the header pages have granule position 0.
+	{
+		name: "identification header page granule position",
+		stream: func(t *testing.T) []byte {
+			return writeLayoutStream(t, func(w *ogg.Writer) {
+				w.WritePacket([]byte(testIDHeader), 960)
+				w.Flush()
+				w.WritePacket(testCommentHeader(), 0)
+				w.Flush()
+			})
+		},
+		warning: "identification header page has granule position 960",
+		must:    true,
+	},
+	{
+		name: "comment header page granule position",
+		stream: func(t *testing.T) []byte {
+			return writeLayoutStream(t, func(w *ogg.Writer) {
+				w.WritePacket([]byte(testIDHeader), 0)
+				w.Flush()
+				w.WritePacket(testCommentHeader(), 100)
+				w.Flush()
+			})
+		},
+		warning: "comment header page has granule position 100",
+		must:    true,
+	},
+	// Section 5.1: the version, and data after the header.
+	{
+		name:    "minor version",
+		stream:  withIDHeader("OpusHead\x0f\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00extra"),
+		warning: "version 15, expected 1",
+	},
+	{
+		name:    "data after the header",
+		stream:  withIDHeader(testIDHeader + "extra"),
+		warning: "5 bytes of unspecified data after the header",
+		must:    true,
+	},
+	// Section 5.1.1: channel mapping families.
+	{
+		name:    "mapping family 1 with 9 channels",
+		stream:  withIDHeader("OpusHead\x01\x09\x38\x01\x80\xbb\x00\x00\x00\x00\x01\x09\x00\x00\x01\x02\x03\x04\x05\x06\x07\x08"),
+		warning: "mapping family 1 allows 1 to 8 channels, got 9",
+		must:    true,
+	},
+	{
+		name:    "unknown mapping family",
+		stream:  withIDHeader("OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x04\x01\x01\x00\x01"),
+		warning: "unknown mapping family 4, treated as 255",
+	},
+	// Section 5.2: the comment header.
+	{
+		name:    "vendor string",
+		stream:  withVendor("\xff\xfe"),
+		warning: "vendor string is not valid UTF-8",
+		must:    true,
+	},
+	{
+		name:    "comment without field name",
+		stream:  withComments("TITLE=a", "no separator"),
+		warning: `user comment "no separator" is not of the form NAME=value`,
+		must:    true,
+	},
+	{
+		name:    "comment field name",
+		stream:  withComments("TI~TLE=a"),
+		warning: `user comment field name "TI~TLE" contains invalid characters`,
+		must:    true,
+	},
+	{
+		name:    "comment value",
+		stream:  withComments("TITLE=\xff"),
+		warning: `user comment "TITLE" is not valid UTF-8`,
+		must:    true,
+	},
+	// Section 5.2.1: gain comments.
+	{
+		name:    "repeated R128 gain",
+		stream:  withComments("R128_TRACK_GAIN=1", "R128_TRACK_GAIN=2"),
+		warning: "R128_TRACK_GAIN occurs 2 times, expected at most once",
+		must:    true,
+	},
+	{
+		name:    "R128 gain out of range",
+		stream:  withComments("R128_ALBUM_GAIN=40000"),
+		warning: "R128_ALBUM_GAIN=40000 is not a Q7.8 integer",
+		must:    true,
+	},
+	{
+		name:    "R128 gain in dB",
+		stream:  withComments("R128_TRACK_GAIN=-3.5 dB"),
+		warning: "R128_TRACK_GAIN=-3.5 dB is not a Q7.8 integer",
+		must:    true,
+	},
+	{
+		name:    "ReplayGain comments",
+		stream:  withComments("REPLAYGAIN_TRACK_GAIN=-3.5 dB"),
+		warning: "ReplayGain comments are present",
+	},
+}
+
+func TestConformance(t *testing.T) {
+	for _, test := range conformanceTests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := test.stream(t)
+
+			info, err := ParseInfoReader(bytes.NewReader(stream))
+			if err != nil {
+				t.Fatalf("lenient: %v", err)
+			}
+			if len(info.Warnings) != 1 || !strings.Contains(info.Warnings[0], test.warning) {
+				t.Fatalf("lenient: expected warning %q, got %q", test.warning, info.Warnings)
+			}
+
+			info, err = ParseOptions{Mode: Strict}.ParseInfoReader(bytes.NewReader(stream))
+			if test.must {
+				if err == nil || !strings.Contains(err.Error(), test.warning) {
+					t.Fatalf("strict: expected error %q, got %v", test.warning, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("strict: %v", err)
+			}
+			if len(info.Warnings) != 1 || !strings.Contains(info.Warnings[0], test.warning) {
+				t.Fatalf("strict: expected warning %q, got %q", test.warning, info.Warnings)
+			}
+		})
+	}
+}
+
+func TestConformanceErrors(t *testing.T) {
+	// Streams that cannot be parsed are rejected in both modes.
+	tests := []struct {
+		name   string
+		stream func(t *testing.T) []byte
+		err    string
+	}{
+		{
+			name:   "major version",
+			stream: withIDHeader("OpusHead\x10\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00"),
+			err:    "unsupported version 16",
+		},
+		{
+			name:   "mapping family 0 with 3 channels",
+			stream: withIDHeader("OpusHead\x01\x03\x38\x01\x80\xbb\x00\x00\x00\x00\x00"),
+			err:    "mapping family 0 allows 1 or 2 channels, got 3",
+		},
+		{
+			name: "checksum",
+			stream: func(t *testing.T) []byte {
+				return corruptPage(t, writeTestStream(t, []byte(testIDHeader), testCommentHeader(), 10), 1)
+			},
+			err: "checksum mismatch",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := test.stream(t)
+			for _, opts := range []ParseOptions{{Mode: Lenient}, {Mode: Strict}, {Mode: Strict, Recover: true}} {
+				_, err := opts.ParseInfoReader(bytes.NewReader(stream))
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Errorf("%v: expected error %q, got %v", opts.Mode, test.err, err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,364 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/steabert/gopus/ogg"
+	"github.com/steabert/gopus/opus/decoder"
+	"github.com/steabert/gopus/opus/internal/framing"
+)
+
+// opus_plc_granularity is the number of samples at 48 kHz by which a lost
+// duration can be concealed (2.5 ms).
+const opus_plc_granularity = 120
+
+// opus_default_packet_duration is the number of samples concealed for a
+// packet that cannot be decoded and whose duration is unknown (20 ms).
+const opus_default_packet_duration = 960
+
+// Concealment counts the audio that a Reader made up for missing or
+// damaged packets.
+type Concealment struct {
+	// Gaps counts the places where packets are missing or could not be
+	// decoded.
+	Gaps int64 `json:"gaps"`
+	// Samples is the number of samples per channel concealed, at 48 kHz.
+	Samples int64 `json:"samples"`
+	// FECSamples is the number of those samples decoded from the in-band
+	// FEC data of the packet following a gap.
+	FECSamples int64 `json:"fec_samples"`
+}
+
+// Duration returns the duration of the concealed audio.
+func (c Concealment) Duration() time.Duration {
+	return granuleDuration(c.Samples)
+}
+
+// Reader decodes the first Opus stream of an Ogg stream to interleaved PCM
+// at 48 kHz, without the pre-skip and with the end trimmed to the granule
+// position of the last page (RFC 7845, section 4). The output gain is not
+// applied, see PlaybackGain. A chained stream is read up to the end of its
+// first link.
+//
+// Pages that are missing show as a gap in the page sequence numbers, as do
+// pages that fail their checksum with the Recover option. The packets lost
+// with them are found from the granule position of the next page, as the
+// samples it does not account for, and concealed by the decoder, from the
+// in-band FEC data of the next packet where it has any. Packets that
+// cannot be decoded are concealed as well.
+type Reader struct {
+	info   OpusInfo
+	c      conformance
+	d      *ogg.Demuxer
+	serial uint32
+	dec    *decoder.MultistreamDecoder
+
+	// granule is the granule position of the next decoded sample, or -1
+	// before the first audio page, and end the granule position at which
+	// the stream ends, or -1 before the last page. page is the granule
+	// position of the page being decoded.
+	granule int64
+	end     int64
+	page    int64
+	// skip is the number of samples of the pre-skip still to drop.
+	skip int
+	// packets holds the packets of the page being decoded, and conceal
+	// the number of samples missing before the first of them.
+	packets []ogg.Packet
+	conceal int64
+	// last is the duration of the last packet decoded.
+	last  int
+	ended bool
+	// skipped is the number of bytes of damaged data skipped up to the
+	// last page read.
+	skipped int64
+
+	// pcm holds the decoded samples, out the part not read yet.
+	pcm []int16
+	out []int16
+
+	concealment Concealment
+}
+
+// NewReader parses the Opus headers of the stream read from r, in lenient
+// mode, and returns a Reader decoding its audio.
+func NewReader(r io.Reader) (*Reader, error) {
+	return ParseOptions{}.NewReader(r)
+}
+
+// NewReader parses the Opus headers of the stream read from r and returns
+// a Reader decoding its audio. The Properties option does not apply.
+func (opts ParseOptions) NewReader(r io.Reader) (*Reader, error) {
+	d := ogg.NewDemuxer(r)
+	rd := &Reader{d: d, c: conformance{mode: opts.Mode}, granule: -1, end: -1}
+	opts.recoverPages(d.Pages(), &rd.c)
+
+	var packet ogg.Packet
+	for {
+		err := d.ReadPacket(&packet)
+		if err == io.EOF {
+			return nil, noStreamError(d.Pages())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		if packet.FirstPacket && bytes.HasPrefix(packet.Data, []byte("OpusHead")) {
+			break
+		}
+	}
+
+	rd.serial = packet.SerialNumber
+	err := parseIDHeader(bytes.NewReader(packet.Data), &rd.info, &rd.c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identification header, %v", err)
+	}
+
+	for {
+		err = d.ReadPacket(&packet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		if packet.SerialNumber == rd.serial {
+			break
+		}
+	}
+
+	err = parseCommentHeader(bytes.NewReader(packet.Data), &rd.info, &rd.c)
+	if err != nil {
+		return nil, fmt.Errorf("invalid comment header, %v", err)
+	}
+	rd.skipped = d.Pages().TotalSkipped()
+
+	if rd.info.MappingFamily == MappingFamilyProjection {
+		return nil, errors.New("mapping family 3 cannot be decoded")
+	}
+	rd.dec, err = decoder.NewMultistreamDecoder(int(rd.info.Channels), int(rd.info.StreamCount),
+		int(rd.info.CoupledCount), rd.info.ChannelMapping)
+	if err != nil {
+		return nil, err
+	}
+	rd.skip = int(rd.info.PreSkip)
+	rd.pcm = make([]int16, framing.MaxPacketDuration*int(rd.info.Channels))
+
+	return rd, nil
+}
+
+// Info returns the Opus headers of the stream, with the warnings found so
+// far. The duration and the audio properties are not determined.
+func (r *Reader) Info() *OpusInfo {
+	r.info.Warnings = r.c.warnings
+	return &r.info
+}
+
+// Concealed returns the audio concealed so far.
+func (r *Reader) Concealed() Concealment {
+	return r.concealment
+}
+
+// Read decodes samples into pcm, interleaved, and returns the number of
+// samples per channel. It returns io.EOF at the end of the stream.
+func (r *Reader) Read(pcm []int16) (int, error) {
+	channels := int(r.info.Channels)
+	if len(pcm) < channels {
+		return 0, io.ErrShortBuffer
+	}
+	for len(r.out) == 0 {
+		err := r.decode()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := min(len(pcm), len(r.out)) / channels
+	copy(pcm, r.out[:n*channels])
+	r.out = r.out[n*channels:]
+	return n, nil
+}
+
+// ReadFloat is like Read, with samples in the range [-1, 1).
+func (r *Reader) ReadFloat(pcm []float32) (int, error) {
+	channels := int(r.info.Channels)
+	if len(pcm) < channels {
+		return 0, io.ErrShortBuffer
+	}
+	for len(r.out) == 0 {
+		err := r.decode()
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := min(len(pcm), len(r.out)) / channels
+	for i, x := range r.out[:n*channels] {
+		pcm[i] = float32(x) / 32768
+	}
+	r.out = r.out[n*channels:]
+	return n, nil
+}
+
+// decode decodes the next packet, or conceals up to 120 ms of missing
+// samples, reading the next page when all of its packets are decoded.
+func (r *Reader) decode() error {
+	if r.conceal == 0 && len(r.packets) == 0 {
+		if r.ended {
+			return io.EOF
+		}
+		return r.readPage()
+	}
+
+	channels := int(r.info.Channels)
+	if r.conceal > 0 {
+		// The last part of the gap is concealed with the FEC data of the
+		// next packet, make sure it can hold that packet.
+		size := r.conceal
+		if size > framing.MaxPacketDuration {
+			size = min(framing.MaxPacketDuration, size-framing.MaxPacketDuration)
+		}
+		pcm := r.pcm[:int(size)*channels]
+
+		var n int
+		var err error
+		if size == r.conceal && len(r.packets) > 0 && r.dec.HasFEC(r.packets[0].Data) &&
+			size >= int64(packetSamples(r.packets[0].Data)) {
+			n, err = r.dec.DecodeFEC(r.packets[0].Data, pcm)
+			if err == nil {
+				r.concealment.FECSamples += int64(n)
+			}
+		} else {
+			n, err = r.dec.Decode(nil, pcm)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to conceal lost packets, %v", err)
+		}
+		r.conceal -= int64(n)
+		r.concealment.Samples += int64(n)
+		r.emit(n)
+		return nil
+	}
+
+	packet := r.packets[0]
+	r.packets[0] = ogg.Packet{}
+	r.packets = r.packets[1:]
+	n, err := r.dec.Decode(packet.Data, r.pcm)
+	if err != nil {
+		// Conceal the packet for the duration of its TOC byte, or else of
+		// the packet before it. Without either, it takes up the samples
+		// of the page that its other packets do not account for, or 20 ms.
+		size := int64(packetSamples(packet.Data))
+		if size == 0 {
+			size = int64(r.last)
+		}
+		if size == 0 {
+			size = r.page - r.granule
+			for _, next := range r.packets {
+				size -= int64(packetSamples(next.Data))
+			}
+			size = min(size, framing.MaxPacketDuration) / opus_plc_granularity * opus_plc_granularity
+		}
+		if size <= 0 {
+			size = opus_default_packet_duration
+		}
+		r.conceal = size
+		r.concealment.Gaps++
+		return nil
+	}
+	r.last = n
+	r.emit(n)
+	return nil
+}
+
+// readPage reads the packets that end on the next page of the stream, and
+// determines from its granule position the number of samples missing
+// before them.
+func (r *Reader) readPage() error {
+	var packet ogg.Packet
+	r.packets = r.packets[:0]
+	for {
+		err := r.d.ReadPacket(&packet)
+		if err == io.EOF || errors.Is(err, io.ErrUnexpectedEOF) || (err == nil && packet.FirstPacket) {
+			// The end of the stream, or the start of the next link, where
+			// the last page of this link is missing.
+			r.ended = true
+			return io.EOF
+		}
+		if err != nil {
+			return fmt.Errorf("invalid OGG stream, %v", err)
+		}
+		if packet.SerialNumber != r.serial {
+			continue
+		}
+
+		r.packets = append(r.packets, packet)
+		if packet.GranulePosition != -1 {
+			break
+		}
+	}
+
+	// Packets can only be lost where pages are.
+	damaged := r.d.Pages().TotalSkipped() != r.skipped
+	for _, packet := range r.packets {
+		damaged = damaged || packet.Gap
+	}
+	r.skipped = r.d.Pages().TotalSkipped()
+
+	// The granule position is that of the last sample of the last packet.
+	last := r.packets[len(r.packets)-1]
+	r.page = last.GranulePosition
+	samples := 0
+	for _, packet := range r.packets {
+		samples += packetSamples(packet.Data)
+	}
+	start := last.GranulePosition - int64(samples)
+
+	switch {
+	case r.granule < 0:
+		// The first audio page may start later than 0, or if it is also
+		// the last page, its end may be trimmed (RFC 7845, section 4.5).
+		if last.LastPacket && start < 0 {
+			start = 0
+		}
+		r.granule = start
+	case start > r.granule && damaged:
+		// The end of the last page may be trimmed, so it may hide part of
+		// a gap.
+		r.conceal = (start - r.granule) / opus_plc_granularity * opus_plc_granularity
+		r.concealment.Gaps++
+	}
+
+	if last.LastPacket {
+		r.end = last.GranulePosition
+		r.ended = true
+	}
+	return nil
+}
+
+// emit makes the first n decoded samples available to read, dropping the
+// pre-skip and the samples past the end of the stream.
+func (r *Reader) emit(n int) {
+	channels := int(r.info.Channels)
+	out := r.pcm[:n*channels]
+	if r.end >= 0 && r.granule+int64(n) > r.end {
+		out = out[:max(0, r.end-r.granule)*int64(channels)]
+	}
+	skip := min(r.skip, len(out)/channels)
+	r.skip -= skip
+	r.out = out[skip*channels:]
+	r.granule += int64(n)
+}
+
+// packetSamples returns the number of samples at 48 kHz of a packet, or of
+// a multistream packet, which has the duration of its first packet, or 0
+// if it has no valid duration.
+func packetSamples(data []byte) int {
+	samples, err := framing.Samples(data)
+	if err != nil {
+		return 0
+	}
+	return samples
+}
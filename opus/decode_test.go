@@ -0,0 +1,210 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/steabert/gopus/ogg"
+	"github.com/steabert/gopus/opus/decoder"
+)
+
+// readTestVector returns the packets of a test vector of the decoder, nil
+// for the packets that are lost.
+func readTestVector(t *testing.T, name string) [][]byte {
+	t.Helper()
+	b, err := os.ReadFile("decoder/testdata/" + name + ".bit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packets [][]byte
+	for len(b) >= 8 {
+		size := int(binary.BigEndian.Uint32(b))
+		var packet []byte
+		if size > 0 {
+			packet = b[8 : 8+size]
+		}
+		packets = append(packets, packet)
+		b = b[8+size:]
+	}
+	return packets
+}
+
+// writeVectorStream returns an Ogg Opus stream of the packets, one per
+// page, and its last granule position. The pages of the lost packets and
+// of the packets in drop are left out of the stream; a lost packet has
+// the duration of the packet before it.
+func writeVectorStream(t *testing.T, packets [][]byte, drop ...int) ([]byte, int64) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(testIDHeader), 0)
+	w.Flush()
+	w.WritePacket(testCommentHeader(), 0)
+	w.Flush()
+	granule := int64(0)
+	last := 0
+	for i, packet := range packets {
+		if packet != nil {
+			last = packetSamples(packet)
+		}
+		granule += int64(last)
+		if packet == nil || slices.Contains(drop, i) {
+			w.SetSequenceNumber(w.SequenceNumber() + 1)
+			continue
+		}
+		w.WritePacket(packet, granule)
+		if i < len(packets)-1 {
+			w.Flush()
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), granule
+}
+
+// decodeTestStream decodes a stream to the end and returns the number of
+// samples per channel and the audio concealed.
+func decodeTestStream(opts ParseOptions, stream []byte) (int64, Concealment, error) {
+	r, err := opts.NewReader(bytes.NewReader(stream))
+	if err != nil {
+		return 0, Concealment{}, err
+	}
+	pcm := make([]int16, 4096)
+	var samples int64
+	for {
+		n, err := r.Read(pcm)
+		if err == io.EOF {
+			return samples, r.Concealed(), nil
+		}
+		if err != nil {
+			return samples, r.Concealed(), err
+		}
+		samples += int64(n)
+	}
+}
+
+func TestReaderConcealDropped(t *testing.T) {
+	packets := readTestVector(t, "celt_fb")
+	stream, granule := writeVectorStream(t, packets, 5, 15, 16)
+	samples, concealed, err := decodeTestStream(ParseOptions{}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples != granule-312 {
+		t.Fatalf("%d samples, expected %d", samples, granule-312)
+	}
+	lost := int64(packetSamples(packets[5]) + packetSamples(packets[15]) + packetSamples(packets[16]))
+	expected := Concealment{Gaps: 2, Samples: lost}
+	if concealed != expected {
+		t.Fatalf("concealed %+v, expected %+v", concealed, expected)
+	}
+}
+
+func TestReaderConcealCorrupt(t *testing.T) {
+	packets := readTestVector(t, "celt_fb")
+	stream, granule := writeVectorStream(t, packets)
+	// The headers take the first two pages.
+	stream = corruptPage(t, stream, 2+12)
+
+	samples, concealed, err := decodeTestStream(ParseOptions{Recover: true}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples != granule-312 {
+		t.Fatalf("%d samples, expected %d", samples, granule-312)
+	}
+	expected := Concealment{Gaps: 1, Samples: int64(packetSamples(packets[12]))}
+	if concealed != expected {
+		t.Fatalf("concealed %+v, expected %+v", concealed, expected)
+	}
+
+	// Without Recover, the damaged page fails the stream.
+	_, _, err = decodeTestStream(ParseOptions{}, stream)
+	if err == nil {
+		t.Fatal("expected an error for a page failing its checksum")
+	}
+}
+
+func TestReaderConcealFEC(t *testing.T) {
+	packets := readTestVector(t, "silk_fec")
+	stream, granule := writeVectorStream(t, packets)
+
+	// Every run of lost packets is a gap, concealed with the FEC data of
+	// the packet after it where it has any.
+	dec, err := decoder.NewMultistreamDecoder(2, 1, 1, []uint8{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected Concealment
+	gap := int64(0)
+	last := 0
+	for _, packet := range packets {
+		if packet == nil {
+			gap += int64(last)
+			continue
+		}
+		last = packetSamples(packet)
+		if gap > 0 {
+			expected.Gaps++
+			expected.Samples += gap
+			if dec.HasFEC(packet) && gap <= 5760 {
+				expected.FECSamples += gap
+			}
+			gap = 0
+		}
+	}
+	if expected.Gaps == 0 || expected.FECSamples == 0 {
+		t.Fatalf("test vector has no lost packets with FEC data, %+v", expected)
+	}
+
+	samples, concealed, err := decodeTestStream(ParseOptions{}, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples != granule-312 {
+		t.Fatalf("%d samples, expected %d", samples, granule-312)
+	}
+	if concealed != expected {
+		t.Fatalf("concealed %+v, expected %+v", concealed, expected)
+	}
+}
+
+func TestReaderConcealUndecodable(t *testing.T) {
+	// A code 3 packet without frames has no duration, and none is known
+	// before it on the first page: it is concealed for 20 ms. On the next
+	// page, it takes up the samples that the packet after it does not.
+	invalid := []byte{0xff, 0x00}
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(testIDHeader), 0)
+	w.Flush()
+	w.WritePacket(testCommentHeader(), 0)
+	w.Flush()
+	w.WritePacket(invalid, 960)
+	w.Flush()
+	w.WritePacket(invalid, -1)
+	w.WritePacket(testAudioPacket, 960+960+2400+960)
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples, concealed, err := decodeTestStream(ParseOptions{}, buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first page starts at the granule position of its end.
+	if samples != 960+2400+960-312 {
+		t.Fatalf("%d samples, expected %d", samples, 960+2400+960-312)
+	}
+	expected := Concealment{Gaps: 2, Samples: 960 + 2400}
+	if concealed != expected {
+		t.Fatalf("concealed %+v, expected %+v", concealed, expected)
+	}
+}
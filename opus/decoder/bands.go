@@ -0,0 +1,874 @@
+package decoder
+
+// Decoding of the CELT band shapes (RFC 6716, section 4.3.4).
+
+// celtLCGRand is the linear congruential generator of the noise fill.
+func celtLCGRand(seed uint32) uint32 {
+	return 1664525*seed + 1013904223
+}
+
+// bitexactCos is a cos() approximation that is bit-exact on any platform,
+// which matters because it affects the bit allocation.
+func bitexactCos(x int16) int16 {
+	tmp := (4096 + int32(x)*int32(x)) >> 13
+	x2 := int16(tmp)
+	x2 = (32767 - x2) + int16(fracMul16(int32(x2), -7651+fracMul16(int32(x2), 8277+fracMul16(-626, int32(x2)))))
+	return 1 + x2
+}
+
+func bitexactLog2Tan(isin, icos int) int {
+	lc := ecILog(uint32(icos))
+	ls := ecILog(uint32(isin))
+	icos <<= 15 - lc
+	isin <<= 15 - ls
+	return (ls-lc)*(1<<11) +
+		int(fracMul16(int32(isin), fracMul16(int32(isin), -2597)+7932)) -
+		int(fracMul16(int32(icos), fracMul16(int32(icos), -2597)+7932))
+}
+
+// denormaliseBands multiplies the normalized shapes of X by the band
+// energies bandLogE, into freq.
+func denormaliseBands(X []int16, freq []int32, bandLogE []int16, start, end, M int, silence bool) {
+	eBands := celtEBand5ms
+	N := M * celt_short_mdct_size
+	bound := M * int(eBands[end])
+	if silence {
+		bound = 0
+		start = 0
+		end = 0
+	}
+	clear(freq[:M*int(eBands[start])])
+	for i := start; i < end; i++ {
+		j := M * int(eBands[i])
+		band_end := M * int(eBands[i+1])
+		lg := saturate16(int32(bandLogE[i]) + int32(celtEMeans[i])<<6)
+
+		// Handle the integer part of the log energy.
+		var g int32
+		shift := 16 - int(lg>>db_shift)
+		if shift > 31 {
+			shift = 0
+			g = 0
+		} else {
+			// Handle the fractional part.
+			g = extract16(celtExp2Frac(lg & (1<<db_shift - 1)))
+		}
+		// Handle extreme gains with negative shift.
+		if shift < 0 {
+			// For shift <= -2 and g > 16384 we'd be likely to overflow, so
+			// cap the gain, which is equivalent to a cap of 18 on lg.
+			if shift <= -2 {
+				g = 16384
+				shift = -2
+			}
+			for ; j < band_end; j++ {
+				freq[j] = shl32(mult16_16(int32(X[j]), g), -shift)
+			}
+		} else {
+			for ; j < band_end; j++ {
+				freq[j] = mult16_16(int32(X[j]), g) >> shift
+			}
+		}
+	}
+	clear(freq[bound:N])
+}
+
+// antiCollapse fills the blocks of the transient bands that received no
+// pulses with noise.
+func antiCollapse(X_ []int16, collapse_masks []uint8, LM, C, size, start, end int, logE, prev1logE, prev2logE []int16, pulses []int, seed uint32) {
+	eBands := celtEBand5ms
+	for i := start; i < end; i++ {
+		N0 := int(eBands[i+1] - eBands[i])
+		// The depth in 1/8 bits.
+		depth := int(celtUdiv(int32(1+pulses[i]), int32(N0))) >> LM
+
+		thresh32 := celtExp2(-shl16(int32(depth), 10-bitres)) >> 1
+		thresh := extract16(mult16_32_q15(16384, min(32767, thresh32)))
+		t := int32(N0 << LM)
+		shift := celtILog2(t) >> 1
+		t = shl32(t, (7-shift)<<1)
+		sqrt_1 := extract16(celtRsqrtNorm(t))
+
+		for c := range C {
+			renormalize := false
+			prev1 := prev1logE[c*celt_nb_ebands+i]
+			prev2 := prev2logE[c*celt_nb_ebands+i]
+			if C == 1 {
+				prev1 = max(prev1, prev1logE[celt_nb_ebands+i])
+				prev2 = max(prev2, prev2logE[celt_nb_ebands+i])
+			}
+			Ediff := int32(logE[c*celt_nb_ebands+i]) - int32(min(prev1, prev2))
+			Ediff = max(0, Ediff)
+
+			var r int32
+			if Ediff < 16384 {
+				r32 := celtExp2(-extract16(Ediff)) >> 1
+				r = extract16(2 * min(16383, r32))
+			}
+			if LM == 3 {
+				r = extract16(mult16_16_q14(23170, min(23169, r)))
+			}
+			r = min(thresh, r) >> 1
+			r = extract16(mult16_16_q15(sqrt_1, r) >> shift)
+
+			X := X_[c*size+int(eBands[i])<<LM:]
+			for k := range 1 << LM {
+				// Detect collapse.
+				if collapse_masks[i*C+c]&(1<<k) != 0 {
+					continue
+				}
+				// Fill with noise.
+				for j := range N0 {
+					seed = celtLCGRand(seed)
+					if seed&0x8000 != 0 {
+						X[j<<LM+k] = int16(r)
+					} else {
+						X[j<<LM+k] = int16(-r)
+					}
+				}
+				renormalize = true
+			}
+			// We just added some energy, so we need to renormalise.
+			if renormalize {
+				renormaliseVector(X, N0<<LM, q15_one)
+			}
+		}
+	}
+}
+
+// stereoMerge converts the mid and side of a band to left and right.
+func stereoMerge(X, Y []int16, mid int32, N int) {
+	var xp, side int32
+	// Compute the norm of X+Y and X-Y as |X|^2 + |Y|^2 +/- sum(xy).
+	for j := range N {
+		xp = mac16_16(xp, int32(Y[j]), int32(X[j]))
+		side = mac16_16(side, int32(Y[j]), int32(Y[j]))
+	}
+	// Compensate for the mid normalization.
+	xp = mult16_32_q15(mid, xp)
+	// mid and side are in Q15, not Q14 like X and Y.
+	mid2 := mid >> 1
+	El := mult16_16(mid2, mid2) + side - 2*xp
+	Er := mult16_16(mid2, mid2) + side + 2*xp
+	if Er < 161061 || El < 161061 { // 6e-4 in Q28
+		copy(Y[:N], X[:N])
+		return
+	}
+
+	kl := celtILog2(El) >> 1
+	kr := celtILog2(Er) >> 1
+	lgain := celtRsqrtNorm(vshr32(El, (kl-7)<<1))
+	rgain := celtRsqrtNorm(vshr32(Er, (kr-7)<<1))
+	kl = max(kl, 7)
+	kr = max(kr, 7)
+
+	for j := range N {
+		// Apply the mid scaling, the side is already scaled.
+		l := extract16(mult16_16_p15(mid, int32(X[j])))
+		r := int32(Y[j])
+		X[j] = int16(pshr32(mult16_16(lgain, sub16(l, r)), kl+1))
+		Y[j] = int16(pshr32(mult16_16(rgain, add16(l, r)), kr+1))
+	}
+}
+
+// orderyTable converts from natural Hadamard to ordery Hadamard, for N =
+// 2, 4, 8 and 16.
+var orderyTable = []int{
+	1, 0,
+	3, 0, 2, 1,
+	7, 0, 4, 3, 6, 1, 5, 2,
+	15, 0, 8, 7, 12, 3, 11, 4, 14, 1, 9, 6, 13, 2, 10, 5,
+}
+
+func deinterleaveHadamard(X []int16, N0, stride int, hadamard bool) {
+	var tmp [176]int16
+	N := N0 * stride
+	if hadamard {
+		ordery := orderyTable[stride-2:]
+		for i := range stride {
+			for j := range N0 {
+				tmp[ordery[i]*N0+j] = X[j*stride+i]
+			}
+		}
+	} else {
+		for i := range stride {
+			for j := range N0 {
+				tmp[i*N0+j] = X[j*stride+i]
+			}
+		}
+	}
+	copy(X[:N], tmp[:N])
+}
+
+func interleaveHadamard(X []int16, N0, stride int, hadamard bool) {
+	var tmp [176]int16
+	N := N0 * stride
+	if hadamard {
+		ordery := orderyTable[stride-2:]
+		for i := range stride {
+			for j := range N0 {
+				tmp[j*stride+i] = X[ordery[i]*N0+j]
+			}
+		}
+	} else {
+		for i := range stride {
+			for j := range N0 {
+				tmp[j*stride+i] = X[i*N0+j]
+			}
+		}
+	}
+	copy(X[:N], tmp[:N])
+}
+
+func haar1(X []int16, N0, stride int) {
+	N0 >>= 1
+	for i := range stride {
+		for j := range N0 {
+			tmp1 := mult16_16(23170, int32(X[stride*2*j+i])) // 0.70710678 in Q15
+			tmp2 := mult16_16(23170, int32(X[stride*(2*j+1)+i]))
+			X[stride*2*j+i] = int16(pshr32(tmp1+tmp2, 15))
+			X[stride*(2*j+1)+i] = int16(pshr32(tmp1-tmp2, 15))
+		}
+	}
+}
+
+var exp2Table8 = [8]int{16384, 17866, 19483, 21247, 23170, 25267, 27554, 30048}
+
+func computeQN(N, b, offset, pulse_cap int, stereo bool) int {
+	N2 := 2*N - 1
+	if stereo && N == 2 {
+		N2--
+	}
+	// The upper limit ensures that in a stereo split with itheta==16384,
+	// we'll always have enough bits left over to code at least one pulse
+	// in the side; otherwise it would collapse, since it doesn't get
+	// folded.
+	qb := (b + N2*offset) / N2
+	qb = min(b-pulse_cap-4<<bitres, qb)
+	qb = min(8<<bitres, qb)
+
+	if qb < 1<<bitres>>1 {
+		return 1
+	}
+	qn := exp2Table8[qb&0x7] >> (14 - qb>>bitres)
+	return (qn + 1) >> 1 << 1
+}
+
+// bandCtx holds the state shared by the bands of a frame.
+type bandCtx struct {
+	rd             *rangeDecoder
+	i              int
+	intensity      int
+	spread         int
+	tf_change      int
+	remaining_bits int
+	seed           uint32
+	disable_inv    bool
+}
+
+// splitCtx holds the parameters of a band split.
+type splitCtx struct {
+	inv    bool
+	imid   int
+	iside  int
+	delta  int
+	itheta int
+	qalloc int
+}
+
+// computeTheta decodes the angle between the two halves of a split band,
+// or between the mid and side of a stereo band.
+func (ctx *bandCtx) computeTheta(sctx *splitCtx, N int, b *int, B, B0, LM int, stereo bool, fill *int) {
+	rd := ctx.rd
+	itheta := 0
+	inv := false
+
+	// Decide on the resolution to give to the split parameter theta.
+	pulse_cap := int(celtLogN400[ctx.i]) + LM*(1<<bitres)
+	offset := pulse_cap>>1 - qtheta_offset
+	if stereo && N == 2 {
+		offset = pulse_cap>>1 - qtheta_offset_twophase
+	}
+	qn := computeQN(N, *b, offset, pulse_cap, stereo)
+	if stereo && ctx.i >= ctx.intensity {
+		qn = 1
+	}
+	tell := int(rd.tellFrac())
+	if qn != 1 {
+		// Entropy decoding of the angle. We use a uniform pdf for the
+		// time split, a step for stereo, and a triangular one for the
+		// rest.
+		switch {
+		case stereo && N > 2:
+			const p0 = 3
+			x0 := qn / 2
+			ft := uint32(p0*(x0+1) + x0)
+			// Use a probability of p0 up to itheta=8192 and then use 1
+			// after.
+			fs := int(rd.decode(ft))
+			var x int
+			if fs < (x0+1)*p0 {
+				x = fs / p0
+			} else {
+				x = x0 + 1 + (fs - (x0+1)*p0)
+			}
+			if x <= x0 {
+				rd.update(uint32(p0*x), uint32(p0*(x+1)), ft)
+			} else {
+				rd.update(uint32((x-1-x0)+(x0+1)*p0), uint32((x-x0)+(x0+1)*p0), ft)
+			}
+			itheta = x
+		case B0 > 1 || stereo:
+			// Uniform pdf.
+			itheta = int(rd.decodeUint(uint32(qn + 1)))
+		default:
+			// Triangular pdf.
+			var fs, fl int
+			ft := ((qn >> 1) + 1) * ((qn >> 1) + 1)
+			fm := int(rd.decode(uint32(ft)))
+			if fm < (qn>>1)*((qn>>1)+1)>>1 {
+				itheta = int(isqrt32(8*uint32(fm)+1)-1) >> 1
+				fs = itheta + 1
+				fl = itheta * (itheta + 1) >> 1
+			} else {
+				itheta = (2*(qn+1) - int(isqrt32(8*uint32(ft-fm-1)+1))) >> 1
+				fs = qn + 1 - itheta
+				fl = ft - ((qn + 1 - itheta) * (qn + 2 - itheta) >> 1)
+			}
+			rd.update(uint32(fl), uint32(fl+fs), uint32(ft))
+		}
+		itheta = itheta * 16384 / qn
+	} else if stereo {
+		if *b > 2<<bitres && ctx.remaining_bits > 2<<bitres {
+			inv = rd.decodeBitLogp(2)
+		}
+		// Override the inversion to avoid problems with downmixing.
+		if ctx.disable_inv {
+			inv = false
+		}
+		itheta = 0
+	}
+	qalloc := int(rd.tellFrac()) - tell
+	*b -= qalloc
+
+	var imid, iside, delta int
+	switch itheta {
+	case 0:
+		imid = 32767
+		iside = 0
+		*fill &= 1<<B - 1
+		delta = -16384
+	case 16384:
+		imid = 0
+		iside = 32767
+		*fill &= (1<<B - 1) << B
+		delta = 16384
+	default:
+		imid = int(bitexactCos(int16(itheta)))
+		iside = int(bitexactCos(int16(16384 - itheta)))
+		// This is the mid vs side allocation that minimizes squared
+		// error in that band.
+		delta = int(fracMul16(int32((N-1)<<7), int32(bitexactLog2Tan(iside, imid))))
+	}
+
+	sctx.inv = inv
+	sctx.imid = imid
+	sctx.iside = iside
+	sctx.delta = delta
+	sctx.itheta = itheta
+	sctx.qalloc = qalloc
+}
+
+// quantBandN1 decodes a band of a single sample, for one or two channels.
+func (ctx *bandCtx) quantBandN1(X, Y []int16, lowband_out []int16) uint {
+	x := X
+	for c := 0; c < 1+b2i(Y != nil); c++ {
+		sign := uint32(0)
+		if ctx.remaining_bits >= 1<<bitres {
+			sign = ctx.rd.decodeBits(1)
+			ctx.remaining_bits -= 1 << bitres
+		}
+		if sign != 0 {
+			x[0] = -norm_scaling
+		} else {
+			x[0] = norm_scaling
+		}
+		x = Y
+	}
+	if lowband_out != nil {
+		lowband_out[0] = X[0] >> 4
+	}
+	return 1
+}
+
+// quantPartition decodes a mono partition. It can split the band in two
+// and decode the energy difference between the two halves, recursively,
+// so that bands can end up being split in 8 parts.
+func (ctx *bandCtx) quantPartition(X []int16, N, b, B int, lowband []int16, LM int, gain int32, fill int) uint {
+	B0 := B
+	var cm uint
+
+	// If we need 1.5 more bit than we can produce, split the band in two.
+	cache := pulseCache(ctx.i, LM)
+	if LM != -1 && b > int(cache[cache[0]])+12 && N > 2 {
+		var sctx splitCtx
+		var next_lowband2 []int16
+
+		N >>= 1
+		Y := X[N:]
+		LM--
+		if B == 1 {
+			fill = fill&1 | fill<<1
+		}
+		B = (B + 1) >> 1
+
+		ctx.computeTheta(&sctx, N, &b, B, B0, LM, false, &fill)
+		mid := int32(sctx.imid)
+		side := int32(sctx.iside)
+		delta := sctx.delta
+		itheta := sctx.itheta
+
+		// Give more bits to low-energy MDCTs than they would otherwise
+		// deserve.
+		if B0 > 1 && itheta&0x3fff != 0 {
+			if itheta > 8192 {
+				// Rough approximation for pre-echo masking.
+				delta -= delta >> (4 - LM)
+			} else {
+				// Corresponds to a forward-masking slope of 1.5 dB per
+				// 10 ms.
+				delta = min(0, delta+(N<<bitres>>(5-LM)))
+			}
+		}
+		mbits := max(0, min(b, (b-delta)/2))
+		sbits := b - mbits
+		ctx.remaining_bits -= sctx.qalloc
+
+		if lowband != nil {
+			next_lowband2 = lowband[N:]
+		}
+
+		rebalance := ctx.remaining_bits
+		if mbits >= sbits {
+			cm = ctx.quantPartition(X, N, mbits, B, lowband, LM, mult16_16_p15(gain, mid), fill)
+			rebalance = mbits - (rebalance - ctx.remaining_bits)
+			if rebalance > 3<<bitres && itheta != 0 {
+				sbits += rebalance - 3<<bitres
+			}
+			cm |= ctx.quantPartition(Y, N, sbits, B, next_lowband2, LM, mult16_16_p15(gain, side), fill>>B) << (B0 >> 1)
+		} else {
+			cm = ctx.quantPartition(Y, N, sbits, B, next_lowband2, LM, mult16_16_p15(gain, side), fill>>B) << (B0 >> 1)
+			rebalance = sbits - (rebalance - ctx.remaining_bits)
+			if rebalance > 3<<bitres && itheta != 16384 {
+				mbits += rebalance - 3<<bitres
+			}
+			cm |= ctx.quantPartition(X, N, mbits, B, lowband, LM, mult16_16_p15(gain, mid), fill)
+		}
+		return cm
+	}
+
+	// This is the basic no-split case.
+	q := bits2pulses(ctx.i, LM, b)
+	curr_bits := pulses2bits(ctx.i, LM, q)
+	ctx.remaining_bits -= curr_bits
+
+	// Ensure we can never bust the budget.
+	for ctx.remaining_bits < 0 && q > 0 {
+		ctx.remaining_bits += curr_bits
+		q--
+		curr_bits = pulses2bits(ctx.i, LM, q)
+		ctx.remaining_bits -= curr_bits
+	}
+
+	if q != 0 {
+		return algUnquant(X, N, getPulses(q), ctx.spread, B, ctx.rd, gain)
+	}
+
+	// If there's no pulse, fill the band anyway.
+	cm_mask := uint(1)<<B - 1
+	fill &= int(cm_mask)
+	if fill == 0 {
+		clear(X[:N])
+		return cm
+	}
+	if lowband == nil {
+		// Noise.
+		for j := range N {
+			ctx.seed = celtLCGRand(ctx.seed)
+			X[j] = int16(int32(ctx.seed) >> 20)
+		}
+		cm = cm_mask
+	} else {
+		// Folded spectrum.
+		for j := range N {
+			ctx.seed = celtLCGRand(ctx.seed)
+			// About 48 dB below the "normal" folding level.
+			tmp := int16(4) // 1/256 in Q10
+			if ctx.seed&0x8000 == 0 {
+				tmp = -tmp
+			}
+			X[j] = lowband[j] + tmp
+		}
+		cm = uint(fill)
+	}
+	renormaliseVector(X, N, gain)
+	return cm
+}
+
+var bitInterleaveTable = [16]int{0, 1, 1, 1, 2, 3, 3, 3, 2, 3, 3, 3, 2, 3, 3, 3}
+
+var bitDeinterleaveTable = [16]uint{
+	0x00, 0x03, 0x0C, 0x0F, 0x30, 0x33, 0x3C, 0x3F,
+	0xC0, 0xC3, 0xCC, 0xCF, 0xF0, 0xF3, 0xFC, 0xFF,
+}
+
+// quantBand decodes a band for the mono case.
+func (ctx *bandCtx) quantBand(X []int16, N, b, B int, lowband []int16, LM int, lowband_out []int16, gain int32, lowband_scratch []int16, fill int) uint {
+	N0 := N
+	N_B := N
+	B0 := B
+	time_divide := 0
+	recombine := 0
+	longBlocks := B0 == 1
+	tf_change := ctx.tf_change
+
+	N_B /= B
+
+	// Special case for one sample.
+	if N == 1 {
+		return ctx.quantBandN1(X, nil, lowband_out)
+	}
+
+	if tf_change > 0 {
+		recombine = tf_change
+	}
+	// Band recombining to increase the frequency resolution.
+
+	if lowband_scratch != nil && lowband != nil && (recombine != 0 || (N_B&1 == 0 && tf_change < 0) || B0 > 1) {
+		copy(lowband_scratch[:N], lowband[:N])
+		lowband = lowband_scratch
+	}
+
+	for k := range recombine {
+		if lowband != nil {
+			haar1(lowband, N>>k, 1<<k)
+		}
+		fill = bitInterleaveTable[fill&0xF] | bitInterleaveTable[fill>>4]<<2
+	}
+	B >>= recombine
+	N_B <<= recombine
+
+	// Increase the time resolution.
+	for N_B&1 == 0 && tf_change < 0 {
+		if lowband != nil {
+			haar1(lowband, N_B, B)
+		}
+		fill |= fill << B
+		B <<= 1
+		N_B >>= 1
+		time_divide++
+		tf_change++
+	}
+	B0 = B
+	N_B0 := N_B
+
+	// Reorganize the samples in time order instead of frequency order.
+	if B0 > 1 && lowband != nil {
+		deinterleaveHadamard(lowband, N_B>>recombine, B0<<recombine, longBlocks)
+	}
+
+	cm := ctx.quantPartition(X, N, b, B, lowband, LM, gain, fill)
+
+	// Undo the sample reorganization going from time order to frequency
+	// order.
+	if B0 > 1 {
+		interleaveHadamard(X, N_B>>recombine, B0<<recombine, longBlocks)
+	}
+
+	// Undo the time-frequency changes done earlier.
+	N_B = N_B0
+	B = B0
+	for range time_divide {
+		B >>= 1
+		N_B <<= 1
+		cm |= cm >> B
+		haar1(X, N_B, B)
+	}
+
+	for k := range recombine {
+		cm = bitDeinterleaveTable[cm]
+		haar1(X, N0>>k, 1<<k)
+	}
+	B <<= recombine
+
+	// Scale the output for later folding.
+	if lowband_out != nil {
+		n := extract16(celtSqrt(shl32(int32(N0), 22)))
+		for j := range N0 {
+			lowband_out[j] = int16(mult16_16_q15(n, int32(X[j])))
+		}
+	}
+	return cm & (1<<B - 1)
+}
+
+// quantBandStereo decodes a band for the stereo case.
+func (ctx *bandCtx) quantBandStereo(X, Y []int16, N, b, B int, lowband []int16, LM int, lowband_out, lowband_scratch []int16, fill int) uint {
+	var sctx splitCtx
+	var cm uint
+
+	// Special case for one sample.
+	if N == 1 {
+		return ctx.quantBandN1(X, Y, lowband_out)
+	}
+
+	orig_fill := fill
+
+	ctx.computeTheta(&sctx, N, &b, B, B, LM, true, &fill)
+	mid := int32(sctx.imid)
+	side := int32(sctx.iside)
+	delta := sctx.delta
+	itheta := sctx.itheta
+
+	if N == 2 {
+		// This is a special case for N=2 that only works for stereo and
+		// takes advantage of the fact that mid and side are orthogonal to
+		// code the side with just one bit.
+		mbits := b
+		sbits := 0
+		// Only need one bit for the side.
+		if itheta != 0 && itheta != 16384 {
+			sbits = 1 << bitres
+		}
+		mbits -= sbits
+		ctx.remaining_bits -= sctx.qalloc + sbits
+
+		x2, y2 := X, Y
+		if itheta > 8192 {
+			x2, y2 = Y, X
+		}
+		sign := int16(0)
+		if sbits != 0 {
+			sign = int16(ctx.rd.decodeBits(1))
+		}
+		sign = 1 - 2*sign
+		// Use orig_fill here because we want to fold the side, but if
+		// itheta==16384, we'll have cleared the low bits of fill.
+		cm = ctx.quantBand(x2, N, mbits, B, lowband, LM, lowband_out, q15_one, lowband_scratch, orig_fill)
+		// We don't split N=2 bands, so cm is either 1 or 0 (for a
+		// fold-collapse), and there's no need to worry about mixing with
+		// the other channel.
+		y2[0] = -sign * x2[1]
+		y2[1] = sign * x2[0]
+
+		X[0] = int16(mult16_16_q15(mid, int32(X[0])))
+		X[1] = int16(mult16_16_q15(mid, int32(X[1])))
+		Y[0] = int16(mult16_16_q15(side, int32(Y[0])))
+		Y[1] = int16(mult16_16_q15(side, int32(Y[1])))
+		tmp := X[0]
+		X[0] = tmp - Y[0]
+		Y[0] = tmp + Y[0]
+		tmp = X[1]
+		X[1] = tmp - Y[1]
+		Y[1] = tmp + Y[1]
+	} else {
+		// The normal split code.
+		mbits := max(0, min(b, (b-delta)/2))
+		sbits := b - mbits
+		ctx.remaining_bits -= sctx.qalloc
+
+		rebalance := ctx.remaining_bits
+		if mbits >= sbits {
+			// In stereo mode, we do not apply a scaling to the mid
+			// because we need the normalized mid for folding later.
+			cm = ctx.quantBand(X, N, mbits, B, lowband, LM, lowband_out, q15_one, lowband_scratch, fill)
+			rebalance = mbits - (rebalance - ctx.remaining_bits)
+			if rebalance > 3<<bitres && itheta != 0 {
+				sbits += rebalance - 3<<bitres
+			}
+			// For a stereo split, the high bits of fill are always zero,
+			// so no folding will be done to the side.
+			cm |= ctx.quantBand(Y, N, sbits, B, nil, LM, nil, side, nil, fill>>B)
+		} else {
+			cm = ctx.quantBand(Y, N, sbits, B, nil, LM, nil, side, nil, fill>>B)
+			rebalance = sbits - (rebalance - ctx.remaining_bits)
+			if rebalance > 3<<bitres && itheta != 16384 {
+				mbits += rebalance - 3<<bitres
+			}
+			cm |= ctx.quantBand(X, N, mbits, B, lowband, LM, lowband_out, q15_one, lowband_scratch, fill)
+		}
+	}
+
+	if N != 2 {
+		stereoMerge(X, Y, mid, N)
+	}
+	if sctx.inv {
+		for j := range N {
+			Y[j] = -Y[j]
+		}
+	}
+	return cm
+}
+
+// specialHybridFolding duplicates enough of the folding data of the first
+// band to be able to fold the second one. It copies no data in CELT-only
+// mode.
+func specialHybridFolding(norm, norm2 []int16, start, M int, dual_stereo bool) {
+	eBands := celtEBand5ms
+	n1 := M * int(eBands[start+1]-eBands[start])
+	n2 := M * int(eBands[start+2]-eBands[start+1])
+	if n2 <= n1 {
+		return
+	}
+	copy(norm[n1:n2], norm[2*n1-n2:n1])
+	if dual_stereo {
+		copy(norm2[n1:n2], norm2[2*n1-n2:n1])
+	}
+}
+
+// quantAllBands decodes the shapes of all the bands, into X_ and, for
+// stereo, Y_.
+func quantAllBands(start, end int, X_, Y_ []int16, collapse_masks []uint8, pulses []int, shortBlocks bool, spread int, dual_stereo bool, intensity int, tf_res []int, total_bits, balance int32, rd *rangeDecoder, LM, codedBands int, seed *uint32, disable_inv bool) {
+	var _norm [2 * 8 * 78]int16
+	eBands := celtEBand5ms
+	update_lowband := true
+	C := 1
+	if Y_ != nil {
+		C = 2
+	}
+
+	M := 1 << LM
+	B := 1
+	if shortBlocks {
+		B = M
+	}
+	norm_offset := M * int(eBands[start])
+	// No need to allocate norm for the last band because we don't need an
+	// output in that band.
+	norm_len := M*int(eBands[celt_nb_ebands-1]) - norm_offset
+	norm := _norm[:norm_len]
+	norm2 := _norm[norm_len : 2*norm_len]
+	// We can use the last band as scratch space because we don't need that
+	// scratch space for the last band and we don't care about the data
+	// there until we're decoding the last band.
+	lowband_scratch := X_[M*int(eBands[celt_nb_ebands-1]):]
+
+	lowband_offset := 0
+	ctx := bandCtx{
+		rd:          rd,
+		intensity:   intensity,
+		spread:      spread,
+		seed:        *seed,
+		disable_inv: disable_inv,
+	}
+	for i := start; i < end; i++ {
+		var Y []int16
+		effective_lowband := -1
+		var x_cm, y_cm uint
+
+		ctx.i = i
+		last := i == end-1
+
+		X := X_[M*int(eBands[i]):]
+		if Y_ != nil {
+			Y = Y_[M*int(eBands[i]):]
+		}
+		N := M*int(eBands[i+1]) - M*int(eBands[i])
+		tell := int32(rd.tellFrac())
+
+		// Compute how many bits we want to allocate to this band.
+		if i != start {
+			balance -= tell
+		}
+		remaining_bits := total_bits - tell - 1
+		ctx.remaining_bits = int(remaining_bits)
+		b := 0
+		if i <= codedBands-1 {
+			curr_balance := balance / int32(min(3, codedBands-i))
+			b = int(max(0, min(16383, min(remaining_bits+1, int32(pulses[i])+curr_balance))))
+		}
+
+		if (M*int(eBands[i])-N >= M*int(eBands[start]) || i == start+1) && (update_lowband || lowband_offset == 0) {
+			lowband_offset = i
+		}
+		if i == start+1 {
+			specialHybridFolding(norm, norm2, start, M, dual_stereo)
+		}
+
+		tf_change := tf_res[i]
+		ctx.tf_change = tf_change
+		if last {
+			lowband_scratch = nil
+		}
+
+		// Get a conservative estimate of the collapse masks of the bands
+		// we're going to be folding from.
+		if lowband_offset != 0 && (spread != spread_aggressive || B > 1 || tf_change < 0) {
+			// This ensures we never repeat spectral content within one
+			// band.
+			effective_lowband = max(0, M*int(eBands[lowband_offset])-norm_offset-N)
+			fold_start := lowband_offset
+			for {
+				fold_start--
+				if M*int(eBands[fold_start]) <= effective_lowband+norm_offset {
+					break
+				}
+			}
+			fold_end := lowband_offset - 1
+			for {
+				fold_end++
+				if fold_end >= i || M*int(eBands[fold_end]) >= effective_lowband+norm_offset+N {
+					break
+				}
+			}
+			for fold_i := fold_start; ; {
+				x_cm |= uint(collapse_masks[fold_i*C+0])
+				y_cm |= uint(collapse_masks[fold_i*C+C-1])
+				fold_i++
+				if fold_i >= fold_end {
+					break
+				}
+			}
+		} else {
+			// Otherwise, we'll be using the LCG to fold, so all blocks
+			// will (almost always) be non-zero.
+			x_cm = 1<<B - 1
+			y_cm = x_cm
+		}
+
+		if dual_stereo && i == intensity {
+			// Switch off dual stereo to do intensity.
+			dual_stereo = false
+			for j := range M*int(eBands[i]) - norm_offset {
+				norm[j] = int16((int32(norm[j]) + int32(norm2[j])) >> 1)
+			}
+		}
+		var lowband, lowband2, lowband_out, lowband_out2 []int16
+		if effective_lowband != -1 {
+			lowband = norm[effective_lowband:]
+			lowband2 = norm2[effective_lowband:]
+		}
+		if !last {
+			lowband_out = norm[M*int(eBands[i])-norm_offset:]
+			lowband_out2 = norm2[M*int(eBands[i])-norm_offset:]
+		}
+		if dual_stereo {
+			x_cm = ctx.quantBand(X, N, b/2, B, lowband, LM, lowband_out, q15_one, lowband_scratch, int(x_cm))
+			y_cm = ctx.quantBand(Y, N, b/2, B, lowband2, LM, lowband_out2, q15_one, lowband_scratch, int(y_cm))
+		} else {
+			if Y != nil {
+				x_cm = ctx.quantBandStereo(X, Y, N, b, B, lowband, LM, lowband_out, lowband_scratch, int(x_cm|y_cm))
+			} else {
+				x_cm = ctx.quantBand(X, N, b, B, lowband, LM, lowband_out, q15_one, lowband_scratch, int(x_cm|y_cm))
+			}
+			y_cm = x_cm
+		}
+		collapse_masks[i*C+0] = uint8(x_cm)
+		collapse_masks[i*C+C-1] = uint8(y_cm)
+		balance += int32(pulses[i]) + tell
+
+		// Update the folding position only as long as we have 1 bit per
+		// sample of depth.
+		update_lowband = b > N<<bitres
+	}
+	*seed = ctx.seed
+}
@@ -0,0 +1,773 @@
+package decoder
+
+import "fmt"
+
+// The CELT decoder (RFC 6716, section 4.3), for the 48 kHz mode of the
+// Opus codec.
+
+const (
+	celt_nb_ebands            = 21
+	celt_overlap              = 120
+	celt_short_mdct_size      = 120
+	celt_max_lm               = 3
+	celt_max_period           = 1024
+	celt_decode_buffer_size   = 2048
+	celt_plc_pitch_lag_max    = 720
+	celt_plc_pitch_lag_min    = 100
+	celt_combfilter_minperiod = 15
+	// celt_preemph is the coefficient of the pre-emphasis filter, 0.85 in
+	// Q15.
+	celt_preemph = 27853
+)
+
+var (
+	celtSpreadICDF = []uint8{25, 23, 2, 0}
+	celtTrimICDF   = []uint8{126, 124, 119, 109, 87, 41, 19, 9, 4, 2, 0}
+	celtTapsetICDF = []uint8{2, 1, 0}
+)
+
+// celtCombFilterGains are the taps of the three post-filter tapsets.
+var celtCombFilterGains = [3][3]int32{
+	{10048, 7112, 4248},
+	{15200, 8784, 0},
+	{26208, 3280, 0},
+}
+
+// celtDecoder holds the state of the CELT layer.
+type celtDecoder struct {
+	channels        int
+	stream_channels int
+	start, end      int
+	disable_inv     bool
+
+	rng                   uint32
+	last_pitch_index      int
+	loss_count            int
+	skip_plc              bool
+	postfilter_period     int
+	postfilter_period_old int
+	postfilter_gain       int32
+	postfilter_gain_old   int32
+	postfilter_tapset     int
+	postfilter_tapset_old int
+	preemph_memD          [2]int32
+
+	// decode_mem holds the synthesized signal of every channel, followed
+	// by the overlap with the next frame.
+	decode_mem     [2][celt_decode_buffer_size + celt_overlap]int32
+	lpc            [2][celt_lpc_order]int16
+	oldBandE       [2 * celt_nb_ebands]int16
+	oldLogE        [2 * celt_nb_ebands]int16
+	oldLogE2       [2 * celt_nb_ebands]int16
+	backgroundLogE [2 * celt_nb_ebands]int16
+}
+
+func (d *celtDecoder) init(channels int) {
+	d.channels = channels
+	d.stream_channels = channels
+	d.start = 0
+	d.end = celt_nb_ebands
+	d.disable_inv = channels == 1
+	d.reset()
+}
+
+// reset clears the state carried from frame to frame.
+func (d *celtDecoder) reset() {
+	d.rng = 0
+	d.last_pitch_index = 0
+	d.loss_count = 0
+	d.skip_plc = false
+	d.postfilter_period = 0
+	d.postfilter_period_old = 0
+	d.postfilter_gain = 0
+	d.postfilter_gain_old = 0
+	d.postfilter_tapset = 0
+	d.postfilter_tapset_old = 0
+	d.preemph_memD = [2]int32{}
+	clear(d.decode_mem[0][:])
+	clear(d.decode_mem[1][:])
+	d.lpc = [2][celt_lpc_order]int16{}
+	clear(d.oldBandE[:])
+	clear(d.backgroundLogE[:])
+	for i := range d.oldLogE {
+		d.oldLogE[i] = -28 << db_shift
+		d.oldLogE2[i] = -28 << db_shift
+	}
+}
+
+// combFilter applies the pitch post-filter to the N samples of x starting
+// at x[off], which must be preceded by at least the largest period and 2
+// samples, into y. The filter changes from (T0, g0, tapset0) to (T1, g1,
+// tapset1) over the overlap. y may be x[off:].
+func combFilter(y, x []int32, off, T0, T1, N int, g0, g1 int32, tapset0, tapset1 int, window []int16, overlap int) {
+	if g0 == 0 && g1 == 0 {
+		copy(y[:N], x[off:off+N])
+		return
+	}
+	// When the gain is zero, T0 and/or T1 is set to zero. We need to have
+	// them be at least 2 to avoid processing garbage data.
+	T0 = max(T0, celt_combfilter_minperiod)
+	T1 = max(T1, celt_combfilter_minperiod)
+	g00 := mult16_16_p15(g0, celtCombFilterGains[tapset0][0])
+	g01 := mult16_16_p15(g0, celtCombFilterGains[tapset0][1])
+	g02 := mult16_16_p15(g0, celtCombFilterGains[tapset0][2])
+	g10 := mult16_16_p15(g1, celtCombFilterGains[tapset1][0])
+	g11 := mult16_16_p15(g1, celtCombFilterGains[tapset1][1])
+	g12 := mult16_16_p15(g1, celtCombFilterGains[tapset1][2])
+	x1 := x[off-T1+1]
+	x2 := x[off-T1]
+	x3 := x[off-T1-1]
+	x4 := x[off-T1-2]
+	// If the filter didn't change, we don't need the overlap.
+	if g0 == g1 && T0 == T1 && tapset0 == tapset1 {
+		overlap = 0
+	}
+	i := 0
+	for ; i < overlap; i++ {
+		x0 := x[off+i-T1+2]
+		f := mult16_16_q15(int32(window[i]), int32(window[i]))
+		t := x[off+i] +
+			mult16_32_q15(mult16_16_q15(q15_one-f, g00), x[off+i-T0]) +
+			mult16_32_q15(mult16_16_q15(q15_one-f, g01), x[off+i-T0+1]+x[off+i-T0-1]) +
+			mult16_32_q15(mult16_16_q15(q15_one-f, g02), x[off+i-T0+2]+x[off+i-T0-2]) +
+			mult16_32_q15(mult16_16_q15(f, g10), x2) +
+			mult16_32_q15(mult16_16_q15(f, g11), x1+x3) +
+			mult16_32_q15(mult16_16_q15(f, g12), x0+x4)
+		y[i] = saturate(t, sig_sat)
+		x4 = x3
+		x3 = x2
+		x2 = x1
+		x1 = x0
+	}
+	if g1 == 0 {
+		copy(y[overlap:N], x[off+overlap:off+N])
+		return
+	}
+
+	// Compute the part with the constant filter.
+	for ; i < N; i++ {
+		x0 := x[off+i-T1+2]
+		t := x[off+i] +
+			mult16_32_q15(g10, x2) +
+			mult16_32_q15(g11, x1+x3) +
+			mult16_32_q15(g12, x0+x4)
+		y[i] = saturate(t, sig_sat)
+		x4 = x3
+		x3 = x2
+		x2 = x1
+		x1 = x0
+	}
+}
+
+// tfDecode decodes the time-frequency resolution changes of the bands.
+func tfDecode(start, end int, isTransient bool, tf_res []int, LM int, rd *rangeDecoder) {
+	budget := len(rd.buf) * 8
+	tell := rd.tell()
+	logp := uint(4)
+	if isTransient {
+		logp = 2
+	}
+	tf_select_rsv := LM > 0 && tell+int(logp)+1 <= budget
+	if tf_select_rsv {
+		budget--
+	}
+	tf_changed := 0
+	curr := 0
+	for i := start; i < end; i++ {
+		if tell+int(logp) <= budget {
+			curr ^= b2i(rd.decodeBitLogp(logp))
+			tell = rd.tell()
+			tf_changed |= curr
+		}
+		tf_res[i] = curr
+		logp = 5
+		if isTransient {
+			logp = 4
+		}
+	}
+	tf_select := 0
+	table := celtTFSelectTable[LM]
+	transient := 4 * b2i(isTransient)
+	if tf_select_rsv && table[transient+0+tf_changed] != table[transient+2+tf_changed] {
+		tf_select = b2i(rd.decodeBitLogp(1))
+	}
+	for i := start; i < end; i++ {
+		tf_res[i] = int(table[transient+2*tf_select+tf_res[i]])
+	}
+}
+
+// synthesis denormalizes the bands of X and computes their inverse MDCT
+// into the decoder memory of the CC output channels.
+func (d *celtDecoder) synthesis(X []int16, start, effEnd, C, CC int, isTransient bool, LM int, silence bool) {
+	var freq [celt_short_mdct_size << celt_max_lm]int32
+	var out_syn [2][]int32
+	M := 1 << LM
+	N := celt_short_mdct_size << LM
+	for c := range CC {
+		out_syn[c] = d.decode_mem[c][celt_decode_buffer_size-N:]
+	}
+
+	B := 1
+	NB := celt_short_mdct_size << LM
+	shift := celt_max_lm - LM
+	if isTransient {
+		B = M
+		NB = celt_short_mdct_size
+		shift = celt_max_lm
+	}
+
+	switch {
+	case CC == 2 && C == 1:
+		// Copying a mono stream to two channels.
+		denormaliseBands(X, freq[:], d.oldBandE[:], start, effEnd, M, silence)
+		// Store a temporary copy in the output buffer because the IMDCT
+		// destroys its input.
+		freq2 := out_syn[1][celt_overlap/2:]
+		copy(freq2[:N], freq[:N])
+		for b := range B {
+			mdctBackward(freq2[b:], out_syn[0][NB*b:], celtWindow120, celt_overlap, shift, B)
+		}
+		for b := range B {
+			mdctBackward(freq[b:], out_syn[1][NB*b:], celtWindow120, celt_overlap, shift, B)
+		}
+	case CC == 1 && C == 2:
+		// Downmixing a stereo stream to mono.
+		freq2 := out_syn[0][celt_overlap/2:]
+		denormaliseBands(X, freq[:], d.oldBandE[:], start, effEnd, M, silence)
+		// Use the output buffer as temp array before downmixing.
+		denormaliseBands(X[N:], freq2, d.oldBandE[celt_nb_ebands:], start, effEnd, M, silence)
+		for i := range N {
+			freq[i] = freq[i]>>1 + freq2[i]>>1
+		}
+		for b := range B {
+			mdctBackward(freq[b:], out_syn[0][NB*b:], celtWindow120, celt_overlap, shift, B)
+		}
+	default:
+		// Normal case (mono or stereo).
+		for c := range CC {
+			denormaliseBands(X[c*N:], freq[:], d.oldBandE[c*celt_nb_ebands:], start, effEnd, M, silence)
+			for b := range B {
+				mdctBackward(freq[b:], out_syn[c][NB*b:], celtWindow120, celt_overlap, shift, B)
+			}
+		}
+	}
+	// Saturate the IMDCT output so that we can't overflow in the pitch
+	// post-filter or in the de-emphasis.
+	for c := range CC {
+		for i := range N {
+			out_syn[c][i] = saturate(out_syn[c][i], sig_sat)
+		}
+	}
+}
+
+// deemphasis undoes the pre-emphasis of the last N samples of the decoder
+// memory into the interleaved pcm. With accum, the output is added to
+// pcm.
+func (d *celtDecoder) deemphasis(pcm []int16, N int, accum bool) {
+	C := d.channels
+	for c := range C {
+		x := d.decode_mem[c][celt_decode_buffer_size-N:]
+		m := d.preemph_memD[c]
+		for j := range N {
+			tmp := x[j] + m
+			m = mult16_32_q15(celt_preemph, tmp)
+			if accum {
+				pcm[j*C+c] = int16(saturate16(int32(pcm[j*C+c]) + int32(sig2word16(tmp))))
+			} else {
+				pcm[j*C+c] = sig2word16(tmp)
+			}
+		}
+		d.preemph_memD[c] = m
+	}
+}
+
+// plcPitchSearch returns the pitch period of the decoded signal, for the
+// pitch-based concealment.
+func (d *celtDecoder) plcPitchSearch() int {
+	var lp_pitch_buf [celt_decode_buffer_size >> 1]int16
+	decode_mem := [][]int32{d.decode_mem[0][:], d.decode_mem[1][:]}
+	pitchDownsample(decode_mem, lp_pitch_buf[:], celt_decode_buffer_size, d.channels)
+	pitch_index := pitchSearch(lp_pitch_buf[celt_plc_pitch_lag_max>>1:], lp_pitch_buf[:],
+		celt_decode_buffer_size-celt_plc_pitch_lag_max, celt_plc_pitch_lag_max-celt_plc_pitch_lag_min)
+	return celt_plc_pitch_lag_max - pitch_index
+}
+
+// decodeLost conceals a lost frame of N samples: with noise shaped like
+// the last band energies after a few losses, otherwise by extrapolating
+// the pitch period of the last decoded signal.
+func (d *celtDecoder) decodeLost(N, LM int) {
+	C := d.channels
+	eBands := celtEBand5ms
+	start := d.start
+	loss_count := d.loss_count
+	noise_based := loss_count >= 5 || start != 0 || d.skip_plc
+
+	if noise_based {
+		// Noise-based PLC/CNG.
+		var X [2 * celt_short_mdct_size << celt_max_lm]int16
+		end := d.end
+		effEnd := max(start, min(end, celt_nb_ebands))
+
+		// Energy decay.
+		decay := int16(1536) // 1.5 in Q10
+		if loss_count != 0 {
+			decay = 512 // 0.5 in Q10
+		}
+		for c := range C {
+			for i := start; i < end; i++ {
+				e := &d.oldBandE[c*celt_nb_ebands+i]
+				*e = max(d.backgroundLogE[c*celt_nb_ebands+i], *e-decay)
+			}
+		}
+		seed := d.rng
+		for c := range C {
+			for i := start; i < effEnd; i++ {
+				boffs := N*c + int(eBands[i])<<LM
+				blen := int(eBands[i+1]-eBands[i]) << LM
+				for j := range blen {
+					seed = celtLCGRand(seed)
+					X[boffs+j] = int16(int32(seed) >> 20)
+				}
+				renormaliseVector(X[boffs:], blen, q15_one)
+			}
+		}
+		d.rng = seed
+
+		for c := range C {
+			buf := d.decode_mem[c][:]
+			copy(buf, buf[N:celt_decode_buffer_size+celt_overlap>>1])
+		}
+
+		d.synthesis(X[:], start, effEnd, C, C, false, LM, false)
+		d.loss_count = loss_count + 1
+		return
+	}
+
+	// Pitch-based PLC.
+	var etmp [celt_overlap]int32
+	var _exc [celt_max_period + celt_lpc_order]int16
+	var fir_tmp [celt_max_period]int16
+	exc := _exc[celt_lpc_order:]
+	window := celtWindow120
+	fade := int32(q15_one)
+	var pitch_index int
+	if loss_count == 0 {
+		pitch_index = d.plcPitchSearch()
+		d.last_pitch_index = pitch_index
+	} else {
+		pitch_index = d.last_pitch_index
+		fade = 26214 // 0.8 in Q15
+	}
+
+	// We want the excitation for 2 pitch periods in order to look for a
+	// decaying signal, but we can't get more than MAX_PERIOD.
+	exc_length := min(2*pitch_index, celt_max_period)
+
+	for c := range C {
+		var S1 int32
+		buf := d.decode_mem[c][:]
+		lpc := d.lpc[c][:]
+		for i := range celt_max_period + celt_lpc_order {
+			_exc[i] = int16(round16(buf[celt_decode_buffer_size-celt_max_period-celt_lpc_order+i], sig_shift))
+		}
+
+		if loss_count == 0 {
+			var ac [celt_lpc_order + 1]int32
+			// Compute LPC coefficients for the last MAX_PERIOD samples
+			// before the first loss so we can work in the
+			// excitation-filter domain.
+			celtAutocorr(exc, ac[:], window, celt_overlap, celt_lpc_order, celt_max_period)
+			// Add a noise floor of -40 dB.
+			ac[0] += ac[0] >> 13
+			// Use lag windowing to stabilize the Levinson-Durbin
+			// recursion.
+			for i := int32(1); i <= celt_lpc_order; i++ {
+				ac[i] -= mult16_32_q15(2*i*i, ac[i])
+			}
+			celtLPC(lpc, ac[:], celt_lpc_order)
+			// Apply bandwidth expansion until we can guarantee that no
+			// overflow can happen in the IIR filter. This means:
+			// 32768*sum(abs(filter)) < 2^31
+			for {
+				tmp := int32(q15_one)
+				sum := int32(1 << sig_shift)
+				for i := range celt_lpc_order {
+					sum += abs32(int32(lpc[i]))
+				}
+				if sum < 65535 {
+					break
+				}
+				for i := range celt_lpc_order {
+					tmp = mult16_16_q15(32440, tmp) // 0.99 in Q15
+					lpc[i] = int16(mult16_16_q15(int32(lpc[i]), tmp))
+				}
+			}
+		}
+		// Compute the excitation for exc_length samples before the loss.
+		// We need the copy because celtFIR() cannot filter in-place.
+		celtFIR(_exc[celt_max_period-exc_length:], lpc, fir_tmp[:], exc_length, celt_lpc_order)
+		copy(exc[celt_max_period-exc_length:celt_max_period], fir_tmp[:exc_length])
+
+		// Check if the waveform is decaying, and if so how fast. We do
+		// this to avoid adding energy when concealing in a segment with
+		// decaying energy.
+		var decay int32
+		{
+			E1 := int32(1)
+			E2 := int32(1)
+			shift := max(0, 2*celtZLog2(celtMaxAbs16(exc[celt_max_period-exc_length:celt_max_period]))-20)
+			decay_length := exc_length >> 1
+			for i := range decay_length {
+				e := int32(exc[celt_max_period-decay_length+i])
+				E1 += mult16_16(e, e) >> shift
+				e = int32(exc[celt_max_period-2*decay_length+i])
+				E2 += mult16_16(e, e) >> shift
+			}
+			E1 = min(E1, E2)
+			decay = celtSqrt(fracDiv32(E1>>1, E2))
+		}
+
+		// Move the decoder memory one frame to the left to give us room
+		// to add the data for the new frame. We ignore the overlap that
+		// extends past the end of the buffer, because we aren't going to
+		// use it.
+		copy(buf, buf[N:celt_decode_buffer_size])
+
+		// Extrapolate from the end of the excitation with a period of
+		// pitch_index, scaling down each period by an additional factor
+		// of decay.
+		extrapolation_offset := celt_max_period - pitch_index
+		// We need to extrapolate enough samples to cover a complete MDCT
+		// window (including overlap/2 samples on both sides).
+		extrapolation_len := N + celt_overlap
+		// We also apply fading if this is not the first loss.
+		attenuation := mult16_16_q15(fade, decay)
+		for i, j := 0, 0; i < extrapolation_len; i, j = i+1, j+1 {
+			if j >= pitch_index {
+				j -= pitch_index
+				attenuation = mult16_16_q15(attenuation, decay)
+			}
+			buf[celt_decode_buffer_size-N+i] = shl32(mult16_16_q15(attenuation, int32(exc[extrapolation_offset+j])), sig_shift)
+			// Compute the energy of the previously decoded signal whose
+			// excitation we're copying.
+			tmp := round16(buf[celt_decode_buffer_size-celt_max_period-N+extrapolation_offset+j], sig_shift)
+			S1 += mult16_16(tmp, tmp) >> 10
+		}
+		{
+			var lpc_mem [celt_lpc_order]int16
+			// Copy the last decoded samples (prior to the overlap region)
+			// to synthesis filter memory so we can have a continuous
+			// signal.
+			for i := range celt_lpc_order {
+				lpc_mem[i] = int16(round16(buf[celt_decode_buffer_size-N-1-i], sig_shift))
+			}
+			// Apply the synthesis filter to convert the excitation back
+			// into the signal domain.
+			syn := buf[celt_decode_buffer_size-N:]
+			celtIIR(syn, lpc, syn, extrapolation_len, celt_lpc_order, lpc_mem[:])
+			for i := range extrapolation_len {
+				syn[i] = saturate(syn[i], sig_sat)
+			}
+		}
+
+		// Check if the synthesis energy is higher than expected, which can
+		// happen with the signal changes during our window. If so,
+		// attenuate.
+		{
+			var S2 int32
+			syn := buf[celt_decode_buffer_size-N:]
+			for i := range extrapolation_len {
+				tmp := round16(syn[i], sig_shift)
+				S2 += mult16_16(tmp, tmp) >> 10
+			}
+			// This checks for an "explosion" in the synthesis.
+			if !(S1 > S2>>2) {
+				clear(syn[:extrapolation_len])
+			} else if S1 < S2 {
+				ratio := celtSqrt(fracDiv32(S1>>1+1, S2+1))
+				for i := range celt_overlap {
+					tmp_g := q15_one - mult16_16_q15(int32(window[i]), q15_one-ratio)
+					syn[i] = mult16_32_q15(tmp_g, syn[i])
+				}
+				for i := celt_overlap; i < extrapolation_len; i++ {
+					syn[i] = mult16_32_q15(ratio, syn[i])
+				}
+			}
+		}
+
+		// Apply the pre-filter to the MDCT overlap for the next frame
+		// because the post-filter will be re-applied in the decoder after
+		// the MDCT overlap.
+		combFilter(etmp[:], buf, celt_decode_buffer_size,
+			d.postfilter_period, d.postfilter_period, celt_overlap,
+			-d.postfilter_gain, -d.postfilter_gain,
+			d.postfilter_tapset, d.postfilter_tapset, nil, 0)
+
+		// Simulate TDAC on the concealed audio so that it blends with the
+		// MDCT of the next frame.
+		for i := range celt_overlap / 2 {
+			buf[celt_decode_buffer_size+i] =
+				mult16_32_q15(int32(window[i]), etmp[celt_overlap-1-i]) +
+					mult16_32_q15(int32(window[celt_overlap-i-1]), etmp[i])
+		}
+	}
+
+	d.loss_count = loss_count + 1
+}
+
+// decode decodes a CELT frame of frame_size samples from data into the
+// interleaved pcm, continuing with rd if it is not nil. A nil or 1-byte
+// data conceals a lost frame. With accum, the output is added to pcm.
+func (d *celtDecoder) decode(data []byte, pcm []int16, frame_size int, rd *rangeDecoder, accum bool) error {
+	CC := d.channels
+	C := d.stream_channels
+	start := d.start
+	end := d.end
+
+	LM := 0
+	for LM <= celt_max_lm && celt_short_mdct_size<<LM != frame_size {
+		LM++
+	}
+	if LM > celt_max_lm {
+		return fmt.Errorf("invalid CELT frame size %d", frame_size)
+	}
+	M := 1 << LM
+	N := M * celt_short_mdct_size
+	if len(data) > max_frame_size {
+		return invalid("CELT frame of %d bytes exceeds %d bytes", len(data), max_frame_size)
+	}
+
+	effEnd := min(end, celt_nb_ebands)
+
+	if len(data) <= 1 {
+		d.decodeLost(N, LM)
+		d.deemphasis(pcm, N, accum)
+		return nil
+	}
+
+	// Check if there are at least two packets received consecutively
+	// before turning on the pitch-based PLC.
+	d.skip_plc = d.loss_count != 0
+
+	if rd == nil {
+		rd = new(rangeDecoder)
+		rd.init(data)
+	}
+
+	if C == 1 {
+		for i := range celt_nb_ebands {
+			d.oldBandE[i] = max(d.oldBandE[i], d.oldBandE[celt_nb_ebands+i])
+		}
+	}
+
+	total_bits := int32(len(data) * 8)
+	tell := int32(rd.tell())
+
+	silence := false
+	if tell >= total_bits {
+		silence = true
+	} else if tell == 1 {
+		silence = rd.decodeBitLogp(15)
+	}
+	if silence {
+		// Pretend we've read all the remaining bits.
+		tell = int32(len(data) * 8)
+		rd.nbitsTotal += int(tell) - rd.tell()
+	}
+
+	var postfilter_gain int32
+	postfilter_pitch := 0
+	postfilter_tapset := 0
+	if start == 0 && tell+16 <= total_bits {
+		if rd.decodeBitLogp(1) {
+			octave := int(rd.decodeUint(6))
+			postfilter_pitch = 16<<octave + int(rd.decodeBits(uint(4+octave))) - 1
+			qg := int32(rd.decodeBits(3))
+			if int32(rd.tell())+2 <= total_bits {
+				postfilter_tapset = rd.decodeICDF(celtTapsetICDF, 2)
+			}
+			postfilter_gain = 3072 * (qg + 1) // 0.09375 in Q15
+		}
+		tell = int32(rd.tell())
+	}
+
+	isTransient := false
+	if LM > 0 && tell+3 <= total_bits {
+		isTransient = rd.decodeBitLogp(3)
+		tell = int32(rd.tell())
+	}
+	shortBlocks := isTransient
+
+	// Decode the global flags (first symbols in the stream).
+	intra_ener := false
+	if tell+3 <= total_bits {
+		intra_ener = rd.decodeBitLogp(3)
+	}
+	// Get the band energies.
+	unquantCoarseEnergy(start, end, d.oldBandE[:], intra_ener, rd, C, LM)
+
+	var tf_res [celt_nb_ebands]int
+	tfDecode(start, end, isTransient, tf_res[:], LM, rd)
+
+	tell = int32(rd.tell())
+	spread_decision := spread_normal
+	if tell+4 <= total_bits {
+		spread_decision = rd.decodeICDF(celtSpreadICDF, 5)
+	}
+
+	var cap, offsets [celt_nb_ebands]int
+	initCaps(cap[:], LM, C)
+
+	dynalloc_logp := uint(6)
+	total_bits <<= bitres
+	tell = int32(rd.tellFrac())
+	for i := start; i < end; i++ {
+		width := C * int(celtEBand5ms[i+1]-celtEBand5ms[i]) << LM
+		// quanta is 6 bits, but no more than 1 bit/sample and no less
+		// than 1/8 bit/sample.
+		quanta := min(width<<bitres, max(6<<bitres, width))
+		dynalloc_loop_logp := dynalloc_logp
+		boost := 0
+		for tell+int32(dynalloc_loop_logp<<bitres) < total_bits && boost < cap[i] {
+			flag := rd.decodeBitLogp(dynalloc_loop_logp)
+			tell = int32(rd.tellFrac())
+			if !flag {
+				break
+			}
+			boost += quanta
+			total_bits -= int32(quanta)
+			dynalloc_loop_logp = 1
+		}
+		offsets[i] = boost
+		// Making dynalloc more likely.
+		if boost > 0 {
+			dynalloc_logp = max(2, dynalloc_logp-1)
+		}
+	}
+
+	alloc_trim := 5
+	if tell+6<<bitres <= total_bits {
+		alloc_trim = rd.decodeICDF(celtTrimICDF, 7)
+	}
+
+	bits := int32(len(data)*8)<<bitres - int32(rd.tellFrac()) - 1
+	anti_collapse_rsv := int32(0)
+	if isTransient && LM >= 2 && bits >= int32(LM+2)<<bitres {
+		anti_collapse_rsv = 1 << bitres
+	}
+	bits -= anti_collapse_rsv
+
+	var alloc celtAllocation
+	computeAllocation(&alloc, start, end, offsets[:], cap[:], alloc_trim, bits, C, LM, rd)
+
+	unquantFineEnergy(start, end, d.oldBandE[:], alloc.fine_quant[:], rd, C)
+
+	for c := range CC {
+		buf := d.decode_mem[c][:]
+		copy(buf, buf[N:celt_decode_buffer_size+celt_overlap/2])
+	}
+
+	// Decode the fixed codebook.
+	var collapse_masks [2 * celt_nb_ebands]uint8
+	var X [2 * celt_short_mdct_size << celt_max_lm]int16
+	var Y []int16
+	if C == 2 {
+		Y = X[N:]
+	}
+	quantAllBands(start, end, X[:], Y, collapse_masks[:], alloc.pulses[:], shortBlocks,
+		spread_decision, alloc.dual_stereo, alloc.intensity, tf_res[:],
+		int32(len(data)*(8<<bitres))-anti_collapse_rsv, alloc.balance, rd, LM,
+		alloc.codedBands, &d.rng, d.disable_inv)
+
+	anti_collapse_on := false
+	if anti_collapse_rsv > 0 {
+		anti_collapse_on = rd.decodeBits(1) != 0
+	}
+
+	unquantEnergyFinalise(start, end, d.oldBandE[:], alloc.fine_quant[:], alloc.fine_priority[:],
+		len(data)*8-rd.tell(), rd, C)
+
+	if anti_collapse_on {
+		antiCollapse(X[:], collapse_masks[:], LM, C, N, start, end, d.oldBandE[:],
+			d.oldLogE[:], d.oldLogE2[:], alloc.pulses[:], d.rng)
+	}
+
+	if silence {
+		for i := range C * celt_nb_ebands {
+			d.oldBandE[i] = -28 << db_shift
+		}
+	}
+
+	d.synthesis(X[:], start, effEnd, C, CC, isTransient, LM, silence)
+
+	for c := range CC {
+		out_syn := d.decode_mem[c][:]
+		off := celt_decode_buffer_size - N
+		d.postfilter_period = max(d.postfilter_period, celt_combfilter_minperiod)
+		d.postfilter_period_old = max(d.postfilter_period_old, celt_combfilter_minperiod)
+		combFilter(out_syn[off:], out_syn, off, d.postfilter_period_old, d.postfilter_period,
+			celt_short_mdct_size, d.postfilter_gain_old, d.postfilter_gain,
+			d.postfilter_tapset_old, d.postfilter_tapset, celtWindow120, celt_overlap)
+		if LM != 0 {
+			off += celt_short_mdct_size
+			combFilter(out_syn[off:], out_syn, off, d.postfilter_period, postfilter_pitch,
+				N-celt_short_mdct_size, d.postfilter_gain, postfilter_gain,
+				d.postfilter_tapset, postfilter_tapset, celtWindow120, celt_overlap)
+		}
+	}
+	d.postfilter_period_old = d.postfilter_period
+	d.postfilter_gain_old = d.postfilter_gain
+	d.postfilter_tapset_old = d.postfilter_tapset
+	d.postfilter_period = postfilter_pitch
+	d.postfilter_gain = postfilter_gain
+	d.postfilter_tapset = postfilter_tapset
+	if LM != 0 {
+		d.postfilter_period_old = d.postfilter_period
+		d.postfilter_gain_old = d.postfilter_gain
+		d.postfilter_tapset_old = d.postfilter_tapset
+	}
+
+	if C == 1 {
+		copy(d.oldBandE[celt_nb_ebands:], d.oldBandE[:celt_nb_ebands])
+	}
+
+	// In case start or end were to change.
+	if !isTransient {
+		copy(d.oldLogE2[:], d.oldLogE[:])
+		copy(d.oldLogE[:], d.oldBandE[:])
+		// In normal circumstances, we only allow the noise floor to
+		// increase by up to 2.4 dB/second, but when we're in DTX, we allow
+		// up to 6 dB increase for each update.
+		max_background_increase := int16(M) // 0.001 in Q10, per 2.5 ms
+		if d.loss_count >= 10 {
+			max_background_increase = 1 << db_shift
+		}
+		for i := range 2 * celt_nb_ebands {
+			d.backgroundLogE[i] = min(d.backgroundLogE[i]+max_background_increase, d.oldBandE[i])
+		}
+	} else {
+		for i := range 2 * celt_nb_ebands {
+			d.oldLogE[i] = min(d.oldLogE[i], d.oldBandE[i])
+		}
+	}
+	for c := range 2 {
+		for i := range start {
+			d.oldBandE[c*celt_nb_ebands+i] = 0
+			d.oldLogE[c*celt_nb_ebands+i] = -28 << db_shift
+			d.oldLogE2[c*celt_nb_ebands+i] = -28 << db_shift
+		}
+		for i := end; i < celt_nb_ebands; i++ {
+			d.oldBandE[c*celt_nb_ebands+i] = 0
+			d.oldLogE[c*celt_nb_ebands+i] = -28 << db_shift
+			d.oldLogE2[c*celt_nb_ebands+i] = -28 << db_shift
+		}
+	}
+	d.rng = rd.rng
+
+	d.deemphasis(pcm, N, accum)
+	d.loss_count = 0
+	if rd.tell() > 8*len(data) {
+		return invalid("CELT frame overruns its %d bytes", len(data))
+	}
+	return nil
+}
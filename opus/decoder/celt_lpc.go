@@ -0,0 +1,140 @@
+package decoder
+
+// The LPC helpers of the CELT packet loss concealment.
+
+const celt_lpc_order = 24
+
+// celtLPC computes the LPC coefficients of order p from the
+// autocorrelation ac, with the Levinson-Durbin recursion.
+func celtLPC(lpc_out []int16, ac []int32, p int) {
+	var lpc [celt_lpc_order]int32
+	lpc_err := ac[0]
+
+	if ac[0] != 0 {
+		for i := range p {
+			// Sum up the reflection coefficient of this iteration.
+			var rr int32
+			for j := range i {
+				rr += mult32_32_q31(lpc[j], ac[i-j])
+			}
+			rr += ac[i+1] >> 3
+			r := -fracDiv32(shl32(rr, 3), lpc_err)
+			// Update the LPC coefficients and the total error.
+			lpc[i] = r >> 3
+			for j := range (i + 1) >> 1 {
+				tmp1 := lpc[j]
+				tmp2 := lpc[i-1-j]
+				lpc[j] = tmp1 + mult32_32_q31(r, tmp2)
+				lpc[i-1-j] = tmp2 + mult32_32_q31(r, tmp1)
+			}
+			lpc_err = lpc_err - mult32_32_q31(mult32_32_q31(r, r), lpc_err)
+			// Bail out once we get 30 dB gain.
+			if lpc_err < ac[0]>>10 {
+				break
+			}
+		}
+	}
+	for i := range p {
+		lpc_out[i] = int16(round16(lpc[i], 16))
+	}
+}
+
+// celtFIR filters the N samples of x with the ord coefficients of num.
+// x must have ord samples of history in front of it, at x[-ord:].
+func celtFIR(x []int16, num []int16, y []int16, N, ord int) {
+	for i := range N {
+		sum := shl32(int32(x[ord+i]), sig_shift)
+		for j := range ord {
+			sum = mac16_16(sum, int32(num[ord-j-1]), int32(x[i+j]))
+		}
+		y[i] = int16(round16(sum, sig_shift))
+	}
+}
+
+// celtIIR filters the N samples of x with the all-pole filter den of
+// order ord. N must be a multiple of 4. x and y may be the same.
+func celtIIR(x []int32, den []int16, y []int32, N, ord int, mem []int16) {
+	var hist [celt_lpc_order]int32
+	for i := range ord {
+		hist[i] = int32(mem[i])
+	}
+	for i := range N {
+		sum := x[i]
+		for j := range ord {
+			sum -= mult16_16(int32(den[j]), hist[j])
+		}
+		copy(hist[1:ord], hist[:ord-1])
+		hist[0] = sround16(sum, sig_shift)
+		y[i] = sum
+	}
+	// The reference keeps the unrounded output in the memory.
+	for i := range ord {
+		mem[i] = int16(y[N-i-1])
+	}
+}
+
+// celtAutocorr computes the lag+1 first autocorrelation values of the n
+// samples of x, windowed over overlap samples at both ends, and returns
+// the shift applied to them.
+func celtAutocorr(x []int16, ac []int32, window []int16, overlap, lag, n int) int {
+	var xx [celt_max_period]int16
+	fastN := n - lag
+	xptr := x
+	if overlap != 0 {
+		copy(xx[:n], x[:n])
+		for i := range overlap {
+			xx[i] = int16(mult16_16_q15(int32(x[i]), int32(window[i])))
+			xx[n-i-1] = int16(mult16_16_q15(int32(x[n-i-1]), int32(window[i])))
+		}
+		xptr = xx[:n]
+	}
+
+	ac0 := int32(1 + n<<7)
+	if n&1 != 0 {
+		ac0 += mult16_16(int32(xptr[0]), int32(xptr[0])) >> 9
+	}
+	for i := n & 1; i < n; i += 2 {
+		ac0 += mult16_16(int32(xptr[i]), int32(xptr[i])) >> 9
+		ac0 += mult16_16(int32(xptr[i+1]), int32(xptr[i+1])) >> 9
+	}
+	shift := (celtILog2(ac0) - 30 + 10) / 2
+	if shift > 0 {
+		for i := range n {
+			xx[i] = int16(pshr32(int32(xptr[i]), shift))
+		}
+		xptr = xx[:n]
+	} else {
+		shift = 0
+	}
+
+	celtPitchXcorr(xptr, xptr, ac, fastN, lag+1)
+	for k := 0; k <= lag; k++ {
+		var d int32
+		for i := k + fastN; i < n; i++ {
+			d = mac16_16(d, int32(xptr[i]), int32(xptr[i-k]))
+		}
+		ac[k] += d
+	}
+
+	shift = 2 * shift
+	if shift <= 0 {
+		ac[0] += shl32(1, -shift)
+	}
+	if ac[0] < 268435456 {
+		shift2 := 29 - ecILog(uint32(ac[0]))
+		for i := 0; i <= lag; i++ {
+			ac[i] = shl32(ac[i], shift2)
+		}
+		shift -= shift2
+	} else if ac[0] >= 536870912 {
+		shift2 := 1
+		if ac[0] >= 1073741824 {
+			shift2++
+		}
+		for i := 0; i <= lag; i++ {
+			ac[i] >>= shift2
+		}
+		shift += shift2
+	}
+	return shift
+}
@@ -0,0 +1,128 @@
+package decoder
+
+// Decoding of the PVQ codewords of CELT (RFC 6716, section 4.3.4.2).
+
+// celtPVQURows are the offsets of the rows of U(N,K) in celtPVQUData.
+var celtPVQURows = [15]int{
+	0, 176, 351, 525, 698, 870, 1041, 1131, 1178, 1207, 1226, 1240, 1248,
+	1254, 1257,
+}
+
+func celtPVQRow(n int) []uint32 {
+	return celtPVQUData[celtPVQURows[n]:]
+}
+
+// celtPVQU returns U(N,K), the number of combinations of K pulses in N
+// dimensions with the sign of the first pulse implied.
+func celtPVQU(n, k int) uint32 {
+	return celtPVQRow(min(n, k))[max(n, k)]
+}
+
+// celtPVQV returns V(N,K), the number of PVQ codewords of K pulses in N
+// dimensions.
+func celtPVQV(n, k int) uint32 {
+	return celtPVQU(n, k) + celtPVQU(n, k+1)
+}
+
+// cwrsi decodes the codeword i of k pulses in n dimensions into y, and
+// returns its squared norm.
+func cwrsi(n, k int, i uint32, y []int) int32 {
+	var yy int32
+	var p uint32
+	var s, k0 int
+	var val int16
+	for n > 2 {
+		if k >= n {
+			// Lots of pulses case.
+			row := celtPVQRow(n)
+			// Are the pulses in this dimension negative?
+			p = row[k+1]
+			s = 0
+			if i >= p {
+				s = -1
+				i -= p
+			}
+			// Count how many pulses were placed in this dimension.
+			k0 = k
+			q := row[n]
+			if q > i {
+				k = n
+				for {
+					k--
+					p = celtPVQRow(k)[n]
+					if p <= i {
+						break
+					}
+				}
+			} else {
+				for p = row[k]; p > i; p = row[k] {
+					k--
+				}
+			}
+			i -= p
+			val = int16((k0 - k + s) ^ s)
+			y[0] = int(val)
+			y = y[1:]
+			yy = mac16_16(yy, int32(val), int32(val))
+		} else {
+			// Lots of dimensions case. Are there any pulses in this
+			// dimension at all?
+			p = celtPVQRow(k)[n]
+			q := celtPVQRow(k + 1)[n]
+			if p <= i && i < q {
+				i -= p
+				y[0] = 0
+				y = y[1:]
+			} else {
+				// Are the pulses in this dimension negative?
+				s = 0
+				if i >= q {
+					s = -1
+					i -= q
+				}
+				// Count how many pulses were placed in this dimension.
+				k0 = k
+				for {
+					k--
+					p = celtPVQRow(k)[n]
+					if p <= i {
+						break
+					}
+				}
+				i -= p
+				val = int16((k0 - k + s) ^ s)
+				y[0] = int(val)
+				y = y[1:]
+				yy = mac16_16(yy, int32(val), int32(val))
+			}
+		}
+		n--
+	}
+	// n == 2
+	p = uint32(2*k + 1)
+	s = 0
+	if i >= p {
+		s = -1
+		i -= p
+	}
+	k0 = k
+	k = int((i + 1) >> 1)
+	if k != 0 {
+		i -= uint32(2*k - 1)
+	}
+	val = int16((k0 - k + s) ^ s)
+	y[0] = int(val)
+	yy = mac16_16(yy, int32(val), int32(val))
+	// n == 1
+	s = -int(i)
+	val = int16((k + s) ^ s)
+	y[1] = int(val)
+	yy = mac16_16(yy, int32(val), int32(val))
+	return yy
+}
+
+// decodePulses decodes the pulses of a band of n dimensions with k
+// pulses into y, and returns their squared norm.
+func decodePulses(y []int, n, k int, rd *rangeDecoder) int32 {
+	return cwrsi(n, k, rd.decodeUint(celtPVQV(n, k)), y)
+}
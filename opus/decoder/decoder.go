@@ -0,0 +1,504 @@
+package decoder
+
+import (
+	"fmt"
+
+	"github.com/steabert/gopus/opus/internal/framing"
+)
+
+// sample_rate is the output sample rate of the decoder.
+const sample_rate = 48000
+
+// The frame sizes at 48 kHz.
+const (
+	f2_5 = sample_rate / 400
+	f5   = sample_rate / 200
+	f10  = sample_rate / 100
+	f20  = sample_rate / 50
+)
+
+// Decoder decodes the packets of an Opus stream of one or two channels
+// (RFC 6716) to PCM at 48 kHz.
+type Decoder struct {
+	channels int
+	silk     silkDecoder
+	silk_ctl silkDecControl
+	celt     celtDecoder
+
+	stream_channels      int
+	bandwidth            int
+	mode                 int
+	prev_mode            int
+	frame_size           int
+	prev_redundancy      bool
+	last_packet_duration int
+	rangeFinal           uint32
+
+	// pcm holds the output of DecodeFloat before its conversion.
+	pcm []int16
+}
+
+// NewDecoder returns a decoder producing channels channels, 1 or 2.
+func NewDecoder(channels int) (*Decoder, error) {
+	if channels != 1 && channels != 2 {
+		return nil, fmt.Errorf("invalid number of channels %d", channels)
+	}
+	d := &Decoder{channels: channels}
+	d.silk_ctl.API_sampleRate = sample_rate
+	d.silk_ctl.nChannelsAPI = channels
+	d.silk.init()
+	d.celt.init(channels)
+	d.Reset()
+	return d, nil
+}
+
+// Channels returns the number of output channels of the decoder.
+func (d *Decoder) Channels() int {
+	return d.channels
+}
+
+// Reset resets the decoder to its initial state, as when a stream is
+// restarted from an arbitrary position.
+func (d *Decoder) Reset() {
+	d.silk.init()
+	d.celt.reset()
+	d.stream_channels = d.channels
+	d.bandwidth = 0
+	d.mode = 0
+	d.prev_mode = 0
+	d.frame_size = sample_rate / 400
+	d.prev_redundancy = false
+	d.last_packet_duration = 0
+	d.rangeFinal = 0
+}
+
+// FinalRange returns the final state of the range decoder after the last
+// packet, which the encoder reports for conformance testing.
+func (d *Decoder) FinalRange() uint32 {
+	return d.rangeFinal
+}
+
+// Decode decodes a packet into pcm, interleaved, and returns the number of
+// samples per channel. pcm must hold the duration of the packet, up to
+// 120 ms. A nil packet conceals a lost packet of the duration of pcm,
+// which must be a multiple of 2.5 ms.
+func (d *Decoder) Decode(packet []byte, pcm []int16) (int, error) {
+	return d.decodeNative(packet, pcm, len(pcm)/d.channels, false, false)
+}
+
+// DecodeFEC conceals a lost packet of the duration of pcm, which must be a
+// multiple of 2.5 ms, with the in-band FEC data of packet, the packet that
+// follows it. Without FEC data, the loss is concealed as for a nil packet.
+// packet itself is not decoded, it is passed to Decode next.
+func (d *Decoder) DecodeFEC(packet []byte, pcm []int16) (int, error) {
+	return d.decodeNative(packet, pcm, len(pcm)/d.channels, true, false)
+}
+
+// DecodeFloat is like Decode, with samples in the range [-1, 1).
+func (d *Decoder) DecodeFloat(packet []byte, pcm []float32) (int, error) {
+	frame_size := len(pcm) / d.channels
+	if packet != nil {
+		nb_samples, err := packetSamples(packet)
+		if err != nil {
+			return 0, err
+		}
+		frame_size = min(frame_size, nb_samples)
+	}
+	if cap(d.pcm) < frame_size*d.channels {
+		d.pcm = make([]int16, frame_size*d.channels)
+	}
+	out := d.pcm[:frame_size*d.channels]
+	n, err := d.decodeNative(packet, out, frame_size, false, false)
+	if err != nil {
+		return 0, err
+	}
+	for i, x := range out[:n*d.channels] {
+		pcm[i] = float32(x) / 32768
+	}
+	return n, nil
+}
+
+// packetSamples returns the number of samples at 48 kHz of a packet.
+func packetSamples(packet []byte) (int, error) {
+	samples, err := framing.Samples(packet)
+	if err != nil {
+		return 0, invalid("%v", err)
+	}
+	return samples, nil
+}
+
+// smoothFade cross-fades in1 to in2 into out over overlap samples, with
+// the power-complementary window of CELT.
+func smoothFade(in1, in2, out []int16, overlap, channels int) {
+	for c := range channels {
+		for i := range overlap {
+			w := mult16_16_q15(int32(celtWindow120[i]), int32(celtWindow120[i]))
+			out[i*channels+c] = int16(mac16_16(mult16_16(w, int32(in2[i*channels+c])),
+				q15_one-w, int32(in1[i*channels+c])) >> 15)
+		}
+	}
+}
+
+// decodeFrame decodes a frame of data into pcm, which can hold frame_size
+// samples, and returns the number of samples per channel. A nil data
+// conceals a lost frame. With decode_fec, the frame is decoded from the
+// in-band FEC data of SILK.
+func (d *Decoder) decodeFrame(data []byte, pcm []int16, frame_size int, decode_fec bool) (int, error) {
+	var rd rangeDecoder
+	var celt_err error
+	var redundant_rng uint32
+	transition := false
+	redundancy := false
+	celt_to_silk := false
+	redundancy_bytes := 0
+
+	// Limit the frame size to 60 ms.
+	frame_size = min(frame_size, 3*f20)
+	// Payloads of 1 (2 including the TOC) or 0 bytes trigger the PLC/DTX.
+	if len(data) <= 1 {
+		data = nil
+		// In that case, don't conceal more than what the TOC says.
+		frame_size = min(frame_size, d.frame_size)
+	}
+
+	var audiosize, mode, bandwidth int
+	if data != nil {
+		audiosize = d.frame_size
+		mode = d.mode
+		bandwidth = d.bandwidth
+		rd.init(data)
+	} else {
+		audiosize = frame_size
+		mode = d.prev_mode
+		bandwidth = 0
+
+		if mode == 0 {
+			// If we haven't got any packet yet, all we can do is return
+			// zeros.
+			clear(pcm[:audiosize*d.channels])
+			return audiosize, nil
+		}
+
+		// Avoids trying to run the PLC on sizes other than 2.5 (CELT), 5
+		// (CELT), 10, or 20 ms (e.g. 12.5 or 30 ms).
+		if audiosize > f20 {
+			for audiosize > 0 {
+				n, err := d.decodeFrame(nil, pcm, min(audiosize, f20), false)
+				if err != nil {
+					return 0, err
+				}
+				pcm = pcm[n*d.channels:]
+				audiosize -= n
+			}
+			return frame_size, nil
+		} else if audiosize < f20 {
+			if audiosize > f10 {
+				audiosize = f10
+			} else if mode != mode_silk_only && audiosize > f5 && audiosize < f10 {
+				audiosize = f5
+			}
+		}
+	}
+
+	// CELT can do the accumulation on top of the SILK output.
+	celt_accum := mode != mode_celt_only && frame_size >= f10
+
+	var pcm_transition []int16
+	if data != nil && d.prev_mode > 0 &&
+		((mode == mode_celt_only && d.prev_mode != mode_celt_only && !d.prev_redundancy) ||
+			(mode != mode_celt_only && d.prev_mode == mode_celt_only)) {
+		transition = true
+		pcm_transition = make([]int16, f5*d.channels)
+	}
+	if transition && mode == mode_celt_only {
+		d.decodeFrame(nil, pcm_transition, min(f5, audiosize), false)
+	}
+	if audiosize > frame_size {
+		return 0, fmt.Errorf("%w, %d samples for a frame of %d", ErrBufferTooSmall, frame_size, audiosize)
+	}
+	frame_size = audiosize
+
+	var pcm_silk []int16
+	if mode != mode_celt_only && !celt_accum {
+		pcm_silk = make([]int16, max(f10, frame_size)*d.channels)
+	}
+
+	// SILK processing.
+	if mode != mode_celt_only {
+		pcm_ptr := pcm_silk
+		if celt_accum {
+			pcm_ptr = pcm
+		}
+
+		if d.prev_mode == mode_celt_only {
+			d.silk.init()
+		}
+
+		// The SILK PLC cannot produce frames of less than 10 ms.
+		d.silk_ctl.payloadSize_ms = max(10, 1000*audiosize/sample_rate)
+
+		if data != nil {
+			d.silk_ctl.nChannelsInternal = d.stream_channels
+			if mode == mode_silk_only {
+				switch bandwidth {
+				case bandwidth_narrowband:
+					d.silk_ctl.internalSampleRate = 8000
+				case bandwidth_mediumband:
+					d.silk_ctl.internalSampleRate = 12000
+				default:
+					d.silk_ctl.internalSampleRate = 16000
+				}
+			} else {
+				// Hybrid mode.
+				d.silk_ctl.internalSampleRate = 16000
+			}
+		}
+
+		lost_flag := silk_flag_decode_normal
+		if data == nil {
+			lost_flag = silk_flag_packet_lost
+		} else if decode_fec {
+			lost_flag = silk_flag_decode_lbrr
+		}
+		for decoded_samples := 0; decoded_samples < frame_size; {
+			// Call the SILK decoder.
+			n := d.silk.decode(&d.silk_ctl, lost_flag, decoded_samples == 0, &rd, pcm_ptr)
+			pcm_ptr = pcm_ptr[n*d.channels:]
+			decoded_samples += n
+		}
+	}
+
+	length := len(data)
+	start_band := 0
+	if !decode_fec && mode != mode_celt_only && data != nil &&
+		rd.tell()+17+20*b2i(d.mode == mode_hybrid) <= 8*length {
+		// Check if we have a redundant 0-8 kHz band.
+		if mode == mode_hybrid {
+			redundancy = rd.decodeBitLogp(12)
+		} else {
+			redundancy = true
+		}
+		if redundancy {
+			celt_to_silk = rd.decodeBitLogp(1)
+			// redundancy_bytes will be at least two, in the non-hybrid
+			// case due to the tell check above.
+			if mode == mode_hybrid {
+				redundancy_bytes = int(rd.decodeUint(256)) + 2
+			} else {
+				redundancy_bytes = length - (rd.tell()+7)>>3
+			}
+			length -= redundancy_bytes
+			// This is a sanity check. It should never happen for a valid
+			// packet, so the exact behaviour is not normative.
+			if length*8 < rd.tell() {
+				length = 0
+				redundancy_bytes = 0
+				redundancy = false
+			}
+			// Shrink the decoder because of the raw bits.
+			rd.buf = rd.buf[:len(rd.buf)-redundancy_bytes]
+		}
+	}
+	if mode != mode_celt_only {
+		start_band = 17
+	}
+
+	if redundancy {
+		transition = false
+	}
+
+	if transition && mode != mode_celt_only {
+		d.decodeFrame(nil, pcm_transition, min(f5, audiosize), false)
+	}
+
+	if bandwidth != 0 {
+		endband := 21
+		switch bandwidth {
+		case bandwidth_narrowband:
+			endband = 13
+		case bandwidth_mediumband, bandwidth_wideband:
+			endband = 17
+		case bandwidth_superwideband:
+			endband = 19
+		}
+		d.celt.end = endband
+	}
+	d.celt.stream_channels = d.stream_channels
+
+	var redundant_audio []int16
+	var redundant_data []byte
+	if redundancy {
+		redundant_audio = make([]int16, f5*d.channels)
+		redundant_data = data[length : length+redundancy_bytes]
+	}
+
+	// 5 ms redundant frame for CELT->SILK.
+	if redundancy && celt_to_silk {
+		d.celt.start = 0
+		d.celt.decode(redundant_data, redundant_audio, f5, nil, false)
+		redundant_rng = d.celt.rng
+	}
+
+	// This must be after the PLC.
+	d.celt.start = start_band
+
+	if mode != mode_silk_only {
+		celt_frame_size := min(f20, frame_size)
+		// Make sure to discard any previous CELT state.
+		if mode != d.prev_mode && d.prev_mode > 0 && !d.prev_redundancy {
+			d.celt.reset()
+		}
+		// Decode CELT.
+		celt_data := data
+		if decode_fec {
+			celt_data = nil
+		} else if celt_data != nil {
+			celt_data = celt_data[:length]
+		}
+		celt_err = d.celt.decode(celt_data, pcm, celt_frame_size, &rd, celt_accum)
+	} else {
+		silence := []byte{0xff, 0xff}
+		if !celt_accum {
+			clear(pcm[:frame_size*d.channels])
+		}
+		// For hybrid -> SILK transitions, we let the CELT MDCT do a
+		// fade-out by decoding a silence frame.
+		if d.prev_mode == mode_hybrid && !(redundancy && celt_to_silk && d.prev_redundancy) {
+			d.celt.start = 0
+			d.celt.decode(silence, pcm, f2_5, nil, celt_accum)
+		}
+	}
+
+	if mode != mode_celt_only && !celt_accum {
+		for i := range frame_size * d.channels {
+			pcm[i] = int16(saturate16(int32(pcm[i]) + int32(pcm_silk[i])))
+		}
+	}
+
+	// 5 ms redundant frame for SILK->CELT.
+	if redundancy && !celt_to_silk {
+		d.celt.reset()
+		d.celt.start = 0
+		d.celt.decode(redundant_data, redundant_audio, f5, nil, false)
+		redundant_rng = d.celt.rng
+		tail := pcm[d.channels*(frame_size-f2_5):]
+		smoothFade(tail, redundant_audio[d.channels*f2_5:], tail, f2_5, d.channels)
+	}
+	if redundancy && celt_to_silk {
+		copy(pcm[:d.channels*f2_5], redundant_audio)
+		tail := pcm[d.channels*f2_5:]
+		smoothFade(redundant_audio[d.channels*f2_5:], tail, tail, f2_5, d.channels)
+	}
+	if transition {
+		if audiosize >= f5 {
+			copy(pcm[:d.channels*f2_5], pcm_transition)
+			tail := pcm[d.channels*f2_5:]
+			smoothFade(pcm_transition[d.channels*f2_5:], tail, tail, f2_5, d.channels)
+		} else {
+			// Not enough time to do a clean transition, but we do it
+			// anyway. This will not preserve amplitude perfectly and may
+			// introduce a bit of temporal aliasing, but it shouldn't be
+			// too bad.
+			smoothFade(pcm_transition, pcm, pcm, f2_5, d.channels)
+		}
+	}
+
+	if length <= 1 {
+		d.rangeFinal = 0
+	} else {
+		d.rangeFinal = rd.rng ^ redundant_rng
+	}
+
+	d.prev_mode = mode
+	d.prev_redundancy = redundancy && !celt_to_silk
+
+	if celt_err != nil {
+		return 0, celt_err
+	}
+	return audiosize, nil
+}
+
+// decodeNative decodes a packet, or conceals frame_size samples for a nil
+// packet, into pcm. With self_delimited, the packet is self-delimited, as
+// all but the last stream of a multistream packet.
+func (d *Decoder) decodeNative(data []byte, pcm []int16, frame_size int, decode_fec bool, self_delimited bool) (int, error) {
+	var frames [48][]byte
+	if frame_size <= 0 {
+		return 0, fmt.Errorf("%w, no room for samples", ErrBufferTooSmall)
+	}
+	// For FEC/PLC, the frame size has to be a multiple of 2.5 ms.
+	if (decode_fec || len(data) == 0) && frame_size%f2_5 != 0 {
+		return 0, fmt.Errorf("concealed duration of %d samples is not a multiple of 2.5 ms", frame_size)
+	}
+	if len(data) == 0 {
+		pcm_count := 0
+		for pcm_count < frame_size {
+			n, err := d.decodeFrame(nil, pcm[pcm_count*d.channels:], frame_size-pcm_count, false)
+			if err != nil {
+				return 0, err
+			}
+			pcm_count += n
+		}
+		d.last_packet_duration = pcm_count
+		return pcm_count, nil
+	}
+
+	packet_mode := tocMode(data[0])
+	packet_bandwidth := tocBandwidth(data[0])
+	packet_frame_size := tocFrameSize(data[0])
+	packet_stream_channels := tocChannels(data[0])
+
+	count, _, err := parseFrames(data, self_delimited, &frames)
+	if err != nil {
+		return 0, err
+	}
+
+	if decode_fec {
+		// If no FEC can be present, run the PLC.
+		if frame_size < packet_frame_size || packet_mode == mode_celt_only || d.mode == mode_celt_only {
+			return d.decodeNative(nil, pcm, frame_size, false, false)
+		}
+		// Otherwise, run the PLC on everything except the size for which
+		// we might have FEC.
+		duration_copy := d.last_packet_duration
+		if frame_size-packet_frame_size != 0 {
+			if _, err := d.decodeNative(nil, pcm, frame_size-packet_frame_size, false, false); err != nil {
+				d.last_packet_duration = duration_copy
+				return 0, err
+			}
+		}
+		// Complete with FEC.
+		d.mode = packet_mode
+		d.bandwidth = packet_bandwidth
+		d.frame_size = packet_frame_size
+		d.stream_channels = packet_stream_channels
+		_, err := d.decodeFrame(frames[0], pcm[d.channels*(frame_size-packet_frame_size):], packet_frame_size, true)
+		if err != nil {
+			return 0, err
+		}
+		d.last_packet_duration = frame_size
+		return frame_size, nil
+	}
+
+	if count*packet_frame_size > frame_size {
+		return 0, fmt.Errorf("%w, %d samples for a packet of %d", ErrBufferTooSmall, frame_size, count*packet_frame_size)
+	}
+
+	// Update the state as the last step to avoid updating it on an
+	// invalid packet.
+	d.mode = packet_mode
+	d.bandwidth = packet_bandwidth
+	d.frame_size = packet_frame_size
+	d.stream_channels = packet_stream_channels
+
+	nb_samples := 0
+	for _, frame := range frames[:count] {
+		n, err := d.decodeFrame(frame, pcm[nb_samples*d.channels:], frame_size-nb_samples, false)
+		if err != nil {
+			return 0, err
+		}
+		nb_samples += n
+	}
+	d.last_packet_duration = nb_samples
+	return nb_samples, nil
+}
@@ -0,0 +1,41 @@
+// Package decoder decodes Opus packets (RFC 6716) to PCM at 48 kHz, in
+// pure Go.
+//
+// The decoder is a port of the fixed-point decoder of the reference
+// implementation, libopus 1.3.1, with the updates of RFC 8251, and its
+// output is bit-exact with it. The reference implementation is
+// distributed under the following license:
+//
+//	Copyright 2001-2011 Xiph.Org, Skype Limited, Octasic,
+//	                    Jean-Marc Valin, Timothy B. Terriberry,
+//	                    CSIRO, Gregory Maxwell, Mark Borgerding,
+//	                    Erik de Castro Lopo
+//
+//	Redistribution and use in source and binary forms, with or without
+//	modification, are permitted provided that the following conditions
+//	are met:
+//
+//	- Redistributions of source code must retain the above copyright
+//	notice, this list of conditions and the following disclaimer.
+//
+//	- Redistributions in binary form must reproduce the above copyright
+//	notice, this list of conditions and the following disclaimer in the
+//	documentation and/or other materials provided with the distribution.
+//
+//	- Neither the name of Internet Society, IETF or IETF Trust, nor the
+//	names of specific contributors, may be used to endorse or promote
+//	products derived from this software without specific prior written
+//	permission.
+//
+//	THIS SOFTWARE IS PROVIDED BY THE COPYRIGHT HOLDERS AND CONTRIBUTORS
+//	``AS IS'' AND ANY EXPRESS OR IMPLIED WARRANTIES, INCLUDING, BUT NOT
+//	LIMITED TO, THE IMPLIED WARRANTIES OF MERCHANTABILITY AND FITNESS FOR
+//	A PARTICULAR PURPOSE ARE DISCLAIMED. IN NO EVENT SHALL THE COPYRIGHT OWNER
+//	OR CONTRIBUTORS BE LIABLE FOR ANY DIRECT, INDIRECT, INCIDENTAL, SPECIAL,
+//	EXEMPLARY, OR CONSEQUENTIAL DAMAGES (INCLUDING, BUT NOT LIMITED TO,
+//	PROCUREMENT OF SUBSTITUTE GOODS OR SERVICES; LOSS OF USE, DATA, OR
+//	PROFITS; OR BUSINESS INTERRUPTION) HOWEVER CAUSED AND ON ANY THEORY OF
+//	LIABILITY, WHETHER IN CONTRACT, STRICT LIABILITY, OR TORT (INCLUDING
+//	NEGLIGENCE OR OTHERWISE) ARISING IN ANY WAY OUT OF THE USE OF THIS
+//	SOFTWARE, EVEN IF ADVISED OF THE POSSIBILITY OF SUCH DAMAGE.
+package decoder
@@ -0,0 +1,157 @@
+package decoder
+
+import (
+	"fmt"
+	"math"
+)
+
+// The speakers of the Vorbis channel order.
+const (
+	speaker_left = iota
+	speaker_center
+	speaker_right
+	speaker_side_left
+	speaker_side_right
+	speaker_rear_center
+	speaker_lfe
+)
+
+// vorbisLayouts holds the speakers of every channel of the Vorbis channel
+// order of mapping family 1 (RFC 7845, section 5.1.1.2), for 3 to 8
+// channels. The rear channels of 7.1 are mixed like the side channels.
+var vorbisLayouts = [...][]int{
+	// L, C, R.
+	3: {speaker_left, speaker_center, speaker_right},
+	// FL, FR, RL, RR.
+	4: {speaker_left, speaker_right, speaker_side_left, speaker_side_right},
+	// FL, C, FR, RL, RR.
+	5: {speaker_left, speaker_center, speaker_right, speaker_side_left, speaker_side_right},
+	// 5.1: FL, C, FR, RL, RR, LFE.
+	6: {speaker_left, speaker_center, speaker_right, speaker_side_left, speaker_side_right, speaker_lfe},
+	// 6.1: FL, C, FR, SL, SR, RC, LFE.
+	7: {speaker_left, speaker_center, speaker_right, speaker_side_left, speaker_side_right, speaker_rear_center, speaker_lfe},
+	// 7.1: FL, C, FR, SL, SR, RL, RR, LFE.
+	8: {speaker_left, speaker_center, speaker_right, speaker_side_left, speaker_side_right, speaker_side_left, speaker_side_right, speaker_lfe},
+}
+
+// speakerGains holds the left and right gains of every speaker before
+// normalization. As in ITU-R BS.775, the center is mixed at -3 dB into
+// both sides and the LFE channel is dropped; it only extends the bass of
+// the main channels. The surround channels are mixed at sqrt(3)/2 into
+// their own side and 1/2 into the other, which keeps some of their
+// spread, and the rear center at -3 dB of that into both sides.
+var speakerGains = [...][2]float64{
+	speaker_left:        {1, 0},
+	speaker_center:      {math.Sqrt2 / 2, math.Sqrt2 / 2},
+	speaker_right:       {0, 1},
+	speaker_side_left:   {math.Sqrt(3) / 2, 0.5},
+	speaker_side_right:  {0.5, math.Sqrt(3) / 2},
+	speaker_rear_center: {math.Sqrt(3) / 2 * math.Sqrt2 / 2, math.Sqrt(3) / 2 * math.Sqrt2 / 2},
+	speaker_lfe:         {0, 0},
+}
+
+// stereoDownmix holds the left and right gains of every channel of the
+// layouts. The gains of each side are normalized to a total of 1, so that
+// the mix cannot clip.
+var stereoDownmix = func() (downmix [len(vorbisLayouts)][][2]float32) {
+	for channels, layout := range vorbisLayouts {
+		var total [2]float64
+		for _, speaker := range layout {
+			total[0] += speakerGains[speaker][0]
+			total[1] += speakerGains[speaker][1]
+		}
+		for _, speaker := range layout {
+			gains := speakerGains[speaker]
+			downmix[channels] = append(downmix[channels], [2]float32{
+				float32(gains[0] / total[0]),
+				float32(gains[1] / total[1]),
+			})
+		}
+	}
+	return downmix
+}()
+
+// Downmix mixes interleaved PCM in the channel layout of mapping family 0
+// or 1 to stereo or mono, as produced by a MultistreamDecoder.
+type Downmix struct {
+	channels int
+	out      int
+	// matrix holds the gain of every input channel for every output
+	// channel, matrix[c*out+o].
+	matrix []float32
+}
+
+// NewStereoDownmix returns a downmix of channels channels, 1 to 8, to
+// stereo. Mono is copied to both sides and stereo is left as is.
+func NewStereoDownmix(channels int) (*Downmix, error) {
+	m := &Downmix{channels: channels, out: 2}
+	switch {
+	case channels == 1:
+		m.matrix = []float32{1, 1}
+	case channels == 2:
+		m.matrix = []float32{1, 0, 0, 1}
+	case channels > 2 && channels < len(stereoDownmix):
+		for _, gains := range stereoDownmix[channels] {
+			m.matrix = append(m.matrix, gains[0], gains[1])
+		}
+	default:
+		return nil, fmt.Errorf("no stereo downmix for %d channels", channels)
+	}
+	return m, nil
+}
+
+// NewMonoDownmix returns a downmix of channels channels, 1 to 8, to mono,
+// as the average of the sides of the stereo downmix.
+func NewMonoDownmix(channels int) (*Downmix, error) {
+	stereo, err := NewStereoDownmix(channels)
+	if err != nil {
+		return nil, fmt.Errorf("no mono downmix for %d channels", channels)
+	}
+	m := &Downmix{channels: channels, out: 1, matrix: make([]float32, channels)}
+	for c := range channels {
+		m.matrix[c] = (stereo.matrix[2*c] + stereo.matrix[2*c+1]) / 2
+	}
+	if channels == 1 {
+		m.matrix[0] = 1
+	}
+	return m, nil
+}
+
+// Channels returns the number of input and output channels of the
+// downmix.
+func (m *Downmix) Channels() (in int, out int) {
+	return m.channels, m.out
+}
+
+// Mix downmixes the samples of src into dst and returns the number of
+// samples per channel, limited by the size of both.
+func (m *Downmix) Mix(dst, src []float32) int {
+	n := min(len(src)/m.channels, len(dst)/m.out)
+	for i := range n {
+		in := src[i*m.channels : (i+1)*m.channels]
+		for o := range m.out {
+			var sum float32
+			for c, x := range in {
+				sum += m.matrix[c*m.out+o] * x
+			}
+			dst[i*m.out+o] = sum
+		}
+	}
+	return n
+}
+
+// MixInt16 is like Mix, for 16-bit samples, which are rounded and clipped.
+func (m *Downmix) MixInt16(dst, src []int16) int {
+	n := min(len(src)/m.channels, len(dst)/m.out)
+	for i := range n {
+		in := src[i*m.channels : (i+1)*m.channels]
+		for o := range m.out {
+			var sum float32
+			for c, x := range in {
+				sum += m.matrix[c*m.out+o] * float32(x)
+			}
+			dst[i*m.out+o] = int16(max(math.MinInt16, min(math.MaxInt16, math.Round(float64(sum)))))
+		}
+	}
+	return n
+}
@@ -0,0 +1,100 @@
+package decoder
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDownmixMatrices(t *testing.T) {
+	// The gains of the 5.1 and 7.1 layouts before normalization: front,
+	// center, own and other surround side.
+	const (
+		c = math.Sqrt2 / 2
+		s = 0.8660254
+		o = 0.5
+	)
+	tests := []struct {
+		channels int
+		stereo   [][2]float64
+	}{
+		{6, [][2]float64{{1, 0}, {c, c}, {0, 1}, {s, o}, {o, s}, {0, 0}}},
+		{8, [][2]float64{{1, 0}, {c, c}, {0, 1}, {s, o}, {o, s}, {s, o}, {o, s}, {0, 0}}},
+	}
+	for _, test := range tests {
+		var total float64
+		for _, gains := range test.stereo {
+			total += gains[0]
+		}
+
+		stereo, err := NewStereoDownmix(test.channels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mono, err := NewMonoDownmix(test.channels)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for ch, gains := range test.stereo {
+			left := stereo.matrix[2*ch]
+			right := stereo.matrix[2*ch+1]
+			if math.Abs(float64(left)-gains[0]/total) > 1e-6 || math.Abs(float64(right)-gains[1]/total) > 1e-6 {
+				t.Errorf("%d channels: channel %d has stereo gains %v, %v", test.channels, ch, left, right)
+			}
+			expected := (gains[0] + gains[1]) / 2 / total
+			if math.Abs(float64(mono.matrix[ch])-expected) > 1e-6 {
+				t.Errorf("%d channels: channel %d has mono gain %v, expected %v", test.channels, ch, mono.matrix[ch], expected)
+			}
+		}
+	}
+}
+
+func TestDownmixPeak(t *testing.T) {
+	// Full scale on every channel does not clip, and the LFE channel is
+	// dropped.
+	for channels := 1; channels <= 8; channels++ {
+		for _, mix := range []func(int) (*Downmix, error){NewStereoDownmix, NewMonoDownmix} {
+			m, err := mix(channels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, out := m.Channels()
+
+			src := make([]float32, channels)
+			for ch := range src {
+				src[ch] = 1
+			}
+			dst := make([]float32, out)
+			m.Mix(dst, src)
+			for o, x := range dst {
+				if x > 1.000001 {
+					t.Errorf("%d channels to %d: output %d peaks at %v", channels, out, o, x)
+				}
+			}
+
+			if channels < 6 {
+				continue
+			}
+			src = make([]float32, channels)
+			src[channels-1] = 1
+			m.Mix(dst, src)
+			for o, x := range dst {
+				if x != 0 {
+					t.Errorf("%d channels to %d: LFE mixed into output %d at %v", channels, out, o, x)
+				}
+			}
+		}
+	}
+}
+
+func TestDownmixInt16(t *testing.T) {
+	m, err := NewStereoDownmix(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := []int16{32767, 32767, 32767, 32767, 32767, 32767, -32768, -32768, -32768, -32768, -32768, -32768}
+	dst := make([]int16, 4)
+	n := m.MixInt16(dst, src)
+	if n != 2 || dst[0] != 32767 || dst[1] != 32767 || dst[2] != -32768 || dst[3] != -32768 {
+		t.Fatalf("mixed %d samples %v", n, dst)
+	}
+}
@@ -0,0 +1,466 @@
+package decoder
+
+import "math/bits"
+
+// The decoder works in fixed point, like the reference decoder, so that
+// the output is bit-exact with it. The helpers follow the arithmetic
+// macros of the reference: 16-bit operands are truncated to int16 and
+// results are 32-bit, wrapping on overflow.
+
+const (
+	q15_one        = 32767
+	sig_shift      = 12
+	sig_sat        = 300000000
+	norm_scaling   = 16384
+	db_shift       = 10
+	celt_sig_scale = 32768
+)
+
+// ecILog returns the number of bits needed to represent v, 0 for 0.
+func ecILog(v uint32) int {
+	return bits.Len32(v)
+}
+
+func celtILog2(x int32) int {
+	return bits.Len32(uint32(x)) - 1
+}
+
+func celtZLog2(x int32) int {
+	if x <= 0 {
+		return 0
+	}
+	return celtILog2(x)
+}
+
+func extract16(x int32) int32 {
+	return int32(int16(x))
+}
+
+func shl16(a int32, shift int) int32 {
+	return int32(int16(uint16(a) << shift))
+}
+
+func shl32(a int32, shift int) int32 {
+	return int32(uint32(a) << shift)
+}
+
+func pshr32(a int32, shift int) int32 {
+	return (a + (1 << shift >> 1)) >> shift
+}
+
+func vshr32(a int32, shift int) int32 {
+	if shift > 0 {
+		return a >> shift
+	}
+	return shl32(a, -shift)
+}
+
+func round16(x int32, shift int) int32 {
+	return extract16(pshr32(x, shift))
+}
+
+func saturate(x, a int32) int32 {
+	if x > a {
+		return a
+	}
+	if x < -a {
+		return -a
+	}
+	return x
+}
+
+func saturate16(x int32) int32 {
+	if x > 32767 {
+		return 32767
+	}
+	if x < -32768 {
+		return -32768
+	}
+	return x
+}
+
+func sround16(x int32, shift int) int32 {
+	return extract16(saturate(pshr32(x, shift), 32767))
+}
+
+func add16(a, b int32) int32 {
+	return int32(int16(a) + int16(b))
+}
+
+func sub16(a, b int32) int32 {
+	return int32(int16(a)) - int32(int16(b))
+}
+
+func add32Ovflw(a, b int32) int32 {
+	return int32(uint32(a) + uint32(b))
+}
+
+func sub32Ovflw(a, b int32) int32 {
+	return int32(uint32(a) - uint32(b))
+}
+
+func mult16_16(a, b int32) int32 {
+	return int32(int16(a)) * int32(int16(b))
+}
+
+func mult16_16_q14(a, b int32) int32 { return mult16_16(a, b) >> 14 }
+func mult16_16_q15(a, b int32) int32 { return mult16_16(a, b) >> 15 }
+
+func mult16_16_p15(a, b int32) int32 { return (16384 + mult16_16(a, b)) >> 15 }
+
+func mult16_32_q15(a, b int32) int32 {
+	return int32(int64(int16(a)) * int64(b) >> 15)
+}
+
+func mult32_32_q31(a, b int32) int32 {
+	return int32(int64(a) * int64(b) >> 31)
+}
+
+func mac16_16(c, a, b int32) int32 {
+	return c + mult16_16(a, b)
+}
+
+func abs32(x int32) int32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// sig2word16 converts a signal in Q12 to a 16-bit sample.
+func sig2word16(x int32) int16 {
+	x = pshr32(x, sig_shift)
+	return int16(min(max(x, -32768), 32767))
+}
+
+// fracMul16 multiplies two Q15 values.
+func fracMul16(a, b int32) int32 {
+	return (16384 + int32(int16(a))*int32(int16(b))) >> 15
+}
+
+func isqrt32(val uint32) uint32 {
+	g := uint32(0)
+	bshift := (ecILog(val) - 1) >> 1
+	b := uint32(1) << bshift
+	for {
+		t := (g<<1 + b) << bshift
+		if t <= val {
+			g += b
+			val -= t
+		}
+		b >>= 1
+		bshift--
+		if bshift < 0 {
+			break
+		}
+	}
+	return g
+}
+
+func fracDiv32(a, b int32) int32 {
+	shift := celtILog2(b) - 29
+	a = vshr32(a, shift)
+	b = vshr32(b, shift)
+	// 16-bit reciprocal.
+	rcp := round16(celtRcp(round16(b, 16)), 3)
+	result := mult16_32_q15(rcp, a)
+	rem := pshr32(a, 2) - mult32_32_q31(result, b)
+	result = result + shl32(mult16_32_q15(rcp, rem), 2)
+	if result >= 536870912 {
+		return 2147483647
+	} else if result <= -536870912 {
+		return -2147483647
+	}
+	return shl32(result, 2)
+}
+
+// celtRsqrtNorm is the reciprocal square root in the range [0.25,1), Q16
+// in and Q14 out.
+func celtRsqrtNorm(x int32) int32 {
+	n := extract16(x - 32768)
+	r := add16(23557, mult16_16_q15(n, add16(-13490, mult16_16_q15(n, 6713))))
+	r2 := extract16(mult16_16_q15(r, r))
+	y := shl16(sub16(add16(mult16_16_q15(r2, n), r2), 16384), 1)
+	return add16(r, mult16_16_q15(r, mult16_16_q15(y, sub16(mult16_16_q15(y, 12288), 16384))))
+}
+
+var celtSqrtCoefs = [5]int32{23175, 11561, -3011, 1699, -664}
+
+// celtSqrt is the square root, QX in and QX/2 out.
+func celtSqrt(x int32) int32 {
+	if x == 0 {
+		return 0
+	} else if x >= 1073741824 {
+		return 32767
+	}
+	k := (celtILog2(x) >> 1) - 7
+	x = vshr32(x, 2*k)
+	n := extract16(x - 32768)
+	c := celtSqrtCoefs
+	rt := add16(c[0], mult16_16_q15(n, add16(c[1], mult16_16_q15(n, add16(c[2],
+		mult16_16_q15(n, add16(c[3], mult16_16_q15(n, c[4]))))))))
+	return vshr32(rt, 7-k)
+}
+
+func celtCosPi2(x int32) int32 {
+	x2 := extract16(mult16_16_p15(x, x))
+	return add16(1, min(32766, sub16(32767, x2)+mult16_16_p15(x2, -7651+mult16_16_p15(x2, 8277+mult16_16_p15(-626, x2)))))
+}
+
+func celtCosNorm(x int32) int32 {
+	x = x & 0x0001ffff
+	if x > 1<<16 {
+		x = 1<<17 - x
+	}
+	if x&0x00007fff != 0 {
+		if x < 1<<15 {
+			return celtCosPi2(extract16(x))
+		}
+		return -celtCosPi2(extract16(65536 - x))
+	}
+	if x&0x0000ffff != 0 {
+		return 0
+	} else if x&0x0001ffff != 0 {
+		return -32767
+	}
+	return 32767
+}
+
+// celtRcp is the reciprocal, Q15 in and Q16 out.
+func celtRcp(x int32) int32 {
+	i := celtILog2(x)
+	n := extract16(vshr32(x, i-15) - 32768)
+	r := add16(30840, mult16_16_q15(-15420, n))
+	r = extract16(sub16(r, mult16_16_q15(r, add16(mult16_16_q15(r, n), add16(r, -32768)))))
+	r = extract16(sub16(r, add16(1, mult16_16_q15(r, add16(mult16_16_q15(r, n), add16(r, -32768))))))
+	return vshr32(r, i-16)
+}
+
+func celtDiv(a, b int32) int32 {
+	return mult32_32_q31(a, celtRcp(b))
+}
+
+var celtLog2Coefs = [5]int32{-6801 + (1 << (13 - db_shift)), 15746, -5217, 2545, -1401}
+
+// celtLog2 is the base-2 logarithm, Q14 in and Q10 out.
+func celtLog2(x int32) int32 {
+	if x == 0 {
+		return -32767
+	}
+	i := celtILog2(x)
+	n := extract16(vshr32(x, i-15) - 32768 - 16384)
+	c := celtLog2Coefs
+	frac := add16(c[0], mult16_16_q15(n, add16(c[1], mult16_16_q15(n, add16(c[2], mult16_16_q15(n, add16(c[3], mult16_16_q15(n, c[4]))))))))
+	return extract16(shl16(int32(i-13), db_shift) + frac>>(14-db_shift))
+}
+
+func celtExp2Frac(x int32) int32 {
+	frac := shl16(x, 4)
+	return add16(16383, mult16_16_q15(frac, add16(22804, mult16_16_q15(frac, add16(14819, mult16_16_q15(10204, frac))))))
+}
+
+// celtExp2 is the base-2 exponential, Q10 in and Q16 out.
+func celtExp2(x int32) int32 {
+	integer := int(int16(x) >> 10)
+	if integer > 14 {
+		return 0x7f000000
+	} else if integer < -15 {
+		return 0
+	}
+	frac := celtExp2Frac(extract16(x - shl16(int32(integer), 10)))
+	return vshr32(frac, -integer-2)
+}
+
+func celtMaxAbs16(x []int16) int32 {
+	var maxval, minval int16
+	for _, v := range x {
+		maxval = max(maxval, v)
+		minval = min(minval, v)
+	}
+	return max(int32(maxval), -int32(minval))
+}
+
+func celtMaxAbs32(x []int32) int32 {
+	var maxval, minval int32
+	for _, v := range x {
+		maxval = max(maxval, v)
+		minval = min(minval, v)
+	}
+	return max(maxval, -minval)
+}
+
+// The SILK helpers follow the macros of the reference SILK decoder.
+
+func silkSMULWB(a, b int32) int32 {
+	return int32(int64(a) * int64(int16(b)) >> 16)
+}
+
+func silkSMLAWB(a, b, c int32) int32 {
+	return int32(int64(a) + int64(b)*int64(int16(c))>>16)
+}
+
+func silkSMULBB(a, b int32) int32 {
+	return int32(int16(a)) * int32(int16(b))
+}
+
+func silkSMLABB(a, b, c int32) int32 {
+	return a + int32(int16(b))*int32(int16(c))
+}
+
+func silkSMULWW(a, b int32) int32 {
+	return int32(int64(a) * int64(b) >> 16)
+}
+
+func silkSMLAWW(a, b, c int32) int32 {
+	return int32(int64(a) + int64(b)*int64(c)>>16)
+}
+
+func silkSMMUL(a, b int32) int32 {
+	return int32(int64(a) * int64(b) >> 32)
+}
+
+func silkSMULTT(a, b int32) int32 {
+	return (a >> 16) * (b >> 16)
+}
+
+func silkMLAOvflw(a, b, c int32) int32 {
+	return int32(uint32(a) + uint32(b)*uint32(c))
+}
+
+func silkSMLABBOvflw(a, b, c int32) int32 {
+	return int32(uint32(a) + uint32(int32(int16(b))*int32(int16(c))))
+}
+
+func silkRand(seed int32) int32 {
+	return silkMLAOvflw(907633515, seed, 196314165)
+}
+
+func silkLShift(a int32, shift int) int32 {
+	return int32(uint32(a) << shift)
+}
+
+func silkRShiftRound(a int32, shift int) int32 {
+	if shift == 1 {
+		return a>>1 + a&1
+	}
+	return (a>>(shift-1) + 1) >> 1
+}
+
+func silkRShiftRound64(a int64, shift int) int64 {
+	if shift == 1 {
+		return a>>1 + a&1
+	}
+	return (a>>(shift-1) + 1) >> 1
+}
+
+func silkSat16(a int32) int32 {
+	return saturate16(a)
+}
+
+func silkLimit(a, limit1, limit2 int32) int32 {
+	if limit1 > limit2 {
+		return min(max(a, limit2), limit1)
+	}
+	return min(max(a, limit1), limit2)
+}
+
+func silkLimitInt(a, limit1, limit2 int) int {
+	if limit1 > limit2 {
+		return min(max(a, limit2), limit1)
+	}
+	return min(max(a, limit1), limit2)
+}
+
+func silkLShiftSat32(a int32, shift int) int32 {
+	return silkLShift(silkLimit(a, -0x80000000>>shift, 0x7fffffff>>shift), shift)
+}
+
+func silkAddSat32(a, b int32) int32 {
+	sum := int64(a) + int64(b)
+	return int32(min(max(sum, -0x80000000), 0x7fffffff))
+}
+
+func silkSubSat32(a, b int32) int32 {
+	diff := int64(a) - int64(b)
+	return int32(min(max(diff, -0x80000000), 0x7fffffff))
+}
+
+func silkAbs(a int32) int32 {
+	if a > 0 {
+		return a
+	}
+	return -a
+}
+
+func silkCLZ32(in int32) int32 {
+	return int32(bits.LeadingZeros32(uint32(in)))
+}
+
+func silkROR32(a int32, rot int) int32 {
+	return int32(bits.RotateLeft32(uint32(a), -rot))
+}
+
+// silkCLZFrac returns the number of leading zeros and the 7 bits after the
+// leading one.
+func silkCLZFrac(in int32) (lz, frac_Q7 int32) {
+	lzeros := silkCLZ32(in)
+	return lzeros, silkROR32(in, int(24-lzeros)) & 0x7f
+}
+
+func silkSqrtApprox(x int32) int32 {
+	if x <= 0 {
+		return 0
+	}
+	lz, frac_Q7 := silkCLZFrac(x)
+	var y int32 = 46214 // sqrt(2) * 32768
+	if lz&1 != 0 {
+		y = 32768
+	}
+	y >>= lz >> 1
+	return silkSMLAWB(y, y, silkSMULBB(213, frac_Q7))
+}
+
+// silkDiv32VarQ approximates (a32 << Qres) / b32.
+func silkDiv32VarQ(a32, b32 int32, Qres int) int32 {
+	a_headrm := int(silkCLZ32(silkAbs(a32))) - 1
+	a32_nrm := silkLShift(a32, a_headrm)
+	b_headrm := int(silkCLZ32(silkAbs(b32))) - 1
+	b32_nrm := silkLShift(b32, b_headrm)
+
+	// Inverse of b32, with 14 bits of precision.
+	b32_inv := (0x7fffffff >> 2) / (b32_nrm >> 16)
+
+	result := silkSMULWB(a32_nrm, b32_inv)
+	a32_nrm = sub32Ovflw(a32_nrm, silkLShift(silkSMMUL(b32_nrm, result), 3))
+	result = silkSMLAWB(result, a32_nrm, b32_inv)
+
+	lshift := 29 + a_headrm - b_headrm - Qres
+	if lshift < 0 {
+		return silkLShiftSat32(result, -lshift)
+	}
+	if lshift < 32 {
+		return result >> lshift
+	}
+	return 0
+}
+
+// silkInverse32VarQ approximates (1 << Qres) / b32.
+func silkInverse32VarQ(b32 int32, Qres int) int32 {
+	b_headrm := int(silkCLZ32(silkAbs(b32))) - 1
+	b32_nrm := silkLShift(b32, b_headrm)
+
+	b32_inv := (0x7fffffff >> 2) / (b32_nrm >> 16)
+
+	result := silkLShift(b32_inv, 16)
+	err_Q32 := silkLShift((1<<29)-silkSMULWB(b32_nrm, b32_inv), 3)
+	result = silkSMLAWW(result, err_Q32, b32_inv)
+
+	lshift := 61 - b_headrm - Qres
+	if lshift <= 0 {
+		return silkLShiftSat32(result, -lshift)
+	}
+	if lshift < 32 {
+		return result >> lshift
+	}
+	return 0
+}
@@ -0,0 +1,209 @@
+package decoder
+
+// The mixed-radix FFT of the CELT inverse MDCT, in fixed point. Complex
+// values are stored interleaved, the real part first.
+
+type kissTwiddle struct {
+	r, i int16
+}
+
+type kissFFTState struct {
+	nfft    int
+	shift   int
+	factors []int
+	bitrev  []int16
+}
+
+// celtFFTStates are the FFTs of the MDCT for each frame size, from 20 ms
+// down to 2.5 ms. They share the twiddles of the largest one.
+var celtFFTStates = [4]*kissFFTState{
+	{480, -1, []int{5, 96, 3, 32, 4, 8, 2, 4, 4, 1}, celtFFTBitrev480},
+	{240, 1, []int{5, 48, 3, 16, 4, 4, 4, 1}, celtFFTBitrev240},
+	{120, 2, []int{5, 24, 3, 8, 2, 4, 4, 1}, celtFFTBitrev120},
+	{60, 3, []int{5, 12, 3, 4, 4, 1}, celtFFTBitrev60},
+}
+
+// sMul multiplies a Q15 twiddle into a 32-bit value.
+func sMul(a int32, b int16) int32 {
+	return mult16_32_q15(int32(b), a)
+}
+
+func kfBfly2(f []int32, N int) {
+	const tw = 23170 // 0.7071067812 in Q15
+	for range N {
+		// The radix-2 stage always follows a radix-4 one, so m is 4.
+		var tr, ti int32
+
+		tr, ti = f[8], f[9]
+		f[8], f[9] = sub32Ovflw(f[0], tr), sub32Ovflw(f[1], ti)
+		f[0], f[1] = add32Ovflw(f[0], tr), add32Ovflw(f[1], ti)
+
+		tr = sMul(add32Ovflw(f[10], f[11]), tw)
+		ti = sMul(sub32Ovflw(f[11], f[10]), tw)
+		f[10], f[11] = sub32Ovflw(f[2], tr), sub32Ovflw(f[3], ti)
+		f[2], f[3] = add32Ovflw(f[2], tr), add32Ovflw(f[3], ti)
+
+		tr, ti = f[13], -f[12]
+		f[12], f[13] = sub32Ovflw(f[4], tr), sub32Ovflw(f[5], ti)
+		f[4], f[5] = add32Ovflw(f[4], tr), add32Ovflw(f[5], ti)
+
+		tr = sMul(sub32Ovflw(f[15], f[14]), tw)
+		ti = sMul(-add32Ovflw(f[15], f[14]), tw)
+		f[14], f[15] = sub32Ovflw(f[6], tr), sub32Ovflw(f[7], ti)
+		f[6], f[7] = add32Ovflw(f[6], tr), add32Ovflw(f[7], ti)
+
+		f = f[16:]
+	}
+}
+
+// cMul returns the complex product of f[k] and a twiddle.
+func cMul(f []int32, k int, t kissTwiddle) (int32, int32) {
+	r, i := f[2*k], f[2*k+1]
+	return sub32Ovflw(sMul(r, t.r), sMul(i, t.i)), add32Ovflw(sMul(r, t.i), sMul(i, t.r))
+}
+
+func kfBfly4(fout []int32, fstride int, m, N, mm int) {
+	tw := celtFFTTwiddles48000960
+	if m == 1 {
+		// Degenerate case where all the twiddles are 1.
+		f := fout
+		for range N {
+			s0r, s0i := sub32Ovflw(f[0], f[4]), sub32Ovflw(f[1], f[5])
+			f[0], f[1] = add32Ovflw(f[0], f[4]), add32Ovflw(f[1], f[5])
+			s1r, s1i := add32Ovflw(f[2], f[6]), add32Ovflw(f[3], f[7])
+			f[4], f[5] = sub32Ovflw(f[0], s1r), sub32Ovflw(f[1], s1i)
+			f[0], f[1] = add32Ovflw(f[0], s1r), add32Ovflw(f[1], s1i)
+			s1r, s1i = sub32Ovflw(f[2], f[6]), sub32Ovflw(f[3], f[7])
+			f[2] = add32Ovflw(s0r, s1i)
+			f[3] = sub32Ovflw(s0i, s1r)
+			f[6] = sub32Ovflw(s0r, s1i)
+			f[7] = add32Ovflw(s0i, s1r)
+			f = f[8:]
+		}
+		return
+	}
+	for i := range N {
+		f := fout[2*i*mm:]
+		for j := range m {
+			s0r, s0i := cMul(f, j+m, tw[j*fstride])
+			s1r, s1i := cMul(f, j+2*m, tw[2*j*fstride])
+			s2r, s2i := cMul(f, j+3*m, tw[3*j*fstride])
+			s5r, s5i := sub32Ovflw(f[2*j], s1r), sub32Ovflw(f[2*j+1], s1i)
+			f[2*j], f[2*j+1] = add32Ovflw(f[2*j], s1r), add32Ovflw(f[2*j+1], s1i)
+			s3r, s3i := add32Ovflw(s0r, s2r), add32Ovflw(s0i, s2i)
+			s4r, s4i := sub32Ovflw(s0r, s2r), sub32Ovflw(s0i, s2i)
+			f[2*(j+2*m)], f[2*(j+2*m)+1] = sub32Ovflw(f[2*j], s3r), sub32Ovflw(f[2*j+1], s3i)
+			f[2*j], f[2*j+1] = add32Ovflw(f[2*j], s3r), add32Ovflw(f[2*j+1], s3i)
+			f[2*(j+m)] = add32Ovflw(s5r, s4i)
+			f[2*(j+m)+1] = sub32Ovflw(s5i, s4r)
+			f[2*(j+3*m)] = sub32Ovflw(s5r, s4i)
+			f[2*(j+3*m)+1] = add32Ovflw(s5i, s4r)
+		}
+	}
+}
+
+func kfBfly3(fout []int32, fstride int, m, N, mm int) {
+	const epi3i = -28378
+	tw := celtFFTTwiddles48000960
+	for i := range N {
+		f := fout[2*i*mm:]
+		for k := range m {
+			s1r, s1i := cMul(f, k+m, tw[k*fstride])
+			s2r, s2i := cMul(f, k+2*m, tw[2*k*fstride])
+			s3r, s3i := add32Ovflw(s1r, s2r), add32Ovflw(s1i, s2i)
+			s0r, s0i := sub32Ovflw(s1r, s2r), sub32Ovflw(s1i, s2i)
+
+			mr := sub32Ovflw(f[2*k], s3r>>1)
+			mi := sub32Ovflw(f[2*k+1], s3i>>1)
+			s0r, s0i = sMul(s0r, epi3i), sMul(s0i, epi3i)
+			f[2*k], f[2*k+1] = add32Ovflw(f[2*k], s3r), add32Ovflw(f[2*k+1], s3i)
+
+			f[2*(k+2*m)] = add32Ovflw(mr, s0i)
+			f[2*(k+2*m)+1] = sub32Ovflw(mi, s0r)
+			f[2*(k+m)] = sub32Ovflw(mr, s0i)
+			f[2*(k+m)+1] = add32Ovflw(mi, s0r)
+		}
+	}
+}
+
+func kfBfly5(fout []int32, fstride int, m, N, mm int) {
+	const (
+		yar, yai = 10126, -31164
+		ybr, ybi = -26510, -19261
+	)
+	tw := celtFFTTwiddles48000960
+	for i := range N {
+		f := fout[2*i*mm:]
+		for u := range m {
+			i0, i1, i2, i3, i4 := 2*u, 2*(u+m), 2*(u+2*m), 2*(u+3*m), 2*(u+4*m)
+			s0r, s0i := f[i0], f[i0+1]
+			s1r, s1i := cMul(f, u+m, tw[u*fstride])
+			s2r, s2i := cMul(f, u+2*m, tw[2*u*fstride])
+			s3r, s3i := cMul(f, u+3*m, tw[3*u*fstride])
+			s4r, s4i := cMul(f, u+4*m, tw[4*u*fstride])
+
+			s7r, s7i := add32Ovflw(s1r, s4r), add32Ovflw(s1i, s4i)
+			s10r, s10i := sub32Ovflw(s1r, s4r), sub32Ovflw(s1i, s4i)
+			s8r, s8i := add32Ovflw(s2r, s3r), add32Ovflw(s2i, s3i)
+			s9r, s9i := sub32Ovflw(s2r, s3r), sub32Ovflw(s2i, s3i)
+
+			f[i0] = add32Ovflw(f[i0], add32Ovflw(s7r, s8r))
+			f[i0+1] = add32Ovflw(f[i0+1], add32Ovflw(s7i, s8i))
+
+			s5r := add32Ovflw(s0r, add32Ovflw(sMul(s7r, yar), sMul(s8r, ybr)))
+			s5i := add32Ovflw(s0i, add32Ovflw(sMul(s7i, yar), sMul(s8i, ybr)))
+			s6r := add32Ovflw(sMul(s10i, yai), sMul(s9i, ybi))
+			s6i := -add32Ovflw(sMul(s10r, yai), sMul(s9r, ybi))
+
+			f[i1], f[i1+1] = sub32Ovflw(s5r, s6r), sub32Ovflw(s5i, s6i)
+			f[i4], f[i4+1] = add32Ovflw(s5r, s6r), add32Ovflw(s5i, s6i)
+
+			s11r := add32Ovflw(s0r, add32Ovflw(sMul(s7r, ybr), sMul(s8r, yar)))
+			s11i := add32Ovflw(s0i, add32Ovflw(sMul(s7i, ybr), sMul(s8i, yar)))
+			s12r := sub32Ovflw(sMul(s9i, yai), sMul(s10i, ybi))
+			s12i := sub32Ovflw(sMul(s10r, ybi), sMul(s9r, yai))
+
+			f[i2], f[i2+1] = add32Ovflw(s11r, s12r), add32Ovflw(s11i, s12i)
+			f[i3], f[i3+1] = sub32Ovflw(s11r, s12r), sub32Ovflw(s11i, s12i)
+		}
+	}
+}
+
+// fft transforms fout in place. The input must be in bit-reversed order
+// and is not scaled.
+func (st *kissFFTState) fft(fout []int32) {
+	var fstride [8]int
+
+	// The shift can be -1.
+	shift := max(st.shift, 0)
+
+	fstride[0] = 1
+	L := 0
+	for {
+		p := st.factors[2*L]
+		m := st.factors[2*L+1]
+		fstride[L+1] = fstride[L] * p
+		L++
+		if m == 1 {
+			break
+		}
+	}
+	m := st.factors[2*L-1]
+	for i := L - 1; i >= 0; i-- {
+		m2 := 1
+		if i != 0 {
+			m2 = st.factors[2*i-1]
+		}
+		switch st.factors[2*i] {
+		case 2:
+			kfBfly2(fout, fstride[i])
+		case 4:
+			kfBfly4(fout, fstride[i]<<shift, m, fstride[i], m2)
+		case 3:
+			kfBfly3(fout, fstride[i]<<shift, m, fstride[i], m2)
+		case 5:
+			kfBfly5(fout, fstride[i]<<shift, m, fstride[i], m2)
+		}
+		m = m2
+	}
+}
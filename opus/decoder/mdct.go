@@ -0,0 +1,72 @@
+package decoder
+
+// The inverse MDCT of CELT, computed with an FFT of a quarter of its size.
+
+const celt_mdct_size = 1920
+
+// mdctBackward computes the inverse MDCT of the N/2 coefficients of in,
+// read with the given stride, for the frame size N = 1920>>shift. The
+// output holds N/2+overlap samples; the first overlap/2 ones must hold
+// the end of the previous frame, which is overlapped with the new one.
+func mdctBackward(in []int32, out []int32, window []int16, overlap, shift, stride int) {
+	N := celt_mdct_size
+	trig := celtMDCTTwiddles960
+	for range shift {
+		N >>= 1
+		trig = trig[N:]
+	}
+	N2 := N >> 1
+	N4 := N >> 2
+	st := celtFFTStates[shift]
+
+	// Pre-rotate, storing the result directly in bit-reversed order.
+	yp := out[overlap>>1:]
+	xp1 := 0
+	xp2 := stride * (N2 - 1)
+	for i := range N4 {
+		rev := int(st.bitrev[i])
+		yr := add32Ovflw(sMul(in[xp2], trig[i]), sMul(in[xp1], trig[N4+i]))
+		yi := sub32Ovflw(sMul(in[xp1], trig[i]), sMul(in[xp2], trig[N4+i]))
+		// Real and imaginary parts are swapped because we use an FFT
+		// instead of an IFFT.
+		yp[2*rev+1] = yr
+		yp[2*rev] = yi
+		xp1 += 2 * stride
+		xp2 -= 2 * stride
+	}
+
+	st.fft(yp)
+
+	// Post-rotate and de-shuffle from both ends of the buffer at once to
+	// make it in-place.
+	yp0 := 0
+	yp1 := N2 - 2
+	for i := range (N4 + 1) >> 1 {
+		re, im := yp[yp0+1], yp[yp0]
+		t0, t1 := trig[i], trig[N4+i]
+		// The scaling by 2 is done when mixing the windows.
+		yr := add32Ovflw(sMul(re, t0), sMul(im, t1))
+		yi := sub32Ovflw(sMul(re, t1), sMul(im, t0))
+		re, im = yp[yp1+1], yp[yp1]
+		yp[yp0] = yr
+		yp[yp1+1] = yi
+
+		t0, t1 = trig[N4-i-1], trig[N2-i-1]
+		yr = add32Ovflw(sMul(re, t0), sMul(im, t1))
+		yi = sub32Ovflw(sMul(re, t1), sMul(im, t0))
+		yp[yp1] = yr
+		yp[yp0+1] = yi
+		yp0 += 2
+		yp1 -= 2
+	}
+
+	// Mirror on both sides for TDAC.
+	for i := range overlap / 2 {
+		x1 := out[overlap-1-i]
+		x2 := out[i]
+		wp1 := int32(window[i])
+		wp2 := int32(window[overlap-1-i])
+		out[i] = sub32Ovflw(mult16_32_q15(wp2, x2), mult16_32_q15(wp1, x1))
+		out[overlap-1-i] = add32Ovflw(mult16_32_q15(wp1, x2), mult16_32_q15(wp2, x1))
+	}
+}
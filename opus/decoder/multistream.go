@@ -0,0 +1,234 @@
+package decoder
+
+import "fmt"
+
+// silent_channel is the channel mapping index of an output channel that is
+// not coded and decodes to silence.
+const silent_channel = 255
+
+// MultistreamDecoder decodes the packets of a multistream Opus stream, as
+// used by the channel mapping families 1 and 255 of Ogg Opus (RFC 7845,
+// section 5.1.1), to PCM at 48 kHz.
+//
+// A multistream packet holds one Opus packet per stream, all but the last
+// self-delimited (RFC 6716, appendix B). The first coupled streams decode
+// to two channels and the other streams to one, numbered in stream order,
+// and the channel mapping gives the decoded channel of every output
+// channel.
+type MultistreamDecoder struct {
+	channels int
+	coupled  int
+	mapping  []uint8
+	decoders []*Decoder
+
+	// buf holds the output of a stream before it is mapped.
+	buf []int16
+	// pcm holds the output of DecodeFloat before its conversion.
+	pcm []int16
+}
+
+// NewMultistreamDecoder returns a decoder producing channels channels from
+// streams streams, of which the first coupled are stereo, with mapping
+// giving the decoded channel of every output channel, or 255 for silence.
+func NewMultistreamDecoder(channels, streams, coupled int, mapping []uint8) (*MultistreamDecoder, error) {
+	if channels < 1 || channels > 255 {
+		return nil, fmt.Errorf("invalid number of channels %d", channels)
+	}
+	if streams < 1 || coupled < 0 || coupled > streams || streams+coupled > 255 {
+		return nil, fmt.Errorf("invalid stream count %d with %d coupled", streams, coupled)
+	}
+	if len(mapping) != channels {
+		return nil, fmt.Errorf("channel mapping has %d entries for %d channels", len(mapping), channels)
+	}
+	for channel, index := range mapping {
+		if index != silent_channel && int(index) >= streams+coupled {
+			return nil, fmt.Errorf("channel %d maps to decoded channel %d, but there are only %d", channel, index, streams+coupled)
+		}
+	}
+
+	d := &MultistreamDecoder{
+		channels: channels,
+		coupled:  coupled,
+		mapping:  append([]uint8(nil), mapping...),
+		decoders: make([]*Decoder, streams),
+	}
+	for s := range d.decoders {
+		stream_channels := 1
+		if s < coupled {
+			stream_channels = 2
+		}
+		var err error
+		d.decoders[s], err = NewDecoder(stream_channels)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Channels returns the number of output channels of the decoder.
+func (d *MultistreamDecoder) Channels() int {
+	return d.channels
+}
+
+// Reset resets the decoders of all streams to their initial state.
+func (d *MultistreamDecoder) Reset() {
+	for _, dec := range d.decoders {
+		dec.Reset()
+	}
+}
+
+// FinalRange returns the final states of the range decoders of all
+// streams after the last packet, combined with exclusive or.
+func (d *MultistreamDecoder) FinalRange() uint32 {
+	var rng uint32
+	for _, dec := range d.decoders {
+		rng ^= dec.rangeFinal
+	}
+	return rng
+}
+
+// Decode decodes a multistream packet into pcm, interleaved, and returns
+// the number of samples per channel. As for Decoder.Decode, a nil packet
+// conceals a lost packet of the duration of pcm.
+func (d *MultistreamDecoder) Decode(packet []byte, pcm []int16) (int, error) {
+	return d.decodeNative(packet, pcm, len(pcm)/d.channels, false)
+}
+
+// DecodeFEC conceals a lost packet of the duration of pcm with the in-band
+// FEC data of packet, the multistream packet that follows it, as for
+// Decoder.DecodeFEC.
+func (d *MultistreamDecoder) DecodeFEC(packet []byte, pcm []int16) (int, error) {
+	return d.decodeNative(packet, pcm, len(pcm)/d.channels, true)
+}
+
+// HasFEC reports whether a stream of a multistream packet holds in-band
+// FEC data.
+func (d *MultistreamDecoder) HasFEC(packet []byte) bool {
+	var frames [48][]byte
+	for s := range d.decoders {
+		self_delimited := s != len(d.decoders)-1
+		if hasLBRR(packet, self_delimited) {
+			return true
+		}
+		_, size, err := parseFrames(packet, self_delimited, &frames)
+		if err != nil {
+			return false
+		}
+		packet = packet[size:]
+	}
+	return false
+}
+
+// DecodeFloat is like Decode, with samples in the range [-1, 1).
+func (d *MultistreamDecoder) DecodeFloat(packet []byte, pcm []float32) (int, error) {
+	frame_size := min(len(pcm)/d.channels, 5760)
+	if cap(d.pcm) < frame_size*d.channels {
+		d.pcm = make([]int16, frame_size*d.channels)
+	}
+	out := d.pcm[:frame_size*d.channels]
+	n, err := d.decodeNative(packet, out, frame_size, false)
+	if err != nil {
+		return 0, err
+	}
+	for i, x := range out[:n*d.channels] {
+		pcm[i] = float32(x) / 32768
+	}
+	return n, nil
+}
+
+// validate checks that a multistream packet holds a packet for every
+// stream, all of the same duration, and returns that duration.
+func (d *MultistreamDecoder) validate(data []byte) (int, error) {
+	var frames [48][]byte
+	samples := 0
+	for s := range d.decoders {
+		if len(data) == 0 {
+			return 0, invalid("no packet for stream %d", s)
+		}
+		count, size, err := parseFrames(data, s != len(d.decoders)-1, &frames)
+		if err != nil {
+			return 0, fmt.Errorf("stream %d: %w", s, err)
+		}
+		stream_samples := count * tocFrameSize(data[0])
+		if stream_samples > 5760 {
+			return 0, invalid("stream %d has a packet of %d samples, which exceeds 120 ms", s, stream_samples)
+		}
+		if s != 0 && stream_samples != samples {
+			return 0, invalid("stream %d has a packet of %d samples, stream 0 of %d", s, stream_samples, samples)
+		}
+		samples = stream_samples
+		data = data[size:]
+	}
+	return samples, nil
+}
+
+// decodeNative decodes a multistream packet, or conceals frame_size
+// samples for a nil packet, into pcm.
+func (d *MultistreamDecoder) decodeNative(data []byte, pcm []int16, frame_size int, decode_fec bool) (int, error) {
+	if frame_size <= 0 {
+		return 0, fmt.Errorf("%w, no room for samples", ErrBufferTooSmall)
+	}
+	// Limit the frame size to 120 ms.
+	frame_size = min(frame_size, 5760)
+	do_plc := len(data) == 0
+	if !do_plc {
+		if len(data) < 2*len(d.decoders)-1 {
+			return 0, invalid("packet of %d bytes cannot hold %d streams", len(data), len(d.decoders))
+		}
+		samples, err := d.validate(data)
+		if err != nil {
+			return 0, err
+		}
+		if samples > frame_size {
+			return 0, fmt.Errorf("%w, %d samples for a packet of %d", ErrBufferTooSmall, frame_size, samples)
+		}
+	}
+	if cap(d.buf) < 2*frame_size {
+		d.buf = make([]int16, 2*frame_size)
+	}
+
+	var frames [48][]byte
+	for s, dec := range d.decoders {
+		self_delimited := s != len(d.decoders)-1
+		var packet []byte
+		if !do_plc {
+			_, size, _ := parseFrames(data, self_delimited, &frames)
+			packet = data[:size]
+			data = data[size:]
+		}
+		buf := d.buf[:frame_size*dec.channels]
+		n, err := dec.decodeNative(packet, buf, frame_size, decode_fec, self_delimited)
+		if err != nil {
+			return 0, fmt.Errorf("stream %d: %w", s, err)
+		}
+		frame_size = n
+
+		// Copy the decoded channels to the output channels they map to.
+		for i := range dec.channels {
+			index := s + d.coupled
+			if s < d.coupled {
+				index = 2*s + i
+			}
+			for c, m := range d.mapping {
+				if int(m) != index {
+					continue
+				}
+				for j := range frame_size {
+					pcm[j*d.channels+c] = buf[j*dec.channels+i]
+				}
+			}
+		}
+	}
+
+	// Silence the channels that are not coded.
+	for c, m := range d.mapping {
+		if m != silent_channel {
+			continue
+		}
+		for j := range frame_size {
+			pcm[j*d.channels+c] = 0
+		}
+	}
+	return frame_size, nil
+}
@@ -0,0 +1,66 @@
+package decoder
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMultistreamDecodeFEC(t *testing.T) {
+	// A multistream decoder of one coupled stream decodes as a stereo
+	// decoder, with the FEC data of the packet after a lost one.
+	packets := readVector(t, "testdata/silk_fec.bit")
+	d, err := NewDecoder(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := NewMultistreamDecoder(2, 1, 1, []uint8{0, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pcm := make([]int16, 5760*2)
+	ms_pcm := make([]int16, 5760*2)
+	fec := 0
+	for i, packet := range packets {
+		var n, ms_n int
+		var err, ms_err error
+		switch {
+		case len(packet.data) > 0:
+			n, err = d.Decode(packet.data, pcm)
+			ms_n, ms_err = ms.Decode(packet.data, ms_pcm)
+		case i+1 < len(packets) && len(packets[i+1].data) > 0:
+			next := packets[i+1].data
+			if ms.HasFEC(next) {
+				fec++
+			}
+			frame_size := d.last_packet_duration
+			n, err = d.DecodeFEC(next, pcm[:frame_size*2])
+			ms_n, ms_err = ms.DecodeFEC(next, ms_pcm[:frame_size*2])
+			if ms_n != frame_size {
+				t.Fatalf("packet %d: %d samples from FEC data, expected %d", i, ms_n, frame_size)
+			}
+		default:
+			frame_size := d.last_packet_duration
+			n, err = d.Decode(nil, pcm[:frame_size*2])
+			ms_n, ms_err = ms.Decode(nil, ms_pcm[:frame_size*2])
+		}
+		if err != nil || ms_err != nil {
+			t.Fatalf("packet %d: %v, %v", i, err, ms_err)
+		}
+		if ms_n != n || !slices.Equal(ms_pcm[:ms_n*2], pcm[:n*2]) {
+			t.Fatalf("packet %d: %d samples differ from the %d of the stereo decoder", i, ms_n, n)
+		}
+		if len(packet.data) > 0 && ms.FinalRange() != packet.final_range {
+			t.Fatalf("packet %d: final range %08x, expected %08x", i, ms.FinalRange(), packet.final_range)
+		}
+	}
+	if fec == 0 {
+		t.Fatal("no lost packet followed by FEC data")
+	}
+
+	// CELT packets carry no FEC data.
+	celt := readVector(t, "testdata/celt_fb.bit")
+	if ms.HasFEC(celt[0].data) {
+		t.Fatal("FEC data found in a CELT packet")
+	}
+}
@@ -0,0 +1,100 @@
+package decoder
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/steabert/gopus/opus/internal/framing"
+)
+
+// The coding modes of a frame, 0 before the first frame.
+const (
+	mode_silk_only = iota + 1
+	mode_hybrid
+	mode_celt_only
+)
+
+// The audio bandwidths of a frame, 0 if unknown.
+const (
+	bandwidth_narrowband = iota + 1
+	bandwidth_mediumband
+	bandwidth_wideband
+	bandwidth_superwideband
+	bandwidth_fullband
+)
+
+// max_frame_size is the largest size of a frame in bytes.
+const max_frame_size = framing.MaxFrameSize
+
+var (
+	// ErrInvalidPacket is returned for a packet that cannot be decoded.
+	ErrInvalidPacket = errors.New("invalid packet")
+	// ErrBufferTooSmall is returned when the output buffer cannot hold
+	// the decoded samples.
+	ErrBufferTooSmall = errors.New("buffer too small")
+)
+
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w, %s", ErrInvalidPacket, fmt.Sprintf(format, args...))
+}
+
+// tocMode returns the coding mode of the TOC byte.
+func tocMode(toc byte) int {
+	return mode_silk_only + framing.TOC(toc).Mode
+}
+
+// tocBandwidth returns the audio bandwidth of the TOC byte.
+func tocBandwidth(toc byte) int {
+	return bandwidth_narrowband + framing.TOC(toc).Bandwidth
+}
+
+// tocFrameSize returns the number of samples at 48 kHz of the frames of
+// the TOC byte.
+func tocFrameSize(toc byte) int {
+	return framing.TOC(toc).FrameSize
+}
+
+// tocChannels returns the number of channels of the TOC byte.
+func tocChannels(toc byte) int {
+	return framing.Channels(toc)
+}
+
+// HasFEC reports whether a packet holds in-band FEC data, with which
+// Decoder.DecodeFEC recovers the packet before it.
+func HasFEC(packet []byte) bool {
+	return hasLBRR(packet, false)
+}
+
+// hasLBRR reports whether the first frame of a packet holds LBRR frames,
+// the in-band FEC of SILK. The LBRR flags of every channel follow its VAD
+// flags, one per 20 ms, as the first bits of the frame, which the range
+// coder leaves as they are.
+func hasLBRR(data []byte, self_delimited bool) bool {
+	var frames [48][]byte
+	if len(data) == 0 || tocMode(data[0]) == mode_celt_only {
+		return false
+	}
+	_, _, err := parseFrames(data, self_delimited, &frames)
+	if err != nil || len(frames[0]) == 0 {
+		return false
+	}
+	nb_frames := max(1, tocFrameSize(data[0])/f20)
+	lbrr := frames[0][0]>>(7-nb_frames)&0x1 != 0
+	if tocChannels(data[0]) == 2 {
+		lbrr = lbrr || frames[0][0]>>(6-2*nb_frames)&0x1 != 0
+	}
+	return lbrr
+}
+
+// parseFrames splits a packet into its frames and returns their number
+// and the size of the packet. A self-delimited packet (RFC 6716, appendix
+// B) may be followed by other data, which is not part of the packet.
+func parseFrames(data []byte, self_delimited bool, frames *[48][]byte) (count int, packet_size int, err error) {
+	var packet framing.Packet
+	err = framing.Parse(data, self_delimited, &packet)
+	if err != nil {
+		return 0, 0, invalid("%v", err)
+	}
+	*frames = packet.Frames
+	return packet.Count, packet.Size, nil
+}
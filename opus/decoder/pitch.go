@@ -0,0 +1,203 @@
+package decoder
+
+// The pitch analysis of the CELT packet loss concealment.
+
+func celtInnerProd(x, y []int16, N int) int32 {
+	var xy int32
+	for i := range N {
+		xy = mac16_16(xy, int32(x[i]), int32(y[i]))
+	}
+	return xy
+}
+
+// celtPitchXcorr computes the correlation of the len samples of x with y
+// at the lags below max_pitch, and returns the largest one.
+func celtPitchXcorr(x, y []int16, xcorr []int32, len, max_pitch int) int32 {
+	maxcorr := int32(1)
+	for i := range max_pitch {
+		sum := celtInnerProd(x, y[i:], len)
+		xcorr[i] = sum
+		maxcorr = max(maxcorr, sum)
+	}
+	return maxcorr
+}
+
+func findBestPitch(xcorr []int32, y []int16, len, max_pitch int, best_pitch *[2]int, yshift int, maxcorr int32) {
+	Syy := int32(1)
+	best_num := [2]int32{-1, -1}
+	var best_den [2]int32
+	xshift := celtILog2(maxcorr) - 14
+
+	best_pitch[0] = 0
+	best_pitch[1] = 1
+	for j := range len {
+		Syy += mult16_16(int32(y[j]), int32(y[j])) >> yshift
+	}
+	for i := range max_pitch {
+		if xcorr[i] > 0 {
+			xcorr16 := extract16(vshr32(xcorr[i], xshift))
+			num := extract16(mult16_16_q15(xcorr16, xcorr16))
+			if mult16_32_q15(num, best_den[1]) > mult16_32_q15(best_num[1], Syy) {
+				if mult16_32_q15(num, best_den[0]) > mult16_32_q15(best_num[0], Syy) {
+					best_num[1] = best_num[0]
+					best_den[1] = best_den[0]
+					best_pitch[1] = best_pitch[0]
+					best_num[0] = num
+					best_den[0] = Syy
+					best_pitch[0] = i
+				} else {
+					best_num[1] = num
+					best_den[1] = Syy
+					best_pitch[1] = i
+				}
+			}
+		}
+		Syy += mult16_16(int32(y[i+len]), int32(y[i+len]))>>yshift - mult16_16(int32(y[i]), int32(y[i]))>>yshift
+		Syy = max(1, Syy)
+	}
+}
+
+// celtFIR5 filters x in place with a 5-tap FIR filter.
+func celtFIR5(x []int16, num *[5]int16, N int) {
+	var mem [5]int32
+	for i := range N {
+		sum := shl32(int32(x[i]), sig_shift)
+		for k := range 5 {
+			sum = mac16_16(sum, int32(num[k]), mem[k])
+		}
+		mem[4] = mem[3]
+		mem[3] = mem[2]
+		mem[2] = mem[1]
+		mem[1] = mem[0]
+		mem[0] = int32(x[i])
+		x[i] = int16(round16(sum, sig_shift))
+	}
+}
+
+// pitchDownsample low-passes and decimates by 2 the len samples of the C
+// channels of x into x_lp, whitening the result.
+func pitchDownsample(x [][]int32, x_lp []int16, len, C int) {
+	var ac [5]int32
+	var lpc [4]int16
+	var lpc2 [5]int16
+	tmp := int32(q15_one)
+	const c1 = 26214 // 0.8 in Q15
+
+	maxabs := celtMaxAbs32(x[0][:len])
+	if C == 2 {
+		maxabs = max(maxabs, celtMaxAbs32(x[1][:len]))
+	}
+	maxabs = max(maxabs, 1)
+	shift := max(celtILog2(maxabs)-10, 0)
+	if C == 2 {
+		shift++
+	}
+
+	for i := 1; i < len>>1; i++ {
+		x_lp[i] = int16(((x[0][2*i-1]+x[0][2*i+1])>>1 + x[0][2*i]) >> 1 >> shift)
+	}
+	x_lp[0] = int16((x[0][1]>>1 + x[0][0]) >> 1 >> shift)
+	if C == 2 {
+		for i := 1; i < len>>1; i++ {
+			x_lp[i] += int16(((x[1][2*i-1]+x[1][2*i+1])>>1 + x[1][2*i]) >> 1 >> shift)
+		}
+		x_lp[0] += int16((x[1][1]>>1 + x[1][0]) >> 1 >> shift)
+	}
+
+	celtAutocorr(x_lp, ac[:], nil, 0, 4, len>>1)
+
+	// Noise floor -40 dB.
+	ac[0] += ac[0] >> 13
+	// Lag windowing.
+	for i := int32(1); i <= 4; i++ {
+		ac[i] -= mult16_32_q15(2*i*i, ac[i])
+	}
+
+	celtLPC(lpc[:], ac[:], 4)
+	for i := range 4 {
+		tmp = mult16_16_q15(29491, tmp) // 0.9 in Q15
+		lpc[i] = int16(mult16_16_q15(int32(lpc[i]), tmp))
+	}
+	// Add a zero.
+	lpc2[0] = lpc[0] + 3277 // 0.8 in Q12
+	lpc2[1] = lpc[1] + int16(mult16_16_q15(c1, int32(lpc[0])))
+	lpc2[2] = lpc[2] + int16(mult16_16_q15(c1, int32(lpc[1])))
+	lpc2[3] = lpc[3] + int16(mult16_16_q15(c1, int32(lpc[2])))
+	lpc2[4] = int16(mult16_16_q15(c1, int32(lpc[3])))
+	celtFIR5(x_lp, &lpc2, len>>1)
+}
+
+// pitchSearch finds the pitch period of x_lp in y, below max_pitch.
+func pitchSearch(x_lp, y []int16, len, max_pitch int) int {
+	var best_pitch [2]int
+	lag := len + max_pitch
+
+	x_lp4 := make([]int16, len>>2)
+	y_lp4 := make([]int16, lag>>2)
+	xcorr := make([]int32, max_pitch>>1)
+
+	// Downsample by 2 again.
+	for j := range x_lp4 {
+		x_lp4[j] = x_lp[2*j]
+	}
+	for j := range y_lp4 {
+		y_lp4[j] = y[2*j]
+	}
+
+	xmax := celtMaxAbs16(x_lp4)
+	ymax := celtMaxAbs16(y_lp4)
+	shift := celtILog2(max(1, xmax, ymax)) - 11
+	if shift > 0 {
+		for j := range x_lp4 {
+			x_lp4[j] >>= shift
+		}
+		for j := range y_lp4 {
+			y_lp4[j] >>= shift
+		}
+		// Use double the shift for a MAC.
+		shift *= 2
+	} else {
+		shift = 0
+	}
+
+	// Coarse search with 4x decimation.
+	maxcorr := celtPitchXcorr(x_lp4, y_lp4, xcorr, len>>2, max_pitch>>2)
+	findBestPitch(xcorr, y_lp4, len>>2, max_pitch>>2, &best_pitch, 0, maxcorr)
+
+	// Finer search with 2x decimation.
+	maxcorr = 1
+	for i := range max_pitch >> 1 {
+		xcorr[i] = 0
+		if abs(i-2*best_pitch[0]) > 2 && abs(i-2*best_pitch[1]) > 2 {
+			continue
+		}
+		var sum int32
+		for j := range len >> 1 {
+			sum += mult16_16(int32(x_lp[j]), int32(y[i+j])) >> shift
+		}
+		xcorr[i] = max(-1, sum)
+		maxcorr = max(maxcorr, sum)
+	}
+	findBestPitch(xcorr, y, len>>1, max_pitch>>1, &best_pitch, shift+1, maxcorr)
+
+	// Refine by pseudo-interpolation.
+	offset := 0
+	if best_pitch[0] > 0 && best_pitch[0] < (max_pitch>>1)-1 {
+		a := xcorr[best_pitch[0]-1]
+		b := xcorr[best_pitch[0]]
+		c := xcorr[best_pitch[0]+1]
+		if c-a > mult16_32_q15(22938, b-a) { // 0.7 in Q15
+			offset = 1
+		} else if a-c > mult16_32_q15(22938, b-c) {
+			offset = -1
+		}
+	}
+	return 2*best_pitch[0] - offset
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,87 @@
+package decoder
+
+// Decoding of the CELT band energies (RFC 6716, section 4.3.2).
+
+var (
+	celtPredCoef = [4]int32{29440, 26112, 21248, 16384}
+	celtBetaCoef = [4]int32{30147, 22282, 12124, 6554}
+)
+
+const celt_beta_intra = 4915
+
+// unquantCoarseEnergy decodes the coarse energies, in 6 dB steps, with
+// the time and frequency prediction.
+func unquantCoarseEnergy(start, end int, oldEBands []int16, intra bool, rd *rangeDecoder, C, LM int) {
+	prob_model := celtEProbModel[LM][0]
+	var prev [2]int32
+	var coef, beta int32
+	if intra {
+		prob_model = celtEProbModel[LM][1]
+		coef = 0
+		beta = celt_beta_intra
+	} else {
+		beta = celtBetaCoef[LM]
+		coef = celtPredCoef[LM]
+	}
+
+	budget := len(rd.buf) * 8
+
+	// Decode at a fixed coarse resolution.
+	for i := start; i < end; i++ {
+		for c := range C {
+			var qi int
+			tell := rd.tell()
+			switch {
+			case budget-tell >= 15:
+				pi := 2 * min(i, 20)
+				qi = rd.decodeLaplace(uint32(prob_model[pi])<<7, int(prob_model[pi+1])<<6)
+			case budget-tell >= 2:
+				qi = rd.decodeICDF(celtSmallEnergyICDF, 2)
+				qi = qi>>1 ^ -(qi & 1)
+			case budget-tell >= 1:
+				qi = -b2i(rd.decodeBitLogp(1))
+			default:
+				qi = -1
+			}
+			q := int32(qi) << db_shift
+
+			e := &oldEBands[i+c*celt_nb_ebands]
+			*e = max(-9<<db_shift, *e)
+			tmp := pshr32(mult16_16(coef, int32(*e)), 8) + prev[c] + q<<7
+			tmp = max(-28<<(db_shift+7), tmp)
+			*e = int16(pshr32(tmp, 7))
+			prev[c] = prev[c] + q<<7 - mult16_16(beta, pshr32(q, 8))
+		}
+	}
+}
+
+// unquantFineEnergy decodes the fine energies of the bands.
+func unquantFineEnergy(start, end int, oldEBands []int16, fine_quant []int, rd *rangeDecoder, C int) {
+	for i := start; i < end; i++ {
+		if fine_quant[i] <= 0 {
+			continue
+		}
+		for c := range C {
+			q2 := int32(rd.decodeBits(uint(fine_quant[i])))
+			offset := sub16((q2<<db_shift+512)>>fine_quant[i], 512)
+			oldEBands[i+c*celt_nb_ebands] += int16(offset)
+		}
+	}
+}
+
+// unquantEnergyFinalise uses the bits left to refine the energies.
+func unquantEnergyFinalise(start, end int, oldEBands []int16, fine_quant, fine_priority []int, bits_left int, rd *rangeDecoder, C int) {
+	for prio := range 2 {
+		for i := start; i < end && bits_left >= C; i++ {
+			if fine_quant[i] >= celt_max_fine_bits || fine_priority[i] != prio {
+				continue
+			}
+			for c := range C {
+				q2 := int32(rd.decodeBits(1))
+				offset := (shl16(q2, db_shift) - 512) >> (fine_quant[i] + 1)
+				oldEBands[i+c*celt_nb_ebands] += int16(offset)
+				bits_left--
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package decoder
+
+// Range decoder (RFC 6716, section 4.1). The symbols are read from the
+// start of the frame, the raw bits from its end.
+
+const (
+	ec_sym_bits    = 8
+	ec_code_bits   = 32
+	ec_sym_max     = 1<<ec_sym_bits - 1
+	ec_code_top    = 1 << (ec_code_bits - 1)
+	ec_code_bot    = ec_code_top >> ec_sym_bits
+	ec_code_extra  = (ec_code_bits-2)%ec_sym_bits + 1
+	ec_window_size = 32
+	ec_uint_bits   = 8
+	// bitres is the resolution of the fractional bit counts, in 1/8 bits.
+	bitres = 3
+)
+
+type rangeDecoder struct {
+	buf []byte
+	// endOffs is the number of bytes read for raw bits from the end,
+	// endWindow holds the nendBits bits of them not yet used.
+	endOffs   int
+	endWindow uint32
+	nendBits  int
+	// nbitsTotal is the number of whole bits read, not counting the
+	// partial bits in the range.
+	nbitsTotal int
+	offs       int
+	rng        uint32
+	// val is the difference between the top of the range and the input,
+	// minus one. ext is the normalization factor of the last decode.
+	val uint32
+	ext uint32
+	rem int
+	err bool
+}
+
+func (d *rangeDecoder) readByte() int {
+	if d.offs < len(d.buf) {
+		b := d.buf[d.offs]
+		d.offs++
+		return int(b)
+	}
+	return 0
+}
+
+func (d *rangeDecoder) readByteFromEnd() int {
+	if d.endOffs < len(d.buf) {
+		d.endOffs++
+		return int(d.buf[len(d.buf)-d.endOffs])
+	}
+	return 0
+}
+
+// normalize reads input until the range lies in the high-order symbol.
+func (d *rangeDecoder) normalize() {
+	for d.rng <= ec_code_bot {
+		d.nbitsTotal += ec_sym_bits
+		d.rng <<= ec_sym_bits
+		sym := d.rem
+		d.rem = d.readByte()
+		sym = (sym<<ec_sym_bits | d.rem) >> (ec_sym_bits - ec_code_extra)
+		d.val = (d.val<<ec_sym_bits + uint32(ec_sym_max&^sym)) & (ec_code_top - 1)
+	}
+}
+
+func (d *rangeDecoder) init(buf []byte) {
+	*d = rangeDecoder{buf: buf}
+	d.nbitsTotal = ec_code_bits + 1 - ((ec_code_bits-ec_code_extra)/ec_sym_bits)*ec_sym_bits
+	d.rng = 1 << ec_code_extra
+	d.rem = d.readByte()
+	d.val = d.rng - 1 - uint32(d.rem>>(ec_sym_bits-ec_code_extra))
+	d.normalize()
+}
+
+func (d *rangeDecoder) decode(ft uint32) uint32 {
+	d.ext = d.rng / ft
+	s := d.val / d.ext
+	return ft - min(s+1, ft)
+}
+
+func (d *rangeDecoder) decodeBin(bits uint) uint32 {
+	d.ext = d.rng >> bits
+	s := d.val / d.ext
+	return 1<<bits - min(s+1, 1<<bits)
+}
+
+func (d *rangeDecoder) update(fl, fh, ft uint32) {
+	s := d.ext * (ft - fh)
+	d.val -= s
+	if fl > 0 {
+		d.rng = d.ext * (fh - fl)
+	} else {
+		d.rng -= s
+	}
+	d.normalize()
+}
+
+// decodeBitLogp decodes a bit that is one with probability 1/(1<<logp).
+func (d *rangeDecoder) decodeBitLogp(logp uint) bool {
+	r := d.rng
+	v := d.val
+	s := r >> logp
+	ret := v < s
+	if ret {
+		d.rng = s
+	} else {
+		d.val = v - s
+		d.rng = r - s
+	}
+	d.normalize()
+	return ret
+}
+
+// decodeICDF decodes a symbol with an inverse cumulative distribution of
+// ftb bits.
+func (d *rangeDecoder) decodeICDF(icdf []uint8, ftb uint) int {
+	s := d.rng
+	v := d.val
+	r := s >> ftb
+	ret := -1
+	var t uint32
+	for {
+		t = s
+		ret++
+		s = r * uint32(icdf[ret])
+		if v >= s {
+			break
+		}
+	}
+	d.val = v - s
+	d.rng = t - s
+	d.normalize()
+	return ret
+}
+
+// decodeUint decodes a value uniformly distributed in [0, ft).
+func (d *rangeDecoder) decodeUint(ft uint32) uint32 {
+	ft--
+	ftb := ecILog(ft)
+	if ftb > ec_uint_bits {
+		ftb -= ec_uint_bits
+		ft1 := ft>>ftb + 1
+		s := d.decode(ft1)
+		d.update(s, s+1, ft1)
+		t := s<<ftb | d.decodeBits(uint(ftb))
+		if t <= ft {
+			return t
+		}
+		d.err = true
+		return ft
+	}
+	ft++
+	s := d.decode(ft)
+	d.update(s, s+1, ft)
+	return s
+}
+
+// decodeBits reads raw bits from the end of the frame.
+func (d *rangeDecoder) decodeBits(bits uint) uint32 {
+	window := d.endWindow
+	available := d.nendBits
+	if uint(available) < bits {
+		for {
+			window |= uint32(d.readByteFromEnd()) << available
+			available += ec_sym_bits
+			if available > ec_window_size-ec_sym_bits {
+				break
+			}
+		}
+	}
+	ret := window & (1<<bits - 1)
+	window >>= bits
+	available -= int(bits)
+	d.endWindow = window
+	d.nendBits = available
+	d.nbitsTotal += int(bits)
+	return ret
+}
+
+// tell returns the number of bits read so far, rounded up.
+func (d *rangeDecoder) tell() int {
+	return d.nbitsTotal - ecILog(d.rng)
+}
+
+var tellFracCorrection = [8]uint32{35733, 38967, 42495, 46340, 50535, 55109, 60097, 65535}
+
+// tellFrac returns the number of bits read so far in 1/8 bits.
+func (d *rangeDecoder) tellFrac() uint32 {
+	nbits := uint32(d.nbitsTotal) << bitres
+	l := ecILog(d.rng)
+	r := d.rng >> (l - 16)
+	b := r>>12 - 8
+	if r > tellFracCorrection[b] {
+		b++
+	}
+	return nbits - uint32(l<<3) - b
+}
+
+// laplace_minp is the minimum probability of an energy delta.
+const (
+	laplace_log_minp = 0
+	laplace_minp     = 1 << laplace_log_minp
+	// laplace_nmin is the minimum number of guaranteed representable
+	// energy deltas, in one direction.
+	laplace_nmin = 16
+)
+
+func laplaceGetFreq1(fs0 uint32, decay int) uint32 {
+	ft := 32768 - laplace_minp*(2*laplace_nmin) - fs0
+	return ft * uint32(16384-decay) >> 15
+}
+
+// decodeLaplace decodes a Laplace distributed value with the probability
+// fs of zero and the given decay (RFC 6716, section 4.3.2.1).
+func (d *rangeDecoder) decodeLaplace(fs uint32, decay int) int {
+	val := 0
+	fm := d.decodeBin(15)
+	fl := uint32(0)
+	if fm >= fs {
+		val++
+		fl = fs
+		fs = laplaceGetFreq1(fs, decay) + laplace_minp
+		// Search the decaying part of the distribution.
+		for fs > laplace_minp && fm >= fl+2*fs {
+			fs *= 2
+			fl += fs
+			fs = (fs - 2*laplace_minp) * uint32(decay) >> 15
+			fs += laplace_minp
+			val++
+		}
+		// Everything beyond that has probability laplace_minp.
+		if fs <= laplace_minp {
+			di := (fm - fl) >> (laplace_log_minp + 1)
+			val += int(di)
+			fl += 2 * di * laplace_minp
+		}
+		if fm < fl+fs {
+			val = -val
+		} else {
+			fl += fs
+		}
+	}
+	d.update(fl, min(fl+fs, 32768), 32768)
+	return val
+}
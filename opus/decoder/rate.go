@@ -0,0 +1,397 @@
+package decoder
+
+// The CELT bit allocation (RFC 6716, section 4.3.3).
+
+const (
+	celt_max_pseudo     = 40
+	celt_log_max_pseudo = 6
+	celt_max_fine_bits  = 8
+	celt_fine_offset    = 21
+	celt_alloc_steps    = 6
+
+	qtheta_offset          = 4
+	qtheta_offset_twophase = 16
+)
+
+// celtUdiv divides n by d as unsigned integers, like the reference.
+func celtUdiv(n, d int32) int32 {
+	return int32(uint32(n) / uint32(d))
+}
+
+// getPulses returns the number of pulses of a pseudo-pulse index.
+func getPulses(i int) int {
+	if i < 8 {
+		return i
+	}
+	return (8 + i&7) << (i>>3 - 1)
+}
+
+func pulseCache(band, LM int) []uint8 {
+	return celtCacheBits50[celtCacheIndex50[(LM+1)*celt_nb_ebands+band]:]
+}
+
+// bits2pulses returns the pseudo-pulse index whose cost is the closest to
+// bits, in 1/8 bits.
+func bits2pulses(band, LM, bits int) int {
+	cache := pulseCache(band, LM)
+	lo := 0
+	hi := int(cache[0])
+	bits--
+	for range celt_log_max_pseudo {
+		mid := (lo + hi + 1) >> 1
+		if int(cache[mid]) >= bits {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	lo_bits := -1
+	if lo != 0 {
+		lo_bits = int(cache[lo])
+	}
+	if bits-lo_bits <= int(cache[hi])-bits {
+		return lo
+	}
+	return hi
+}
+
+// pulses2bits returns the cost of a pseudo-pulse index, in 1/8 bits.
+func pulses2bits(band, LM, pulses int) int {
+	if pulses == 0 {
+		return 0
+	}
+	return int(pulseCache(band, LM)[pulses]) + 1
+}
+
+// initCaps computes the maximum allocation of each band.
+func initCaps(cap []int, LM, C int) {
+	for i := range celt_nb_ebands {
+		N := int(celtEBand5ms[i+1]-celtEBand5ms[i]) << LM
+		cap[i] = (int(celtCacheCaps50[celt_nb_ebands*(2*LM+C-1)+i]) + 64) * C * N >> 2
+	}
+}
+
+// celtAllocation holds the result of the bit allocation.
+type celtAllocation struct {
+	codedBands    int
+	intensity     int
+	dual_stereo   bool
+	balance       int32
+	pulses        [celt_nb_ebands]int
+	fine_quant    [celt_nb_ebands]int
+	fine_priority [celt_nb_ebands]int
+}
+
+func interpBits2Pulses(a *celtAllocation, start, end, skip_start int, bits1, bits2, thresh, cap []int, total int32, skip_rsv, intensity_rsv, dual_stereo_rsv, C, LM int, rd *rangeDecoder) {
+	eBands := celtEBand5ms
+	bits := a.pulses[:]
+	ebits := a.fine_quant[:]
+	fine_priority := a.fine_priority[:]
+
+	alloc_floor := C << bitres
+	stereo := 0
+	if C > 1 {
+		stereo = 1
+	}
+	logM := LM << bitres
+	lo := 0
+	hi := 1 << celt_alloc_steps
+	for range celt_alloc_steps {
+		mid := (lo + hi) >> 1
+		psum := int32(0)
+		done := false
+		for j := end - 1; j >= start; j-- {
+			tmp := bits1[j] + mid*bits2[j]>>celt_alloc_steps
+			if tmp >= thresh[j] || done {
+				done = true
+				// Don't allocate more than we can actually use.
+				psum += int32(min(tmp, cap[j]))
+			} else if tmp >= alloc_floor {
+				psum += int32(alloc_floor)
+			}
+		}
+		if psum > total {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	psum := int32(0)
+	done := false
+	for j := end - 1; j >= start; j-- {
+		tmp := bits1[j] + lo*bits2[j]>>celt_alloc_steps
+		if tmp < thresh[j] && !done {
+			if tmp >= alloc_floor {
+				tmp = alloc_floor
+			} else {
+				tmp = 0
+			}
+		} else {
+			done = true
+		}
+		// Don't allocate more than we can actually use.
+		tmp = min(tmp, cap[j])
+		bits[j] = tmp
+		psum += int32(tmp)
+	}
+
+	// Decide which bands to skip, working backwards from the end.
+	codedBands := end
+	for ; ; codedBands-- {
+		j := codedBands - 1
+		// Never skip the first band, nor a band that has been boosted by
+		// dynalloc.
+		if j <= skip_start {
+			// Give the bit we reserved to end skipping back.
+			total += int32(skip_rsv)
+			break
+		}
+		// Figure out how many left-over bits we would be adding to this
+		// band. This can include bits we've stolen back from higher,
+		// skipped bands.
+		left := total - psum
+		width := int32(eBands[codedBands] - eBands[start])
+		percoeff := celtUdiv(left, width)
+		left -= width * percoeff
+		rem := max(left-int32(eBands[j]-eBands[start]), 0)
+		band_width := int32(eBands[codedBands] - eBands[j])
+		band_bits := int(int32(bits[j]) + percoeff*band_width + rem)
+		// Only code a skip decision if we're above the threshold for this
+		// band. Otherwise it is force-skipped.
+		if band_bits >= max(thresh[j], alloc_floor+1<<bitres) {
+			if rd.decodeBitLogp(1) {
+				break
+			}
+			// We used a bit to skip this band.
+			psum += 1 << bitres
+			band_bits -= 1 << bitres
+		}
+		// Reclaim the bits originally allocated to this band.
+		psum -= int32(bits[j] + intensity_rsv)
+		if intensity_rsv > 0 {
+			intensity_rsv = int(celtLog2FracTable[j-start])
+		}
+		psum += int32(intensity_rsv)
+		if band_bits >= alloc_floor {
+			// If we have enough for a fine energy bit per channel, use it.
+			psum += int32(alloc_floor)
+			bits[j] = alloc_floor
+		} else {
+			// Otherwise this band gets nothing at all.
+			bits[j] = 0
+		}
+	}
+
+	// Decode the intensity and dual stereo parameters.
+	if intensity_rsv > 0 {
+		a.intensity = start + int(rd.decodeUint(uint32(codedBands+1-start)))
+	} else {
+		a.intensity = 0
+	}
+	if a.intensity <= start {
+		total += int32(dual_stereo_rsv)
+		dual_stereo_rsv = 0
+	}
+	if dual_stereo_rsv > 0 {
+		a.dual_stereo = rd.decodeBitLogp(1)
+	} else {
+		a.dual_stereo = false
+	}
+
+	// Allocate the remaining bits.
+	left := total - psum
+	width := int32(eBands[codedBands] - eBands[start])
+	percoeff := celtUdiv(left, width)
+	left -= width * percoeff
+	for j := start; j < codedBands; j++ {
+		bits[j] += int(percoeff) * int(eBands[j+1]-eBands[j])
+	}
+	for j := start; j < codedBands; j++ {
+		tmp := int(min(left, int32(eBands[j+1]-eBands[j])))
+		bits[j] += tmp
+		left -= int32(tmp)
+	}
+
+	balance := int32(0)
+	j := start
+	for ; j < codedBands; j++ {
+		var excess int32
+		N0 := int(eBands[j+1] - eBands[j])
+		N := N0 << LM
+		bit := int32(bits[j]) + balance
+		if N > 1 {
+			excess = max(bit-int32(cap[j]), 0)
+			bits[j] = int(bit - excess)
+			// Compensate for the extra DoF in stereo.
+			den := C * N
+			if C == 2 && N > 2 && !a.dual_stereo && j < a.intensity {
+				den++
+			}
+			NClogN := den * (int(celtLogN400[j]) + logM)
+			// Offset for the number of fine bits by log2(N)/2 +
+			// FINE_OFFSET compared to their "fair share" of total/N.
+			offset := NClogN>>1 - den*celt_fine_offset
+			// N=2 is the only point that doesn't match the curve.
+			if N == 2 {
+				offset += den << bitres >> 2
+			}
+			// Change the offset for allocating the second and third fine
+			// energy bit.
+			if bits[j]+offset < den*2<<bitres {
+				offset += NClogN >> 2
+			} else if bits[j]+offset < den*3<<bitres {
+				offset += NClogN >> 3
+			}
+			// Divide with rounding.
+			ebits[j] = max(0, bits[j]+offset+den<<(bitres-1))
+			ebits[j] = int(celtUdiv(int32(ebits[j]), int32(den))) >> bitres
+			// Make sure not to bust.
+			if C*ebits[j] > bits[j]>>bitres {
+				ebits[j] = bits[j] >> stereo >> bitres
+			}
+			// More than that is useless because that's about as far as
+			// PVQ can go.
+			ebits[j] = min(ebits[j], celt_max_fine_bits)
+			// If we rounded down or capped this band, make it a candidate
+			// for the final fine energy pass.
+			fine_priority[j] = b2i(ebits[j]*(den<<bitres) >= bits[j]+offset)
+			// Remove the allocated fine bits; the rest are assigned to
+			// PVQ.
+			bits[j] -= C * ebits[j] << bitres
+		} else {
+			// For N=1, all bits go to fine energy except for a single sign
+			// bit.
+			excess = max(0, bit-int32(C<<bitres))
+			bits[j] = int(bit - excess)
+			ebits[j] = 0
+			fine_priority[j] = 1
+		}
+		// Fine energy can't take advantage of the re-balancing in
+		// quantAllBands, so do it here.
+		if excess > 0 {
+			extra_fine := min(int(excess>>(stereo+bitres)), celt_max_fine_bits-ebits[j])
+			ebits[j] += extra_fine
+			extra_bits := extra_fine * C << bitres
+			fine_priority[j] = b2i(int32(extra_bits) >= excess-balance)
+			excess -= int32(extra_bits)
+		}
+		balance = excess
+	}
+	// Save any remaining bits over the cap for the rebalancing in
+	// quantAllBands.
+	a.balance = balance
+
+	// The skipped bands use all their bits for fine energy.
+	for ; j < end; j++ {
+		ebits[j] = bits[j] >> stereo >> bitres
+		bits[j] = 0
+		fine_priority[j] = b2i(ebits[j] < 1)
+	}
+	a.codedBands = codedBands
+}
+
+// computeAllocation decodes the allocation of the total bits, in 1/8
+// bits, to the bands.
+func computeAllocation(a *celtAllocation, start, end int, offsets, cap []int, alloc_trim int, total int32, C, LM int, rd *rangeDecoder) {
+	var bits1, bits2, thresh, trim_offset [celt_nb_ebands]int
+	eBands := celtEBand5ms
+
+	total = max(total, 0)
+	skip_start := start
+	// Reserve a bit to signal the end of manually skipped bands.
+	skip_rsv := 0
+	if total >= 1<<bitres {
+		skip_rsv = 1 << bitres
+	}
+	total -= int32(skip_rsv)
+	// Reserve bits for the intensity and dual stereo parameters.
+	intensity_rsv, dual_stereo_rsv := 0, 0
+	if C == 2 {
+		intensity_rsv = int(celtLog2FracTable[end-start])
+		if int32(intensity_rsv) > total {
+			intensity_rsv = 0
+		} else {
+			total -= int32(intensity_rsv)
+			if total >= 1<<bitres {
+				dual_stereo_rsv = 1 << bitres
+			}
+			total -= int32(dual_stereo_rsv)
+		}
+	}
+
+	for j := start; j < end; j++ {
+		N := int(eBands[j+1] - eBands[j])
+		// Below this threshold, we're sure not to allocate any PVQ bits.
+		thresh[j] = max(C<<bitres, (3*N<<LM<<bitres)>>4)
+		// Tilt of the allocation curve.
+		trim_offset[j] = C * N * (alloc_trim - 5 - LM) * (end - j - 1) * (1 << (LM + bitres)) >> 6
+		// Give less resolution to single-coefficient bands because they
+		// get more benefit from having one coarse value per coefficient.
+		if N<<LM == 1 {
+			trim_offset[j] -= C << bitres
+		}
+	}
+	lo := 1
+	hi := len(celtBandAllocation) - 1
+	for lo <= hi {
+		done := false
+		psum := 0
+		mid := (lo + hi) >> 1
+		for j := end - 1; j >= start; j-- {
+			N := int(eBands[j+1] - eBands[j])
+			bitsj := C * N * int(celtBandAllocation[mid][j]) << LM >> 2
+			if bitsj > 0 {
+				bitsj = max(0, bitsj+trim_offset[j])
+			}
+			bitsj += offsets[j]
+			if bitsj >= thresh[j] || done {
+				done = true
+				// Don't allocate more than we can actually use.
+				psum += min(bitsj, cap[j])
+			} else if bitsj >= C<<bitres {
+				psum += C << bitres
+			}
+		}
+		if int32(psum) > total {
+			hi = mid - 1
+		} else {
+			lo = mid + 1
+		}
+	}
+	hi = lo
+	lo--
+	for j := start; j < end; j++ {
+		N := int(eBands[j+1] - eBands[j])
+		bits1j := C * N * int(celtBandAllocation[lo][j]) << LM >> 2
+		var bits2j int
+		if hi >= len(celtBandAllocation) {
+			bits2j = cap[j]
+		} else {
+			bits2j = C * N * int(celtBandAllocation[hi][j]) << LM >> 2
+		}
+		if bits1j > 0 {
+			bits1j = max(0, bits1j+trim_offset[j])
+		}
+		if bits2j > 0 {
+			bits2j = max(0, bits2j+trim_offset[j])
+		}
+		if lo > 0 {
+			bits1j += offsets[j]
+		}
+		bits2j += offsets[j]
+		if offsets[j] > 0 {
+			skip_start = j
+		}
+		bits2j = max(0, bits2j-bits1j)
+		bits1[j] = bits1j
+		bits2[j] = bits2j
+	}
+	interpBits2Pulses(a, start, end, skip_start, bits1[:], bits2[:], thresh[:], cap, total, skip_rsv, intensity_rsv, dual_stereo_rsv, C, LM, rd)
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,126 @@
+package decoder
+
+const (
+	silk_cng_buf_mask_max  = 255
+	silk_cng_gain_smth_Q16 = 4634
+	silk_cng_nlsf_smth_Q16 = 16348
+)
+
+// silkCNG is the comfort noise generation state.
+type silkCNG struct {
+	CNG_exc_buf_Q14   [silk_max_frame_length]int32
+	CNG_smth_NLSF_Q15 [silk_max_lpc_order]int16
+	CNG_synth_state   [silk_max_lpc_order]int32
+	CNG_smth_Gain_Q16 int32
+	rand_seed         int32
+	fs_kHz            int
+}
+
+func (d *silkChannelDecoder) resetCNG() {
+	NLSF_step_Q15 := int32(32767 / (d.LPC_order + 1))
+	NLSF_acc_Q15 := int32(0)
+	for i := range d.LPC_order {
+		NLSF_acc_Q15 += NLSF_step_Q15
+		d.sCNG.CNG_smth_NLSF_Q15[i] = int16(NLSF_acc_Q15)
+	}
+	d.sCNG.CNG_smth_Gain_Q16 = 0
+	d.sCNG.rand_seed = 3176576
+}
+
+// cngExc generates the excitation for the comfort noise from random
+// samples of the excitation buffer.
+func (c *silkCNG) cngExc(exc_Q14 []int32) {
+	exc_mask := int32(silk_cng_buf_mask_max)
+	for exc_mask > int32(len(exc_Q14)) {
+		exc_mask >>= 1
+	}
+	seed := c.rand_seed
+	for i := range exc_Q14 {
+		seed = silkRand(seed)
+		idx := seed >> 24 & exc_mask
+		exc_Q14[i] = c.CNG_exc_buf_Q14[idx]
+	}
+	c.rand_seed = seed
+}
+
+// cng updates the comfort noise estimate, and adds the comfort noise to
+// frame when the packet was lost.
+func (d *silkChannelDecoder) cng(ctrl *silkDecoderControl, frame []int16, length int) {
+	c := &d.sCNG
+
+	if d.fs_kHz != c.fs_kHz {
+		d.resetCNG()
+		c.fs_kHz = d.fs_kHz
+	}
+
+	if d.lossCnt == 0 && d.prevSignalType == silk_type_no_voice_activity {
+		// Update the comfort noise parameters: smooth the LSFs.
+		for i := range d.LPC_order {
+			c.CNG_smth_NLSF_Q15[i] += int16(silkSMULWB(int32(d.prevNLSF_Q15[i])-int32(c.CNG_smth_NLSF_Q15[i]), silk_cng_nlsf_smth_Q16))
+		}
+
+		// Find the subframe with the highest gain.
+		max_Gain_Q16 := int32(0)
+		subfr := 0
+		for i := range d.nb_subfr {
+			if ctrl.Gains_Q16[i] > max_Gain_Q16 {
+				max_Gain_Q16 = ctrl.Gains_Q16[i]
+				subfr = i
+			}
+		}
+
+		// Update the excitation buffer with the excitation of this
+		// subframe.
+		copy(c.CNG_exc_buf_Q14[d.subfr_length:d.nb_subfr*d.subfr_length], c.CNG_exc_buf_Q14[:])
+		copy(c.CNG_exc_buf_Q14[:d.subfr_length], d.exc_Q14[subfr*d.subfr_length:])
+
+		// Smooth the gains.
+		for i := range d.nb_subfr {
+			c.CNG_smth_Gain_Q16 += silkSMULWB(ctrl.Gains_Q16[i]-c.CNG_smth_Gain_Q16, silk_cng_gain_smth_Q16)
+		}
+	}
+
+	// Add the comfort noise when the packet is lost or during DTX.
+	if d.lossCnt == 0 {
+		clear(c.CNG_synth_state[:d.LPC_order])
+		return
+	}
+
+	var A_Q12 [silk_max_lpc_order]int16
+	var sig [silk_max_frame_length + silk_max_lpc_order]int32
+	CNG_sig_Q14 := sig[:length+silk_max_lpc_order]
+
+	// Generate the excitation.
+	gain_Q16 := silkSMULWW(int32(d.sPLC.randScale_Q14), d.sPLC.prevGain_Q16[1])
+	if gain_Q16 >= 1<<21 || c.CNG_smth_Gain_Q16 > 1<<23 {
+		gain_Q16 = silkSMULTT(gain_Q16, gain_Q16)
+		gain_Q16 = silkSMULTT(c.CNG_smth_Gain_Q16, c.CNG_smth_Gain_Q16) - gain_Q16<<5
+		gain_Q16 = silkSqrtApprox(gain_Q16) << 16
+	} else {
+		gain_Q16 = silkSMULWW(gain_Q16, gain_Q16)
+		gain_Q16 = silkSMULWW(c.CNG_smth_Gain_Q16, c.CNG_smth_Gain_Q16) - gain_Q16<<5
+		gain_Q16 = silkSqrtApprox(gain_Q16) << 8
+	}
+	gain_Q10 := gain_Q16 >> 6
+
+	c.cngExc(CNG_sig_Q14[silk_max_lpc_order:])
+
+	// Convert the LSFs to a filter, and generate the signal by synthesis
+	// filtering.
+	silkNLSF2A(A_Q12[:], c.CNG_smth_NLSF_Q15[:], d.LPC_order)
+	copy(CNG_sig_Q14, c.CNG_synth_state[:])
+	for i := range length {
+		// Start at order/2 to avoid a bias, silkSMLAWB rounds to -inf.
+		LPC_pred_Q10 := int32(d.LPC_order >> 1)
+		for j := range d.LPC_order {
+			LPC_pred_Q10 = silkSMLAWB(LPC_pred_Q10, CNG_sig_Q14[silk_max_lpc_order+i-j-1], int32(A_Q12[j]))
+		}
+
+		CNG_sig_Q14[silk_max_lpc_order+i] = silkAddSat32(CNG_sig_Q14[silk_max_lpc_order+i], silkLShiftSat32(LPC_pred_Q10, 4))
+
+		// Scale with the gain and add to the signal.
+		noise := silkSat16(silkRShiftRound(silkSMULWW(CNG_sig_Q14[silk_max_lpc_order+i], gain_Q10), 8))
+		frame[i] = int16(silkSat16(int32(frame[i]) + noise))
+	}
+	copy(c.CNG_synth_state[:], CNG_sig_Q14[length:])
+}
@@ -0,0 +1,166 @@
+package decoder
+
+// decodeCore reconstructs the signal of a frame from the excitation
+// pulses, running the long-term (LTP) and short-term (LPC) synthesis
+// filters.
+func (d *silkChannelDecoder) decodeCore(ctrl *silkDecoderControl, xq []int16, pulses []int16) {
+	var sLTP [silk_max_frame_length]int16
+	var sLTP_Q15 [2 * silk_max_frame_length]int32
+	var res_Q14 [silk_max_sub_frame_length]int32
+	var sLPC_Q14 [silk_max_sub_frame_length + silk_max_lpc_order]int32
+	var A_Q12_tmp [silk_max_lpc_order]int16
+	lag := 0
+
+	offset_Q10 := int32(silkQuantizationOffsetsQ10[d.indices.signalType>>1][d.indices.quantOffsetType])
+	NLSF_interpolation_flag := d.indices.NLSFInterpCoef_Q2 < 1<<2
+
+	// Decode the excitation.
+	rand_seed := int32(d.indices.Seed)
+	for i := range d.frame_length {
+		rand_seed = silkRand(rand_seed)
+		exc := int32(pulses[i]) << 14
+		if exc > 0 {
+			exc -= silk_quant_level_adjust_Q10 << 4
+		} else if exc < 0 {
+			exc += silk_quant_level_adjust_Q10 << 4
+		}
+		exc += offset_Q10 << 4
+		if rand_seed < 0 {
+			exc = -exc
+		}
+		d.exc_Q14[i] = exc
+		rand_seed = add32Ovflw(rand_seed, int32(pulses[i]))
+	}
+
+	copy(sLPC_Q14[:silk_max_lpc_order], d.sLPC_Q14_buf[:])
+
+	pexc_Q14 := d.exc_Q14[:]
+	pxq := xq
+	sLTP_buf_idx := d.ltp_mem_length
+
+	for k := range d.nb_subfr {
+		pres_Q14 := res_Q14[:]
+		A_Q12 := ctrl.PredCoef_Q12[k>>1][:]
+		copy(A_Q12_tmp[:], A_Q12[:d.LPC_order])
+		B_Q14 := ctrl.LTPCoef_Q14[k*silk_ltp_order:]
+		signalType := int(d.indices.signalType)
+
+		Gain_Q10 := ctrl.Gains_Q16[k] >> 6
+		inv_gain_Q31 := silkInverse32VarQ(ctrl.Gains_Q16[k], 47)
+
+		// Calculate the gain adjustment factor, and scale the short-term
+		// state with it.
+		gain_adj_Q16 := int32(1 << 16)
+		if ctrl.Gains_Q16[k] != d.prev_gain_Q16 {
+			gain_adj_Q16 = silkDiv32VarQ(d.prev_gain_Q16, ctrl.Gains_Q16[k], 16)
+			for i := range silk_max_lpc_order {
+				sLPC_Q14[i] = silkSMULWW(gain_adj_Q16, sLPC_Q14[i])
+			}
+		}
+		d.prev_gain_Q16 = ctrl.Gains_Q16[k]
+
+		// Avoid an abrupt transition from voiced PLC to unvoiced normal
+		// decoding.
+		if d.lossCnt != 0 && d.prevSignalType == silk_type_voiced &&
+			d.indices.signalType != silk_type_voiced && k < silk_max_nb_subfr/2 {
+			clear(B_Q14[:silk_ltp_order])
+			B_Q14[silk_ltp_order/2] = 4096 // 0.25 in Q14
+			signalType = silk_type_voiced
+			ctrl.pitchL[k] = d.lagPrev
+		}
+
+		if signalType == silk_type_voiced {
+			lag = ctrl.pitchL[k]
+
+			// Re-whitening.
+			if k == 0 || (k == 2 && NLSF_interpolation_flag) {
+				// Rewhiten with the new A coefficients.
+				start_idx := d.ltp_mem_length - lag - d.LPC_order - silk_ltp_order/2
+				if k == 2 {
+					copy(d.outBuf[d.ltp_mem_length:], xq[:2*d.subfr_length])
+				}
+				silkLPCAnalysisFilter(sLTP[start_idx:d.ltp_mem_length],
+					d.outBuf[start_idx+k*d.subfr_length:], A_Q12[:d.LPC_order])
+
+				// After rewhitening, the LTP state is unscaled.
+				if k == 0 {
+					// Do LTP downscaling to reduce the inter-packet
+					// dependency.
+					inv_gain_Q31 = silkSMULWB(inv_gain_Q31, ctrl.LTP_scale_Q14) << 2
+				}
+				for i := range lag + silk_ltp_order/2 {
+					sLTP_Q15[sLTP_buf_idx-i-1] = silkSMULWB(inv_gain_Q31, int32(sLTP[d.ltp_mem_length-i-1]))
+				}
+			} else if gain_adj_Q16 != 1<<16 {
+				// Update the LTP state when the gain changes.
+				for i := range lag + silk_ltp_order/2 {
+					sLTP_Q15[sLTP_buf_idx-i-1] = silkSMULWW(gain_adj_Q16, sLTP_Q15[sLTP_buf_idx-i-1])
+				}
+			}
+		}
+
+		// Long-term prediction.
+		if signalType == silk_type_voiced {
+			pred_lag_idx := sLTP_buf_idx - lag + silk_ltp_order/2
+			for i := range d.subfr_length {
+				p := sLTP_Q15[pred_lag_idx-4 : pred_lag_idx+1]
+				// Start at 2 to avoid a bias, silkSMLAWB rounds to -inf.
+				LTP_pred_Q13 := int32(2)
+				LTP_pred_Q13 = silkSMLAWB(LTP_pred_Q13, p[4], int32(B_Q14[0]))
+				LTP_pred_Q13 = silkSMLAWB(LTP_pred_Q13, p[3], int32(B_Q14[1]))
+				LTP_pred_Q13 = silkSMLAWB(LTP_pred_Q13, p[2], int32(B_Q14[2]))
+				LTP_pred_Q13 = silkSMLAWB(LTP_pred_Q13, p[1], int32(B_Q14[3]))
+				LTP_pred_Q13 = silkSMLAWB(LTP_pred_Q13, p[0], int32(B_Q14[4]))
+				pred_lag_idx++
+
+				// Generate the LPC excitation.
+				pres_Q14[i] = pexc_Q14[i] + LTP_pred_Q13<<1
+
+				sLTP_Q15[sLTP_buf_idx] = pres_Q14[i] << 1
+				sLTP_buf_idx++
+			}
+		} else {
+			pres_Q14 = pexc_Q14
+		}
+
+		for i := range d.subfr_length {
+			// Short-term prediction, starting at order/2 to avoid a bias.
+			LPC_pred_Q10 := int32(d.LPC_order >> 1)
+			for j := range d.LPC_order {
+				LPC_pred_Q10 = silkSMLAWB(LPC_pred_Q10, sLPC_Q14[silk_max_lpc_order+i-j-1], int32(A_Q12_tmp[j]))
+			}
+
+			// Add the prediction to the LPC excitation.
+			sLPC_Q14[silk_max_lpc_order+i] = silkAddSat32(pres_Q14[i], silkLShiftSat32(LPC_pred_Q10, 4))
+
+			// Scale with the gain.
+			pxq[i] = int16(silkSat16(silkRShiftRound(silkSMULWW(sLPC_Q14[silk_max_lpc_order+i], Gain_Q10), 8)))
+		}
+
+		// Update the LPC filter state.
+		copy(sLPC_Q14[:silk_max_lpc_order], sLPC_Q14[d.subfr_length:])
+		pexc_Q14 = pexc_Q14[d.subfr_length:]
+		pxq = pxq[d.subfr_length:]
+	}
+
+	copy(d.sLPC_Q14_buf[:], sLPC_Q14[:silk_max_lpc_order])
+}
+
+// silkLPCAnalysisFilter runs the whitening filter B over in. The first
+// len(B) output samples are set to zero.
+func silkLPCAnalysisFilter(out []int16, in []int16, B []int16) {
+	order := len(B)
+	for ix := order; ix < len(out); ix++ {
+		// The sum may wrap around, so that two wraps cancel each other;
+		// this only happens for invalid streams.
+		out32_Q12 := int32(0)
+		for j := range order {
+			out32_Q12 = silkSMLABBOvflw(out32_Q12, int32(in[ix-1-j]), int32(B[j]))
+		}
+
+		// Subtract the prediction and scale to Q0.
+		out32_Q12 = sub32Ovflw(int32(in[ix])<<12, out32_Q12)
+		out[ix] = int16(silkSat16(silkRShiftRound(out32_Q12, 12)))
+	}
+	clear(out[:order])
+}
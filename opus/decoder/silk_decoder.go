@@ -0,0 +1,484 @@
+package decoder
+
+// SILK decoder (RFC 6716, section 4.2), a port of the fixed-point
+// reference decoder.
+
+const (
+	silk_max_frames_per_packet = 3
+	silk_max_nb_subfr          = 4
+	silk_max_lpc_order         = 16
+	silk_min_lpc_order         = 10
+	silk_ltp_order             = 5
+	silk_max_frame_length      = 320 // 20 ms at 16 kHz
+	silk_max_sub_frame_length  = 80
+	silk_sub_frame_length_ms   = 5
+	silk_ltp_mem_length_ms     = 20
+	silk_stereo_interp_len_ms  = 8
+
+	silk_type_no_voice_activity = 0
+	silk_type_unvoiced          = 1
+	silk_type_voiced            = 2
+
+	silk_code_independently                = 0
+	silk_code_independently_no_ltp_scaling = 1
+	silk_code_conditionally                = 2
+
+	// The loss flags of a frame.
+	silk_flag_decode_normal = 0
+	silk_flag_packet_lost   = 1
+	silk_flag_decode_lbrr   = 2
+)
+
+// silkSideInfo holds the quantization indices of a frame.
+type silkSideInfo struct {
+	GainsIndices      [silk_max_nb_subfr]int8
+	LTPIndex          [silk_max_nb_subfr]int8
+	NLSFIndices       [silk_max_lpc_order + 1]int8
+	lagIndex          int16
+	contourIndex      int8
+	signalType        int8
+	quantOffsetType   int8
+	NLSFInterpCoef_Q2 int8
+	PERIndex          int8
+	LTP_scaleIndex    int8
+	Seed              int8
+}
+
+// silkDecoderControl holds the parameters decoded for a frame.
+type silkDecoderControl struct {
+	pitchL        [silk_max_nb_subfr]int
+	Gains_Q16     [silk_max_nb_subfr]int32
+	PredCoef_Q12  [2][silk_max_lpc_order]int16
+	LTPCoef_Q14   [silk_ltp_order * silk_max_nb_subfr]int16
+	LTP_scale_Q14 int32
+}
+
+// silkChannelDecoder is the decoder state of a single SILK channel.
+type silkChannelDecoder struct {
+	prev_gain_Q16  int32
+	exc_Q14        [silk_max_frame_length]int32
+	sLPC_Q14_buf   [silk_max_lpc_order]int32
+	outBuf         [silk_max_frame_length + 2*silk_max_sub_frame_length]int16
+	lagPrev        int
+	LastGainIndex  int8
+	fs_kHz         int
+	fs_API_hz      int
+	nb_subfr       int
+	frame_length   int
+	subfr_length   int
+	ltp_mem_length int
+	LPC_order      int
+	prevNLSF_Q15   [silk_max_lpc_order]int16
+	// first_frame_after_reset deactivates the NLSF interpolation.
+	first_frame_after_reset bool
+
+	pitch_lag_low_bits_iCDF []uint8
+	pitch_contour_iCDF      []uint8
+
+	nFramesDecoded   int
+	nFramesPerPacket int
+
+	ec_prevSignalType int
+	ec_prevLagIndex   int16
+
+	VAD_flags  [silk_max_frames_per_packet]bool
+	LBRR_flag  bool
+	LBRR_flags [silk_max_frames_per_packet]bool
+
+	resampler silkResampler
+	NLSF_CB   *silkNLSFCodebook
+	indices   silkSideInfo
+	sCNG      silkCNG
+
+	lossCnt        int
+	prevSignalType int
+	sPLC           silkPLC
+}
+
+// init resets the channel decoder.
+func (d *silkChannelDecoder) init() {
+	*d = silkChannelDecoder{}
+	d.first_frame_after_reset = true
+	d.prev_gain_Q16 = 65536
+	d.resetCNG()
+	d.resetPLC()
+}
+
+// setFs sets the internal and the output sample rate.
+func (d *silkChannelDecoder) setFs(fs_kHz, fs_API_Hz int) {
+	d.subfr_length = silk_sub_frame_length_ms * fs_kHz
+	frame_length := d.nb_subfr * d.subfr_length
+
+	// Initialize the resampler when switching the internal or external
+	// sample rate.
+	if d.fs_kHz != fs_kHz || d.fs_API_hz != fs_API_Hz {
+		d.resampler.init(fs_kHz*1000, fs_API_Hz)
+		d.fs_API_hz = fs_API_Hz
+	}
+
+	if d.fs_kHz != fs_kHz || frame_length != d.frame_length {
+		if fs_kHz == 8 {
+			if d.nb_subfr == silk_max_nb_subfr {
+				d.pitch_contour_iCDF = silkPitchContourNBICDF
+			} else {
+				d.pitch_contour_iCDF = silkPitchContour10MsNBICDF
+			}
+		} else {
+			if d.nb_subfr == silk_max_nb_subfr {
+				d.pitch_contour_iCDF = silkPitchContourICDF
+			} else {
+				d.pitch_contour_iCDF = silkPitchContour10MsICDF
+			}
+		}
+		if d.fs_kHz != fs_kHz {
+			d.ltp_mem_length = silk_ltp_mem_length_ms * fs_kHz
+			if fs_kHz == 8 || fs_kHz == 12 {
+				d.LPC_order = silk_min_lpc_order
+				d.NLSF_CB = silkNLSFCBNBMB
+			} else {
+				d.LPC_order = silk_max_lpc_order
+				d.NLSF_CB = silkNLSFCBWB
+			}
+			switch fs_kHz {
+			case 16:
+				d.pitch_lag_low_bits_iCDF = silkUniform8ICDF
+			case 12:
+				d.pitch_lag_low_bits_iCDF = silkUniform6ICDF
+			case 8:
+				d.pitch_lag_low_bits_iCDF = silkUniform4ICDF
+			}
+			d.first_frame_after_reset = true
+			d.lagPrev = 100
+			d.LastGainIndex = 10
+			d.prevSignalType = silk_type_no_voice_activity
+			clear(d.outBuf[:])
+			clear(d.sLPC_Q14_buf[:])
+		}
+		d.fs_kHz = fs_kHz
+		d.frame_length = frame_length
+	}
+}
+
+// decodeFrame decodes a frame, or conceals it when lostFlag says so.
+func (d *silkChannelDecoder) decodeFrame(rd *rangeDecoder, out []int16, lostFlag, condCoding int) int {
+	var ctrl silkDecoderControl
+	L := d.frame_length
+
+	if lostFlag == silk_flag_decode_normal ||
+		(lostFlag == silk_flag_decode_lbrr && d.LBRR_flags[d.nFramesDecoded]) {
+		var pulses [(silk_max_frame_length + silk_shell_codec_frame_length - 1) &^ (silk_shell_codec_frame_length - 1)]int16
+
+		d.decodeIndices(rd, d.nFramesDecoded, lostFlag != silk_flag_decode_normal, condCoding)
+		silkDecodePulses(rd, pulses[:], int(d.indices.signalType), int(d.indices.quantOffsetType), d.frame_length)
+		d.decodeParameters(&ctrl, condCoding)
+		// Run the inverse noise shaping quantization.
+		d.decodeCore(&ctrl, out, pulses[:])
+		d.plc(&ctrl, out, false)
+
+		d.lossCnt = 0
+		d.prevSignalType = int(d.indices.signalType)
+		// A frame has been decoded without errors.
+		d.first_frame_after_reset = false
+	} else {
+		// Handle packet loss by extrapolation.
+		d.indices.signalType = int8(d.prevSignalType)
+		d.plc(&ctrl, out, true)
+	}
+
+	// Update the output buffer.
+	mv_len := d.ltp_mem_length - d.frame_length
+	copy(d.outBuf[:mv_len], d.outBuf[d.frame_length:])
+	copy(d.outBuf[mv_len:], out[:d.frame_length])
+
+	// Comfort noise generation and estimation.
+	d.cng(&ctrl, out, L)
+
+	// Ensure a smooth connection of extrapolated and good frames.
+	d.plcGlueFrames(out, L)
+
+	d.lagPrev = ctrl.pitchL[d.nb_subfr-1]
+	return L
+}
+
+type silkStereoState struct {
+	pred_prev_Q13 [2]int32
+	sMid          [2]int16
+	sSide         [2]int16
+}
+
+// silkDecoder decodes the SILK layer of a packet, for one or two channels.
+type silkDecoder struct {
+	channel_state           [2]silkChannelDecoder
+	sStereo                 silkStereoState
+	nChannelsAPI            int
+	nChannelsInternal       int
+	prev_decode_only_middle bool
+}
+
+// silkDecControl describes the frames passed to silkDecoder.decode.
+type silkDecControl struct {
+	nChannelsAPI       int
+	nChannelsInternal  int
+	API_sampleRate     int
+	internalSampleRate int
+	payloadSize_ms     int
+	// prevPitchLag is set by the decoder, at 48 kHz.
+	prevPitchLag int
+}
+
+func (s *silkDecoder) init() {
+	for n := range s.channel_state {
+		s.channel_state[n].init()
+	}
+	s.sStereo = silkStereoState{}
+	s.prev_decode_only_middle = false
+}
+
+// decode decodes a frame of 10 or 20 ms into samplesOut, interleaved, and
+// returns the number of samples per channel.
+func (s *silkDecoder) decode(ctl *silkDecControl, lostFlag int, newPacketFlag bool, rd *rangeDecoder, samplesOut []int16) int {
+	channel_state := &s.channel_state
+	var MS_pred_Q13 [2]int32
+	decode_only_middle := false
+
+	// Test if this is the first frame in the payload.
+	if newPacketFlag {
+		for n := range ctl.nChannelsInternal {
+			channel_state[n].nFramesDecoded = 0
+		}
+	}
+
+	// On a mono to stereo transition, initialize the state of the second
+	// channel.
+	if ctl.nChannelsInternal > s.nChannelsInternal {
+		channel_state[1].init()
+	}
+
+	stereo_to_mono := ctl.nChannelsInternal == 1 && s.nChannelsInternal == 2 &&
+		ctl.internalSampleRate == 1000*channel_state[0].fs_kHz
+
+	if channel_state[0].nFramesDecoded == 0 {
+		for n := range ctl.nChannelsInternal {
+			ch := &channel_state[n]
+			switch ctl.payloadSize_ms {
+			case 0, 10:
+				// Assume 10 ms on packet loss.
+				ch.nFramesPerPacket = 1
+				ch.nb_subfr = 2
+			case 20:
+				ch.nFramesPerPacket = 1
+				ch.nb_subfr = 4
+			case 40:
+				ch.nFramesPerPacket = 2
+				ch.nb_subfr = 4
+			case 60:
+				ch.nFramesPerPacket = 3
+				ch.nb_subfr = 4
+			}
+			fs_kHz_dec := ctl.internalSampleRate>>10 + 1
+			ch.setFs(fs_kHz_dec, ctl.API_sampleRate)
+		}
+	}
+
+	if ctl.nChannelsAPI == 2 && ctl.nChannelsInternal == 2 && (s.nChannelsAPI == 1 || s.nChannelsInternal == 1) {
+		s.sStereo.pred_prev_Q13 = [2]int32{}
+		s.sStereo.sSide = [2]int16{}
+		channel_state[1].resampler = channel_state[0].resampler
+	}
+	s.nChannelsAPI = ctl.nChannelsAPI
+	s.nChannelsInternal = ctl.nChannelsInternal
+
+	if lostFlag != silk_flag_packet_lost && channel_state[0].nFramesDecoded == 0 {
+		// This is the first call for the payload: decode the VAD and LBRR
+		// flags.
+		for n := range ctl.nChannelsInternal {
+			ch := &channel_state[n]
+			for i := range ch.nFramesPerPacket {
+				ch.VAD_flags[i] = rd.decodeBitLogp(1)
+			}
+			ch.LBRR_flag = rd.decodeBitLogp(1)
+		}
+		for n := range ctl.nChannelsInternal {
+			ch := &channel_state[n]
+			ch.LBRR_flags = [silk_max_frames_per_packet]bool{}
+			if !ch.LBRR_flag {
+				continue
+			}
+			if ch.nFramesPerPacket == 1 {
+				ch.LBRR_flags[0] = true
+			} else {
+				LBRR_symbol := rd.decodeICDF(silkLBRRFlagsICDFPtr[ch.nFramesPerPacket-2], 8) + 1
+				for i := range ch.nFramesPerPacket {
+					ch.LBRR_flags[i] = LBRR_symbol>>i&1 != 0
+				}
+			}
+		}
+
+		if lostFlag == silk_flag_decode_normal {
+			// Regular decoding: skip all LBRR data.
+			for i := range channel_state[0].nFramesPerPacket {
+				for n := range ctl.nChannelsInternal {
+					ch := &channel_state[n]
+					if !ch.LBRR_flags[i] {
+						continue
+					}
+					var pulses [silk_max_frame_length]int16
+					if ctl.nChannelsInternal == 2 && n == 0 {
+						silkStereoDecodePred(rd, &MS_pred_Q13)
+						if !channel_state[1].LBRR_flags[i] {
+							decode_only_middle = silkStereoDecodeMidOnly(rd)
+						}
+					}
+					// Use conditional coding if the previous frame is
+					// available.
+					condCoding := silk_code_independently
+					if i > 0 && ch.LBRR_flags[i-1] {
+						condCoding = silk_code_conditionally
+					}
+					ch.decodeIndices(rd, i, true, condCoding)
+					silkDecodePulses(rd, pulses[:], int(ch.indices.signalType), int(ch.indices.quantOffsetType), ch.frame_length)
+				}
+			}
+		}
+	}
+
+	// Get the mid/side predictor index.
+	if ctl.nChannelsInternal == 2 {
+		frame := channel_state[0].nFramesDecoded
+		if lostFlag == silk_flag_decode_normal ||
+			(lostFlag == silk_flag_decode_lbrr && channel_state[0].LBRR_flags[frame]) {
+			silkStereoDecodePred(rd, &MS_pred_Q13)
+			// For LBRR data, only decode the mid-only flag if the LBRR
+			// flag of the side channel is not set.
+			if (lostFlag == silk_flag_decode_normal && !channel_state[1].VAD_flags[frame]) ||
+				(lostFlag == silk_flag_decode_lbrr && !channel_state[1].LBRR_flags[frame]) {
+				decode_only_middle = silkStereoDecodeMidOnly(rd)
+			} else {
+				decode_only_middle = false
+			}
+		} else {
+			MS_pred_Q13 = s.sStereo.pred_prev_Q13
+		}
+	}
+
+	// Reset the prediction memory of the side channel for the first frame
+	// with side coding.
+	if ctl.nChannelsInternal == 2 && !decode_only_middle && s.prev_decode_only_middle {
+		side := &channel_state[1]
+		clear(side.outBuf[:])
+		clear(side.sLPC_Q14_buf[:])
+		side.lagPrev = 100
+		side.LastGainIndex = 10
+		side.prevSignalType = silk_type_no_voice_activity
+		side.first_frame_after_reset = true
+	}
+
+	// Each channel is decoded with two samples of history in front, for
+	// the stereo prediction and the resampler delay.
+	frame_length := channel_state[0].frame_length
+	var tmp [2][silk_max_frame_length + 2]int16
+	samplesOut1_tmp := [2][]int16{tmp[0][:frame_length+2], tmp[1][:frame_length+2]}
+
+	var has_side bool
+	if lostFlag == silk_flag_decode_normal {
+		has_side = !decode_only_middle
+	} else {
+		has_side = !s.prev_decode_only_middle ||
+			(ctl.nChannelsInternal == 2 && lostFlag == silk_flag_decode_lbrr &&
+				channel_state[1].LBRR_flags[channel_state[1].nFramesDecoded])
+	}
+
+	// Decode a frame for each channel.
+	var nSamplesOutDec int
+	for n := range ctl.nChannelsInternal {
+		ch := &channel_state[n]
+		if n == 0 || has_side {
+			FrameIndex := channel_state[0].nFramesDecoded - n
+			var condCoding int
+			switch {
+			case FrameIndex <= 0:
+				// Code independently if no previous frame is available.
+				condCoding = silk_code_independently
+			case lostFlag == silk_flag_decode_lbrr:
+				condCoding = silk_code_independently
+				if ch.LBRR_flags[FrameIndex-1] {
+					condCoding = silk_code_conditionally
+				}
+			case n > 0 && s.prev_decode_only_middle:
+				// A skipped side frame in this packet needs no LTP
+				// scaling, the LTP state is well-defined.
+				condCoding = silk_code_independently_no_ltp_scaling
+			default:
+				condCoding = silk_code_conditionally
+			}
+			nSamplesOutDec = ch.decodeFrame(rd, samplesOut1_tmp[n][2:], lostFlag, condCoding)
+		} else {
+			clear(samplesOut1_tmp[n][2 : 2+nSamplesOutDec])
+		}
+		ch.nFramesDecoded++
+	}
+
+	if ctl.nChannelsAPI == 2 && ctl.nChannelsInternal == 2 {
+		// Convert mid/side to left/right.
+		s.sStereo.msToLR(samplesOut1_tmp[0], samplesOut1_tmp[1], MS_pred_Q13, channel_state[0].fs_kHz, nSamplesOutDec)
+	} else {
+		// Buffering.
+		copy(samplesOut1_tmp[0][:2], s.sStereo.sMid[:])
+		copy(s.sStereo.sMid[:], samplesOut1_tmp[0][nSamplesOutDec:nSamplesOutDec+2])
+	}
+
+	nSamplesOut := nSamplesOutDec * ctl.API_sampleRate / (channel_state[0].fs_kHz * 1000)
+
+	var resample_out [silk_max_api_fs_khz * 20]int16
+	resample_out_ptr := samplesOut
+	if ctl.nChannelsAPI == 2 {
+		resample_out_ptr = resample_out[:nSamplesOut]
+	}
+
+	for n := range min(ctl.nChannelsAPI, ctl.nChannelsInternal) {
+		// Resample the decoded signal to the API sample rate.
+		channel_state[n].resampler.resample(resample_out_ptr, samplesOut1_tmp[n][1:1+nSamplesOutDec])
+		// Interleave if the output and the stream are stereo.
+		if ctl.nChannelsAPI == 2 {
+			for i := range nSamplesOut {
+				samplesOut[n+2*i] = resample_out_ptr[i]
+			}
+		}
+	}
+
+	// Create two-channel output from a mono stream.
+	if ctl.nChannelsAPI == 2 && ctl.nChannelsInternal == 1 {
+		if stereo_to_mono {
+			// Resample the right channel for a newly collapsed stereo
+			// stream, in case we weren't collapsing when switching to mono.
+			channel_state[1].resampler.resample(resample_out_ptr, samplesOut1_tmp[0][1:1+nSamplesOutDec])
+			for i := range nSamplesOut {
+				samplesOut[1+2*i] = resample_out_ptr[i]
+			}
+		} else {
+			for i := range nSamplesOut {
+				samplesOut[1+2*i] = samplesOut[2*i]
+			}
+		}
+	}
+
+	// Export the pitch lag, measured at 48 kHz.
+	if channel_state[0].prevSignalType == silk_type_voiced {
+		mult_tab := [3]int{6, 4, 3}
+		ctl.prevPitchLag = channel_state[0].lagPrev * mult_tab[(channel_state[0].fs_kHz-8)>>2]
+	} else {
+		ctl.prevPitchLag = 0
+	}
+
+	if lostFlag == silk_flag_packet_lost {
+		// On packet loss, remove the gain clamping to prevent having the
+		// energy "bounce back" if packets are lost while the energy is
+		// going down.
+		for i := range s.nChannelsInternal {
+			channel_state[i].LastGainIndex = 10
+		}
+	} else {
+		s.prev_decode_only_middle = decode_only_middle
+	}
+	return nSamplesOut
+}
@@ -0,0 +1,218 @@
+package decoder
+
+const (
+	silk_shell_codec_frame_length      = 16
+	silk_log2_shell_codec_frame_length = 4
+	silk_max_nb_shell_blocks           = silk_max_frame_length / silk_shell_codec_frame_length
+	silk_n_rate_levels                 = 10
+	silk_max_pulses                    = 16
+	silk_nlsf_quant_max_amplitude      = 4
+)
+
+// decodeIndices decodes the side information of a frame.
+func (d *silkChannelDecoder) decodeIndices(rd *rangeDecoder, FrameIndex int, decode_LBRR bool, condCoding int) {
+	var ec_ix [silk_max_lpc_order]int16
+	var pred_Q8 [silk_max_lpc_order]uint8
+
+	// Decode the signal type and the quantizer offset.
+	var Ix int
+	if decode_LBRR || d.VAD_flags[FrameIndex] {
+		Ix = rd.decodeICDF(silkTypeOffsetVADICDF, 8) + 2
+	} else {
+		Ix = rd.decodeICDF(silkTypeOffsetNoVADICDF, 8)
+	}
+	d.indices.signalType = int8(Ix >> 1)
+	d.indices.quantOffsetType = int8(Ix & 1)
+
+	// Decode the gains. The first subframe is either delta coded, or
+	// coded independently in two stages: the MSBs followed by 3 LSBs.
+	if condCoding == silk_code_conditionally {
+		d.indices.GainsIndices[0] = int8(rd.decodeICDF(silkDeltaGainICDF, 8))
+	} else {
+		d.indices.GainsIndices[0] = int8(rd.decodeICDF(silkGainICDF[d.indices.signalType], 8) << 3)
+		d.indices.GainsIndices[0] += int8(rd.decodeICDF(silkUniform8ICDF, 8))
+	}
+	for i := 1; i < d.nb_subfr; i++ {
+		d.indices.GainsIndices[i] = int8(rd.decodeICDF(silkDeltaGainICDF, 8))
+	}
+
+	// Decode the LSF indices.
+	cb := d.NLSF_CB
+	d.indices.NLSFIndices[0] = int8(rd.decodeICDF(cb.CB1_iCDF[int(d.indices.signalType>>1)*cb.nVectors:], 8))
+	silkNLSFUnpack(&ec_ix, &pred_Q8, cb, int(d.indices.NLSFIndices[0]))
+	for i := range cb.order {
+		Ix = rd.decodeICDF(cb.ec_iCDF[ec_ix[i]:], 8)
+		if Ix == 0 {
+			Ix -= rd.decodeICDF(silkNLSFEXTICDF, 8)
+		} else if Ix == 2*silk_nlsf_quant_max_amplitude {
+			Ix += rd.decodeICDF(silkNLSFEXTICDF, 8)
+		}
+		d.indices.NLSFIndices[i+1] = int8(Ix - silk_nlsf_quant_max_amplitude)
+	}
+
+	// Decode the LSF interpolation factor.
+	if d.nb_subfr == silk_max_nb_subfr {
+		d.indices.NLSFInterpCoef_Q2 = int8(rd.decodeICDF(silkNLSFInterpolationFactorICDF, 8))
+	} else {
+		d.indices.NLSFInterpCoef_Q2 = 4
+	}
+
+	if d.indices.signalType == silk_type_voiced {
+		// Decode the pitch lags, delta coded if possible.
+		decode_absolute_lagIndex := true
+		if condCoding == silk_code_conditionally && d.ec_prevSignalType == silk_type_voiced {
+			delta_lagIndex := int16(rd.decodeICDF(silkPitchDeltaICDF, 8))
+			if delta_lagIndex > 0 {
+				delta_lagIndex -= 9
+				d.indices.lagIndex = d.ec_prevLagIndex + delta_lagIndex
+				decode_absolute_lagIndex = false
+			}
+		}
+		if decode_absolute_lagIndex {
+			d.indices.lagIndex = int16(rd.decodeICDF(silkPitchLagICDF, 8) * (d.fs_kHz >> 1))
+			d.indices.lagIndex += int16(rd.decodeICDF(d.pitch_lag_low_bits_iCDF, 8))
+		}
+		d.ec_prevLagIndex = d.indices.lagIndex
+
+		d.indices.contourIndex = int8(rd.decodeICDF(d.pitch_contour_iCDF, 8))
+
+		// Decode the LTP gains.
+		d.indices.PERIndex = int8(rd.decodeICDF(silkLTPPerIndexICDF, 8))
+		for k := range d.nb_subfr {
+			d.indices.LTPIndex[k] = int8(rd.decodeICDF(silkLTPGainICDFPtrs[d.indices.PERIndex], 8))
+		}
+
+		// Decode the LTP scaling.
+		if condCoding == silk_code_independently {
+			d.indices.LTP_scaleIndex = int8(rd.decodeICDF(silkLTPscaleICDF, 8))
+		} else {
+			d.indices.LTP_scaleIndex = 0
+		}
+	}
+	d.ec_prevSignalType = int(d.indices.signalType)
+
+	d.indices.Seed = int8(rd.decodeICDF(silkUniform4ICDF, 8))
+}
+
+// silkDecodePulses decodes the excitation pulses of a frame.
+func silkDecodePulses(rd *rangeDecoder, pulses []int16, signalType, quantOffsetType, frame_length int) {
+	var sum_pulses, nLshifts [silk_max_nb_shell_blocks]int
+
+	RateLevelIndex := rd.decodeICDF(silkRateLevelsICDF[signalType>>1], 8)
+
+	// Calculate the number of shell blocks, 10 ms at 12 kHz needs a
+	// partial one.
+	iter := frame_length >> silk_log2_shell_codec_frame_length
+	if iter*silk_shell_codec_frame_length < frame_length {
+		iter++
+	}
+
+	// Decode the sums of the pulses per block.
+	cdf_ptr := silkPulsesPerBlockICDF[RateLevelIndex]
+	for i := range iter {
+		nLshifts[i] = 0
+		sum_pulses[i] = rd.decodeICDF(cdf_ptr, 8)
+		// The LSB indication.
+		for sum_pulses[i] == silk_max_pulses+1 {
+			nLshifts[i]++
+			// With 10 LSBs, the table is shifted to not allow
+			// silk_max_pulses+1.
+			icdf := silkPulsesPerBlockICDF[silk_n_rate_levels-1]
+			if nLshifts[i] == 10 {
+				icdf = icdf[1:]
+			}
+			sum_pulses[i] = rd.decodeICDF(icdf, 8)
+		}
+	}
+
+	// Shell decoding.
+	for i := range iter {
+		block := pulses[i*silk_shell_codec_frame_length : (i+1)*silk_shell_codec_frame_length]
+		if sum_pulses[i] > 0 {
+			silkShellDecoder(block, rd, sum_pulses[i])
+		} else {
+			clear(block)
+		}
+	}
+
+	// LSB decoding.
+	for i := range iter {
+		if nLshifts[i] == 0 {
+			continue
+		}
+		nLS := nLshifts[i]
+		block := pulses[i*silk_shell_codec_frame_length : (i+1)*silk_shell_codec_frame_length]
+		for k := range block {
+			abs_q := int32(block[k])
+			for range nLS {
+				abs_q = abs_q<<1 + int32(rd.decodeICDF(silkLsbICDF, 8))
+			}
+			block[k] = int16(abs_q)
+		}
+		// Mark the number of pulses as non-zero for the sign decoding.
+		sum_pulses[i] |= nLS << 5
+	}
+
+	silkDecodeSigns(rd, pulses, frame_length, signalType, quantOffsetType, &sum_pulses)
+}
+
+func silkDecodeSplit(rd *rangeDecoder, p int, shell_table []uint8) (child1, child2 int) {
+	if p > 0 {
+		child1 = rd.decodeICDF(shell_table[silkShellCodeTableOffsets[p]:], 8)
+		return child1, p - child1
+	}
+	return 0, 0
+}
+
+// silkShellDecoder decodes the pulses of one shell block of 16 pulses,
+// by recursively splitting their sum in halves.
+func silkShellDecoder(pulses0 []int16, rd *rangeDecoder, pulses4 int) {
+	var pulses3 [2]int
+	var pulses2 [4]int
+	var pulses1 [8]int
+
+	pulses3[0], pulses3[1] = silkDecodeSplit(rd, pulses4, silkShellCodeTable3)
+	for i2 := range 2 {
+		pulses2[2*i2], pulses2[2*i2+1] = silkDecodeSplit(rd, pulses3[i2], silkShellCodeTable2)
+		for i1 := 2 * i2; i1 < 2*i2+2; i1++ {
+			pulses1[2*i1], pulses1[2*i1+1] = silkDecodeSplit(rd, pulses2[i1], silkShellCodeTable1)
+			for i0 := 2 * i1; i0 < 2*i1+2; i0++ {
+				p0, p1 := silkDecodeSplit(rd, pulses1[i0], silkShellCodeTable0)
+				pulses0[2*i0], pulses0[2*i0+1] = int16(p0), int16(p1)
+			}
+		}
+	}
+}
+
+// silkDecodeSigns decodes the signs of the non-zero pulses.
+func silkDecodeSigns(rd *rangeDecoder, pulses []int16, length, signalType, quantOffsetType int, sum_pulses *[silk_max_nb_shell_blocks]int) {
+	var icdf [2]uint8
+	icdf_ptr := silkSignICDF[7*(quantOffsetType+signalType<<1):]
+	length = (length + silk_shell_codec_frame_length/2) >> silk_log2_shell_codec_frame_length
+	for i := range length {
+		p := sum_pulses[i]
+		if p <= 0 {
+			continue
+		}
+		icdf[0] = icdf_ptr[min(p&0x1f, 6)]
+		q := pulses[i*silk_shell_codec_frame_length : (i+1)*silk_shell_codec_frame_length]
+		for j := range q {
+			if q[j] > 0 {
+				q[j] *= int16(rd.decodeICDF(icdf[:], 8)<<1 - 1)
+			}
+		}
+	}
+}
+
+// silkNLSFUnpack unpacks the entropy table indices and the predictor for
+// a first stage codebook vector.
+func silkNLSFUnpack(ec_ix *[silk_max_lpc_order]int16, pred_Q8 *[silk_max_lpc_order]uint8, cb *silkNLSFCodebook, CB1_index int) {
+	ec_sel := cb.ec_sel[CB1_index*cb.order/2:]
+	for i := 0; i < cb.order; i += 2 {
+		entry := ec_sel[i/2]
+		ec_ix[i] = int16(entry>>1&7) * (2*silk_nlsf_quant_max_amplitude + 1)
+		pred_Q8[i] = cb.pred_Q8[i+int(entry&1)*(cb.order-1)]
+		ec_ix[i+1] = int16(entry>>5&7) * (2*silk_nlsf_quant_max_amplitude + 1)
+		pred_Q8[i+1] = cb.pred_Q8[i+int(entry>>4&1)*(cb.order-1)+1]
+	}
+}
@@ -0,0 +1,505 @@
+package decoder
+
+const (
+	silk_n_levels_qgain               = 64
+	silk_min_delta_gain_quant         = -4
+	silk_max_delta_gain_quant         = 36
+	silk_gain_offset                  = (2*128)/6 + 16*128
+	silk_gain_inv_scale_Q16           = (65536 * (((88 - 2) * 128) / 6)) / (silk_n_levels_qgain - 1)
+	silk_bwe_after_loss_Q16           = 63570
+	silk_nlsf_quant_level_adj         = 102 // 0.1 in Q10
+	silk_quant_level_adjust_Q10       = 80
+	silk_max_lpc_stabilize_iterations = 16
+	silk_pe_min_lag_ms                = 2
+	silk_pe_max_lag_ms                = 18
+)
+
+// decodeParameters dequantizes the side information of a frame.
+func (d *silkChannelDecoder) decodeParameters(ctrl *silkDecoderControl, condCoding int) {
+	var pNLSF_Q15, pNLSF0_Q15 [silk_max_lpc_order]int16
+
+	silkGainsDequant(&ctrl.Gains_Q16, &d.indices.GainsIndices, &d.LastGainIndex,
+		condCoding == silk_code_conditionally, d.nb_subfr)
+
+	// Decode the NLSFs and convert them to prediction filter coefficients.
+	silkNLSFDecode(pNLSF_Q15[:], &d.indices.NLSFIndices, d.NLSF_CB)
+	silkNLSF2A(ctrl.PredCoef_Q12[1][:], pNLSF_Q15[:], d.LPC_order)
+
+	// Interpolation is not allowed right after a reset, which improves
+	// the case of packet loss in the first frame after a switch.
+	if d.first_frame_after_reset {
+		d.indices.NLSFInterpCoef_Q2 = 4
+	}
+
+	if d.indices.NLSFInterpCoef_Q2 < 4 {
+		// Interpolate the NLSFs of the first half of the frame, between
+		// the previous and the current vector.
+		for i := range d.LPC_order {
+			pNLSF0_Q15[i] = d.prevNLSF_Q15[i] + int16(int32(d.indices.NLSFInterpCoef_Q2)*
+				(int32(pNLSF_Q15[i])-int32(d.prevNLSF_Q15[i]))>>2)
+		}
+		silkNLSF2A(ctrl.PredCoef_Q12[0][:], pNLSF0_Q15[:], d.LPC_order)
+	} else {
+		ctrl.PredCoef_Q12[0] = ctrl.PredCoef_Q12[1]
+	}
+
+	copy(d.prevNLSF_Q15[:d.LPC_order], pNLSF_Q15[:])
+
+	// After a packet loss, do a bandwidth expansion of the coefficients.
+	if d.lossCnt != 0 {
+		silkBwexpander(ctrl.PredCoef_Q12[0][:d.LPC_order], silk_bwe_after_loss_Q16)
+		silkBwexpander(ctrl.PredCoef_Q12[1][:d.LPC_order], silk_bwe_after_loss_Q16)
+	}
+
+	if d.indices.signalType == silk_type_voiced {
+		silkDecodePitch(d.indices.lagIndex, int(d.indices.contourIndex), ctrl.pitchL[:], d.fs_kHz, d.nb_subfr)
+
+		// Decode the LTP codebook indices and the LTP scaling.
+		cbk_ptr_Q7 := silkLTPVqPtrsQ7[d.indices.PERIndex]
+		for k := range d.nb_subfr {
+			Ix := d.indices.LTPIndex[k]
+			for i := range silk_ltp_order {
+				ctrl.LTPCoef_Q14[k*silk_ltp_order+i] = int16(cbk_ptr_Q7[Ix][i]) << 7
+			}
+		}
+		ctrl.LTP_scale_Q14 = int32(silkLTPScalesTableQ14[d.indices.LTP_scaleIndex])
+	} else {
+		clear(ctrl.pitchL[:d.nb_subfr])
+		clear(ctrl.LTPCoef_Q14[:silk_ltp_order*d.nb_subfr])
+		d.indices.PERIndex = 0
+		ctrl.LTP_scale_Q14 = 0
+	}
+}
+
+// silkGainsDequant converts the gain indices to linear gains.
+func silkGainsDequant(gain_Q16 *[silk_max_nb_subfr]int32, ind *[silk_max_nb_subfr]int8, prev_ind *int8, conditional bool, nb_subfr int) {
+	for k := range nb_subfr {
+		prev := int(*prev_ind)
+		if k == 0 && !conditional {
+			// The gain index may not go down more than 16 steps (~21.8 dB).
+			prev = max(int(ind[k]), prev-16)
+		} else {
+			// Accumulate the delta indices.
+			ind_tmp := int(ind[k]) + silk_min_delta_gain_quant
+			double_step_size_threshold := 2*silk_max_delta_gain_quant - silk_n_levels_qgain + prev
+			if ind_tmp > double_step_size_threshold {
+				prev += ind_tmp<<1 - double_step_size_threshold
+			} else {
+				prev += ind_tmp
+			}
+		}
+		prev = silkLimitInt(prev, 0, silk_n_levels_qgain-1)
+		*prev_ind = int8(prev)
+
+		// Scale and convert to the linear scale, 3967 is 31 in Q7.
+		gain_Q16[k] = silkLog2Lin(min(silkSMULWB(silk_gain_inv_scale_Q16, int32(prev))+silk_gain_offset, 3967))
+	}
+}
+
+// silkDecodePitch converts the lag and contour indices to the pitch lags
+// of the subframes.
+func silkDecodePitch(lagIndex int16, contourIndex int, pitch_lags []int, Fs_kHz, nb_subfr int) {
+	var Lag_CB [][]int8
+	if Fs_kHz == 8 {
+		if nb_subfr == silk_max_nb_subfr {
+			Lag_CB = silkCBLagsStage2
+		} else {
+			Lag_CB = silkCBLagsStage210Ms
+		}
+	} else {
+		if nb_subfr == silk_max_nb_subfr {
+			Lag_CB = silkCBLagsStage3
+		} else {
+			Lag_CB = silkCBLagsStage310Ms
+		}
+	}
+
+	min_lag := silk_pe_min_lag_ms * Fs_kHz
+	max_lag := silk_pe_max_lag_ms * Fs_kHz
+	lag := min_lag + int(lagIndex)
+	for k := range nb_subfr {
+		pitch_lags[k] = silkLimitInt(lag+int(Lag_CB[k][contourIndex]), min_lag, max_lag)
+	}
+}
+
+// silkNLSFDecode dequantizes an NLSF vector from its codebook path.
+func silkNLSFDecode(pNLSF_Q15 []int16, NLSFIndices *[silk_max_lpc_order + 1]int8, cb *silkNLSFCodebook) {
+	var pred_Q8 [silk_max_lpc_order]uint8
+	var ec_ix [silk_max_lpc_order]int16
+	var res_Q10 [silk_max_lpc_order]int16
+
+	silkNLSFUnpack(&ec_ix, &pred_Q8, cb, int(NLSFIndices[0]))
+
+	// Predictive dequantization of the residual.
+	out_Q10 := int32(0)
+	for i := cb.order - 1; i >= 0; i-- {
+		pred_Q10 := silkSMULBB(out_Q10, int32(pred_Q8[i])) >> 8
+		out_Q10 = int32(NLSFIndices[i+1]) << 10
+		if out_Q10 > 0 {
+			out_Q10 -= silk_nlsf_quant_level_adj
+		} else if out_Q10 < 0 {
+			out_Q10 += silk_nlsf_quant_level_adj
+		}
+		out_Q10 = silkSMLAWB(pred_Q10, out_Q10, cb.quantStepSize_Q16)
+		res_Q10[i] = int16(out_Q10)
+	}
+
+	// Apply the inverse square-rooted weights to the first stage and add
+	// it to the output.
+	base := int(NLSFIndices[0]) * cb.order
+	pCB_element := cb.CB1_NLSF_Q8[base:]
+	pCB_Wght_Q9 := cb.CB1_Wght_Q9[base:]
+	for i := range cb.order {
+		NLSF_Q15_tmp := (int32(res_Q10[i])<<14)/int32(pCB_Wght_Q9[i]) + int32(pCB_element[i])<<7
+		pNLSF_Q15[i] = int16(silkLimit(NLSF_Q15_tmp, 0, 32767))
+	}
+
+	silkNLSFStabilize(pNLSF_Q15[:cb.order], cb.deltaMin_Q15)
+}
+
+const silk_nlsf_stabilize_max_loops = 20
+
+// silkNLSFStabilize moves the NLSFs apart, and away from the borders, if
+// they are closer than the minimum distances NDeltaMin_Q15.
+func silkNLSFStabilize(NLSF_Q15 []int16, NDeltaMin_Q15 []int16) {
+	L := len(NLSF_Q15)
+	loops := 0
+	for ; loops < silk_nlsf_stabilize_max_loops; loops++ {
+		// Find the smallest distance.
+		min_diff_Q15 := int32(NLSF_Q15[0]) - int32(NDeltaMin_Q15[0])
+		I := 0
+		for i := 1; i <= L-1; i++ {
+			diff_Q15 := int32(NLSF_Q15[i]) - (int32(NLSF_Q15[i-1]) + int32(NDeltaMin_Q15[i]))
+			if diff_Q15 < min_diff_Q15 {
+				min_diff_Q15 = diff_Q15
+				I = i
+			}
+		}
+		diff_Q15 := 1<<15 - (int32(NLSF_Q15[L-1]) + int32(NDeltaMin_Q15[L]))
+		if diff_Q15 < min_diff_Q15 {
+			min_diff_Q15 = diff_Q15
+			I = L
+		}
+
+		if min_diff_Q15 >= 0 {
+			return
+		}
+
+		if I == 0 {
+			// Move away from the lower limit.
+			NLSF_Q15[0] = NDeltaMin_Q15[0]
+		} else if I == L {
+			// Move away from the higher limit.
+			NLSF_Q15[L-1] = int16(1<<15 - int32(NDeltaMin_Q15[L]))
+		} else {
+			// Find the extremes for the location of the current center
+			// frequency.
+			min_center_Q15 := int32(0)
+			for k := range I {
+				min_center_Q15 += int32(NDeltaMin_Q15[k])
+			}
+			min_center_Q15 += int32(NDeltaMin_Q15[I] >> 1)
+			max_center_Q15 := int32(1 << 15)
+			for k := L; k > I; k-- {
+				max_center_Q15 -= int32(NDeltaMin_Q15[k])
+			}
+			max_center_Q15 -= int32(NDeltaMin_Q15[I] >> 1)
+
+			// Move apart, keeping the same center frequency.
+			center_freq_Q15 := int16(silkLimit(silkRShiftRound(int32(NLSF_Q15[I-1])+int32(NLSF_Q15[I]), 1),
+				min_center_Q15, max_center_Q15))
+			NLSF_Q15[I-1] = center_freq_Q15 - NDeltaMin_Q15[I]>>1
+			NLSF_Q15[I] = NLSF_Q15[I-1] + NDeltaMin_Q15[I]
+		}
+	}
+
+	// Fall back to a safe and simple method, which is less ideal than the
+	// above.
+	silkInsertionSortInt16(NLSF_Q15)
+	NLSF_Q15[0] = max(NLSF_Q15[0], NDeltaMin_Q15[0])
+	for i := 1; i < L; i++ {
+		NLSF_Q15[i] = max(NLSF_Q15[i], int16(silkSat16(int32(NLSF_Q15[i-1])+int32(NDeltaMin_Q15[i]))))
+	}
+	NLSF_Q15[L-1] = int16(min(int32(NLSF_Q15[L-1]), 1<<15-int32(NDeltaMin_Q15[L])))
+	for i := L - 2; i >= 0; i-- {
+		NLSF_Q15[i] = int16(min(int32(NLSF_Q15[i]), int32(NLSF_Q15[i+1])-int32(NDeltaMin_Q15[i+1])))
+	}
+}
+
+func silkInsertionSortInt16(a []int16) {
+	for i := 1; i < len(a); i++ {
+		value := a[i]
+		j := i - 1
+		for ; j >= 0 && value < a[j]; j-- {
+			a[j+1] = a[j]
+		}
+		a[j+1] = value
+	}
+}
+
+// silk_nlsf2a_qa is the Q domain of the polynomials in silkNLSF2A.
+const silk_nlsf2a_qa = 16
+
+// silkNLSF2AFindPoly computes a polynomial from the interleaved 2*cos of
+// the LSFs.
+func silkNLSF2AFindPoly(out []int32, cLSF []int32, dd int) {
+	out[0] = 1 << silk_nlsf2a_qa
+	out[1] = -cLSF[0]
+	for k := 1; k < dd; k++ {
+		ftmp := cLSF[2*k]
+		out[k+1] = out[k-1]<<1 - int32(silkRShiftRound64(int64(ftmp)*int64(out[k]), silk_nlsf2a_qa))
+		for n := k; n > 1; n-- {
+			out[n] += out[n-2] - int32(silkRShiftRound64(int64(ftmp)*int64(out[n-1]), silk_nlsf2a_qa))
+		}
+		out[1] -= ftmp
+	}
+}
+
+// The orderings of the LSFs that maximize the numerical accuracy of
+// silkNLSF2AFindPoly.
+var (
+	silkNLSF2AOrdering16 = [16]uint8{0, 15, 8, 7, 4, 11, 12, 3, 2, 13, 10, 5, 6, 9, 14, 1}
+	silkNLSF2AOrdering10 = [10]uint8{0, 9, 6, 3, 4, 5, 8, 1, 2, 7}
+)
+
+// silkNLSF2A converts normalized LSFs in Q15 to the coefficients of a
+// stable whitening filter in Q12.
+func silkNLSF2A(a_Q12 []int16, NLSF []int16, d int) {
+	var cos_LSF_QA [silk_max_lpc_order]int32
+	var P, Q [silk_max_lpc_order/2 + 1]int32
+	var a32_QA1 [silk_max_lpc_order]int32
+
+	ordering := silkNLSF2AOrdering10[:]
+	if d == 16 {
+		ordering = silkNLSF2AOrdering16[:]
+	}
+
+	// Convert the LSFs to 2*cos(LSF), with a piecewise linear curve from
+	// the table.
+	for k := range d {
+		f_int := int32(NLSF[k]) >> (15 - 7)
+		f_frac := int32(NLSF[k]) - f_int<<(15-7)
+		cos_val := int32(silkLSFCosTabFIXQ12[f_int])
+		delta := int32(silkLSFCosTabFIXQ12[f_int+1]) - cos_val
+		cos_LSF_QA[ordering[k]] = silkRShiftRound(cos_val<<8+delta*f_frac, 20-silk_nlsf2a_qa)
+	}
+
+	// Generate the even and odd polynomials by convolution.
+	dd := d >> 1
+	silkNLSF2AFindPoly(P[:], cos_LSF_QA[0:], dd)
+	silkNLSF2AFindPoly(Q[:], cos_LSF_QA[1:], dd)
+
+	for k := range dd {
+		Ptmp := P[k+1] + P[k]
+		Qtmp := Q[k+1] - Q[k]
+		a32_QA1[k] = -Qtmp - Ptmp
+		a32_QA1[d-k-1] = Qtmp - Ptmp
+	}
+
+	// Convert to Q12, and apply bandwidth expansion while the filter is
+	// too close to unstable.
+	silkLPCFit(a_Q12[:d], a32_QA1[:d], 12, silk_nlsf2a_qa+1)
+	for i := 0; silkLPCInversePredGain(a_Q12[:d]) == 0 && i < silk_max_lpc_stabilize_iterations; i++ {
+		silkBwexpander32(a32_QA1[:d], 65536-2<<i)
+		for k := range d {
+			a_Q12[k] = int16(silkRShiftRound(a32_QA1[k], silk_nlsf2a_qa+1-12))
+		}
+	}
+}
+
+// silkLPCFit converts the coefficients in QIN to int16 in QOUT, limiting
+// them so that they do not wrap around.
+func silkLPCFit(a_QOUT []int16, a_QIN []int32, QOUT, QIN int) {
+	var idx int
+	i := 0
+	for ; i < 10; i++ {
+		// Find the largest absolute value.
+		maxabs := int32(0)
+		for k, v := range a_QIN {
+			absval := silkAbs(v)
+			if absval > maxabs {
+				maxabs = absval
+				idx = k
+			}
+		}
+		maxabs = silkRShiftRound(maxabs, QIN-QOUT)
+		if maxabs <= 32767 {
+			break
+		}
+		// Reduce the magnitude of the coefficients, 163838 is
+		// (0x7fffffff >> 14) + 32767.
+		maxabs = min(maxabs, 163838)
+		chirp_Q16 := 65470 - ((maxabs-32767)<<14)/((maxabs*int32(idx+1))>>2)
+		silkBwexpander32(a_QIN, chirp_Q16)
+	}
+
+	if i == 10 {
+		// Clip the coefficients after the last iteration.
+		for k := range a_QIN {
+			a_QOUT[k] = int16(silkSat16(silkRShiftRound(a_QIN[k], QIN-QOUT)))
+			a_QIN[k] = int32(a_QOUT[k]) << (QIN - QOUT)
+		}
+	} else {
+		for k := range a_QIN {
+			a_QOUT[k] = int16(silkRShiftRound(a_QIN[k], QIN-QOUT))
+		}
+	}
+}
+
+const (
+	silk_inv_pred_gain_qa      = 24
+	silk_inv_pred_gain_a_limit = 16773022 // 0.99975 in Q24
+	// silk_min_inv_pred_gain_Q30 is the inverse of the largest prediction
+	// power gain, 1e4.
+	silk_min_inv_pred_gain_Q30 = 107374
+)
+
+// silkLPCInversePredGain returns the inverse prediction gain of a filter
+// in Q30, or 0 if the filter is unstable.
+func silkLPCInversePredGain(A_Q12 []int16) int32 {
+	var Atmp_QA [silk_max_lpc_order]int32
+	DC_resp := int32(0)
+	for k, a := range A_Q12 {
+		DC_resp += int32(a)
+		Atmp_QA[k] = int32(a) << (silk_inv_pred_gain_qa - 12)
+	}
+	// If the DC is unstable, the full calculation is not needed.
+	if DC_resp >= 4096 {
+		return 0
+	}
+	return silkLPCInversePredGainQA(Atmp_QA[:len(A_Q12)])
+}
+
+func silkLPCInversePredGainQA(A_QA []int32) int32 {
+	invGain_Q30 := int32(1 << 30)
+	k := len(A_QA) - 1
+	for ; k > 0; k-- {
+		if A_QA[k] > silk_inv_pred_gain_a_limit || A_QA[k] < -silk_inv_pred_gain_a_limit {
+			return 0
+		}
+		// Set the reflection coefficient to the negated AR coefficient.
+		rc_Q31 := -silkLShift(A_QA[k], 31-silk_inv_pred_gain_qa)
+		rc_mult1_Q30 := 1<<30 - silkSMMUL(rc_Q31, rc_Q31)
+
+		invGain_Q30 = silkLShift(silkSMMUL(invGain_Q30, rc_mult1_Q30), 2)
+		if invGain_Q30 < silk_min_inv_pred_gain_Q30 {
+			return 0
+		}
+
+		mult2Q := 32 - int(silkCLZ32(silkAbs(rc_mult1_Q30)))
+		rc_mult2 := silkInverse32VarQ(rc_mult1_Q30, mult2Q+30)
+
+		// Update the AR coefficients.
+		for n := range (k + 1) >> 1 {
+			tmp1 := A_QA[n]
+			tmp2 := A_QA[k-n-1]
+			tmp64 := silkRShiftRound64(int64(silkSubSat32(tmp1, mul32FracQ31(tmp2, rc_Q31)))*int64(rc_mult2), mult2Q)
+			if tmp64 > 0x7fffffff || tmp64 < -0x80000000 {
+				return 0
+			}
+			A_QA[n] = int32(tmp64)
+			tmp64 = silkRShiftRound64(int64(silkSubSat32(tmp2, mul32FracQ31(tmp1, rc_Q31)))*int64(rc_mult2), mult2Q)
+			if tmp64 > 0x7fffffff || tmp64 < -0x80000000 {
+				return 0
+			}
+			A_QA[k-n-1] = int32(tmp64)
+		}
+	}
+
+	if A_QA[k] > silk_inv_pred_gain_a_limit || A_QA[k] < -silk_inv_pred_gain_a_limit {
+		return 0
+	}
+	rc_Q31 := -silkLShift(A_QA[0], 31-silk_inv_pred_gain_qa)
+	rc_mult1_Q30 := 1<<30 - silkSMMUL(rc_Q31, rc_Q31)
+	invGain_Q30 = silkLShift(silkSMMUL(invGain_Q30, rc_mult1_Q30), 2)
+	if invGain_Q30 < silk_min_inv_pred_gain_Q30 {
+		return 0
+	}
+	return invGain_Q30
+}
+
+func mul32FracQ31(a, b int32) int32 {
+	return int32(silkRShiftRound64(int64(a)*int64(b), 31))
+}
+
+// silkBwexpander does a bandwidth expansion (chirp) of an AR filter.
+func silkBwexpander(ar []int16, chirp_Q16 int32) {
+	d := len(ar)
+	chirp_minus_one_Q16 := chirp_Q16 - 65536
+	// silkSMULWB is not used, its bias can make the filter unstable.
+	for i := range d - 1 {
+		ar[i] = int16(silkRShiftRound(chirp_Q16*int32(ar[i]), 16))
+		chirp_Q16 += silkRShiftRound(chirp_Q16*chirp_minus_one_Q16, 16)
+	}
+	ar[d-1] = int16(silkRShiftRound(chirp_Q16*int32(ar[d-1]), 16))
+}
+
+// silkBwexpander32 is silkBwexpander for 32-bit coefficients.
+func silkBwexpander32(ar []int32, chirp_Q16 int32) {
+	d := len(ar)
+	chirp_minus_one_Q16 := chirp_Q16 - 65536
+	for i := range d - 1 {
+		ar[i] = silkSMULWW(chirp_Q16, ar[i])
+		chirp_Q16 += silkRShiftRound(chirp_Q16*chirp_minus_one_Q16, 16)
+	}
+	ar[d-1] = silkSMULWW(chirp_Q16, ar[d-1])
+}
+
+// silkLog2Lin approximates 2^(inLog_Q7/128).
+func silkLog2Lin(inLog_Q7 int32) int32 {
+	if inLog_Q7 < 0 {
+		return 0
+	} else if inLog_Q7 >= 3967 {
+		return 0x7fffffff
+	}
+	out := int32(1) << (inLog_Q7 >> 7)
+	frac_Q7 := inLog_Q7 & 0x7f
+	// Piece-wise parabolic approximation.
+	if inLog_Q7 < 2048 {
+		out = out + (out*silkSMLAWB(frac_Q7, silkSMULBB(frac_Q7, 128-frac_Q7), -174))>>7
+	} else {
+		out = out + (out>>7)*silkSMLAWB(frac_Q7, silkSMULBB(frac_Q7, 128-frac_Q7), -174)
+	}
+	return out
+}
+
+// silkLin2Log approximates 128*log2(inLin).
+func silkLin2Log(inLin int32) int32 {
+	lz, frac_Q7 := silkCLZFrac(inLin)
+	// Piece-wise parabolic approximation.
+	return silkSMLAWB(frac_Q7, frac_Q7*(128-frac_Q7), 179) + (31-lz)<<7
+}
+
+// silkSumSqrShift returns the energy of x, shifted right so that it fits
+// in 32 bits with two bits of headroom, and the shift.
+func silkSumSqrShift(x []int16) (energy int32, shift int) {
+	length := len(x)
+	shft := 31 - int(silkCLZ32(int32(length)))
+	// Be conservative with rounding and start with nrg=len.
+	nrg := int32(length)
+	i := 0
+	for ; i < length-1; i += 2 {
+		nrg_tmp := uint32(silkSMULBB(int32(x[i]), int32(x[i])))
+		nrg_tmp = uint32(silkSMLABBOvflw(int32(nrg_tmp), int32(x[i+1]), int32(x[i+1])))
+		nrg = int32(uint32(nrg) + nrg_tmp>>shft)
+	}
+	if i < length {
+		nrg_tmp := uint32(silkSMULBB(int32(x[i]), int32(x[i])))
+		nrg = int32(uint32(nrg) + nrg_tmp>>shft)
+	}
+
+	// Make sure the result fits in 32 bits with two bits of headroom.
+	shft = max(0, shft+3-int(silkCLZ32(nrg)))
+	nrg = 0
+	i = 0
+	for ; i < length-1; i += 2 {
+		nrg_tmp := uint32(silkSMULBB(int32(x[i]), int32(x[i])))
+		nrg_tmp = uint32(silkSMLABBOvflw(int32(nrg_tmp), int32(x[i+1]), int32(x[i+1])))
+		nrg = int32(uint32(nrg) + nrg_tmp>>shft)
+	}
+	if i < length {
+		nrg_tmp := uint32(silkSMULBB(int32(x[i]), int32(x[i])))
+		nrg = int32(uint32(nrg) + nrg_tmp>>shft)
+	}
+	return nrg, shft
+}
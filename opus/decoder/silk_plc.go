@@ -0,0 +1,315 @@
+package decoder
+
+// SILK packet loss concealment.
+
+const (
+	silk_plc_bwe_coef_Q16           = 64881 // 0.99
+	silk_v_pitch_gain_start_min_Q14 = 11469 // 0.7
+	silk_v_pitch_gain_start_max_Q14 = 15565 // 0.95
+	silk_max_pitch_lag_ms           = 18
+	silk_rand_buf_size              = 128
+	silk_rand_buf_mask              = silk_rand_buf_size - 1
+	// The thresholds of the LPC gain, 2^3 is 8 dB and 2^8 is 24 dB.
+	silk_log2_inv_lpc_gain_high_thres = 3
+	silk_log2_inv_lpc_gain_low_thres  = 8
+	silk_pitch_drift_fac_Q16          = 655 // 0.01
+)
+
+var (
+	silkHarmAttQ15            = [2]int32{32440, 31130} // 0.99, 0.95
+	silkPLCRandAttenuateVQ15  = [2]int32{31130, 26214} // 0.95, 0.8
+	silkPLCRandAttenuateUVQ15 = [2]int32{32440, 29491} // 0.99, 0.9
+)
+
+// silkPLC is the state of the packet loss concealment.
+type silkPLC struct {
+	// pitchL_Q8 is the pitch lag to use for voiced concealment.
+	pitchL_Q8         int32
+	LTPCoef_Q14       [silk_ltp_order]int16
+	prevLPC_Q12       [silk_max_lpc_order]int16
+	last_frame_lost   bool
+	rand_seed         int32
+	randScale_Q14     int16
+	conc_energy       int32
+	conc_energy_shift int
+	prevLTP_scale_Q14 int16
+	prevGain_Q16      [2]int32
+	fs_kHz            int
+	nb_subfr          int
+	subfr_length      int
+}
+
+func (d *silkChannelDecoder) resetPLC() {
+	d.sPLC.pitchL_Q8 = int32(d.frame_length) << (8 - 1)
+	d.sPLC.prevGain_Q16 = [2]int32{1 << 16, 1 << 16}
+	d.sPLC.subfr_length = 20
+	d.sPLC.nb_subfr = 2
+}
+
+// plc updates the concealment state after a good frame, or conceals a
+// lost frame.
+func (d *silkChannelDecoder) plc(ctrl *silkDecoderControl, frame []int16, lost bool) {
+	if d.fs_kHz != d.sPLC.fs_kHz {
+		d.resetPLC()
+		d.sPLC.fs_kHz = d.fs_kHz
+	}
+
+	if lost {
+		d.plcConceal(ctrl, frame)
+		d.lossCnt++
+	} else {
+		d.plcUpdate(ctrl)
+	}
+}
+
+// plcUpdate saves the parameters used in case of packet loss.
+func (d *silkChannelDecoder) plcUpdate(ctrl *silkDecoderControl) {
+	p := &d.sPLC
+
+	d.prevSignalType = int(d.indices.signalType)
+	LTP_Gain_Q14 := int32(0)
+	if d.indices.signalType == silk_type_voiced {
+		// Find the parameters of the last subframe that contains a pitch
+		// pulse.
+		for j := 0; j*d.subfr_length < ctrl.pitchL[d.nb_subfr-1]; j++ {
+			if j == d.nb_subfr {
+				break
+			}
+			sf := d.nb_subfr - 1 - j
+			temp_LTP_Gain_Q14 := int32(0)
+			for i := range silk_ltp_order {
+				temp_LTP_Gain_Q14 += int32(ctrl.LTPCoef_Q14[sf*silk_ltp_order+i])
+			}
+			if temp_LTP_Gain_Q14 > LTP_Gain_Q14 {
+				LTP_Gain_Q14 = temp_LTP_Gain_Q14
+				copy(p.LTPCoef_Q14[:], ctrl.LTPCoef_Q14[sf*silk_ltp_order:])
+				p.pitchL_Q8 = int32(ctrl.pitchL[sf]) << 8
+			}
+		}
+
+		p.LTPCoef_Q14 = [silk_ltp_order]int16{}
+		p.LTPCoef_Q14[silk_ltp_order/2] = int16(LTP_Gain_Q14)
+
+		// Limit the LTP coefficients.
+		if LTP_Gain_Q14 < silk_v_pitch_gain_start_min_Q14 {
+			tmp := int32(silk_v_pitch_gain_start_min_Q14) << 10
+			scale_Q10 := tmp / max(LTP_Gain_Q14, 1)
+			for i := range silk_ltp_order {
+				p.LTPCoef_Q14[i] = int16(silkSMULBB(int32(p.LTPCoef_Q14[i]), scale_Q10) >> 10)
+			}
+		} else if LTP_Gain_Q14 > silk_v_pitch_gain_start_max_Q14 {
+			tmp := int32(silk_v_pitch_gain_start_max_Q14) << 14
+			scale_Q14 := tmp / max(LTP_Gain_Q14, 1)
+			for i := range silk_ltp_order {
+				p.LTPCoef_Q14[i] = int16(silkSMULBB(int32(p.LTPCoef_Q14[i]), scale_Q14) >> 14)
+			}
+		}
+	} else {
+		p.pitchL_Q8 = int32(d.fs_kHz*18) << 8
+		p.LTPCoef_Q14 = [silk_ltp_order]int16{}
+	}
+
+	// Save the LPC coefficients and the last two gains.
+	copy(p.prevLPC_Q12[:d.LPC_order], ctrl.PredCoef_Q12[1][:])
+	p.prevLTP_scale_Q14 = int16(ctrl.LTP_scale_Q14)
+	copy(p.prevGain_Q16[:], ctrl.Gains_Q16[d.nb_subfr-2:])
+	p.subfr_length = d.subfr_length
+	p.nb_subfr = d.nb_subfr
+}
+
+// plcEnergy returns the energies of the excitation of the last two
+// subframes, scaled by their gains.
+func plcEnergy(exc_Q14 []int32, prevGain_Q10 [2]int32, subfr_length, nb_subfr int) (energy1 int32, shift1 int, energy2 int32, shift2 int) {
+	var exc_buf [2 * silk_max_sub_frame_length]int16
+	for k := range 2 {
+		for i := range subfr_length {
+			exc_buf[k*subfr_length+i] = int16(silkSat16(silkSMULWW(exc_Q14[i+(k+nb_subfr-2)*subfr_length], prevGain_Q10[k]) >> 8))
+		}
+	}
+	energy1, shift1 = silkSumSqrShift(exc_buf[:subfr_length])
+	energy2, shift2 = silkSumSqrShift(exc_buf[subfr_length : 2*subfr_length])
+	return energy1, shift1, energy2, shift2
+}
+
+// plcConceal generates a frame by extrapolating the previous frames.
+func (d *silkChannelDecoder) plcConceal(ctrl *silkDecoderControl, frame []int16) {
+	var sLTP_Q14 [2 * silk_max_frame_length]int32
+	var sLTP [silk_max_frame_length]int16
+	var A_Q12 [silk_max_lpc_order]int16
+	p := &d.sPLC
+
+	prevGain_Q10 := [2]int32{p.prevGain_Q16[0] >> 6, p.prevGain_Q16[1] >> 6}
+
+	if d.first_frame_after_reset {
+		p.prevLPC_Q12 = [silk_max_lpc_order]int16{}
+	}
+
+	// Use the subframe with the lowest energy of the last two as the
+	// random noise generator.
+	energy1, shift1, energy2, shift2 := plcEnergy(d.exc_Q14[:], prevGain_Q10, d.subfr_length, d.nb_subfr)
+	var rand_ptr []int32
+	if energy1>>shift2 < energy2>>shift1 {
+		rand_ptr = d.exc_Q14[max(0, (p.nb_subfr-1)*p.subfr_length-silk_rand_buf_size):]
+	} else {
+		rand_ptr = d.exc_Q14[max(0, p.nb_subfr*p.subfr_length-silk_rand_buf_size):]
+	}
+
+	// Set up the gains of the random noise and the attenuation.
+	B_Q14 := p.LTPCoef_Q14[:]
+	rand_scale_Q14 := int32(p.randScale_Q14)
+	att := min(len(silkHarmAttQ15)-1, d.lossCnt)
+	harm_Gain_Q15 := silkHarmAttQ15[att]
+	var rand_Gain_Q15 int32
+	if d.prevSignalType == silk_type_voiced {
+		rand_Gain_Q15 = silkPLCRandAttenuateVQ15[att]
+	} else {
+		rand_Gain_Q15 = silkPLCRandAttenuateUVQ15[att]
+	}
+
+	// LPC concealment: apply bandwidth expansion to the previous LPC.
+	silkBwexpander(p.prevLPC_Q12[:d.LPC_order], silk_plc_bwe_coef_Q16)
+	copy(A_Q12[:], p.prevLPC_Q12[:d.LPC_order])
+
+	// The first lost frame.
+	if d.lossCnt == 0 {
+		rand_scale_Q14 = 1 << 14
+
+		if d.prevSignalType == silk_type_voiced {
+			// Reduce the random noise gain for voiced frames.
+			for i := range silk_ltp_order {
+				rand_scale_Q14 -= int32(B_Q14[i])
+			}
+			rand_scale_Q14 = int32(max(3277, int16(rand_scale_Q14))) // 0.2
+			rand_scale_Q14 = int32(int16(silkSMULBB(rand_scale_Q14, int32(p.prevLTP_scale_Q14)) >> 14))
+		} else {
+			// Reduce the random noise for unvoiced frames with a high LPC
+			// gain.
+			invGain_Q30 := silkLPCInversePredGain(p.prevLPC_Q12[:d.LPC_order])
+			down_scale_Q30 := min(1<<30>>silk_log2_inv_lpc_gain_high_thres, invGain_Q30)
+			down_scale_Q30 = max(1<<30>>silk_log2_inv_lpc_gain_low_thres, down_scale_Q30)
+			down_scale_Q30 <<= silk_log2_inv_lpc_gain_high_thres
+			rand_Gain_Q15 = silkSMULWB(down_scale_Q30, rand_Gain_Q15) >> 14
+		}
+	}
+
+	rand_seed := p.rand_seed
+	lag := int(silkRShiftRound(p.pitchL_Q8, 8))
+	sLTP_buf_idx := d.ltp_mem_length
+
+	// Rewhiten the LTP state.
+	idx := d.ltp_mem_length - lag - d.LPC_order - silk_ltp_order/2
+	silkLPCAnalysisFilter(sLTP[idx:d.ltp_mem_length], d.outBuf[idx:], A_Q12[:d.LPC_order])
+
+	// Scale the LTP state.
+	inv_gain_Q30 := silkInverse32VarQ(p.prevGain_Q16[1], 46)
+	inv_gain_Q30 = min(inv_gain_Q30, 0x7fffffff>>1)
+	for i := idx + d.LPC_order; i < d.ltp_mem_length; i++ {
+		sLTP_Q14[i] = silkSMULWB(inv_gain_Q30, int32(sLTP[i]))
+	}
+
+	// LTP synthesis filtering.
+	for range d.nb_subfr {
+		pred_lag_idx := sLTP_buf_idx - lag + silk_ltp_order/2
+		for range d.subfr_length {
+			pr := sLTP_Q14[pred_lag_idx-4 : pred_lag_idx+1]
+			// Start at 2 to avoid a bias, silkSMLAWB rounds to -inf.
+			LTP_pred_Q12 := int32(2)
+			LTP_pred_Q12 = silkSMLAWB(LTP_pred_Q12, pr[4], int32(B_Q14[0]))
+			LTP_pred_Q12 = silkSMLAWB(LTP_pred_Q12, pr[3], int32(B_Q14[1]))
+			LTP_pred_Q12 = silkSMLAWB(LTP_pred_Q12, pr[2], int32(B_Q14[2]))
+			LTP_pred_Q12 = silkSMLAWB(LTP_pred_Q12, pr[1], int32(B_Q14[3]))
+			LTP_pred_Q12 = silkSMLAWB(LTP_pred_Q12, pr[0], int32(B_Q14[4]))
+			pred_lag_idx++
+
+			// Generate the LPC excitation.
+			rand_seed = silkRand(rand_seed)
+			idx := rand_seed >> 25 & silk_rand_buf_mask
+			sLTP_Q14[sLTP_buf_idx] = silkSMLAWB(LTP_pred_Q12, rand_ptr[idx], rand_scale_Q14) << 2
+			sLTP_buf_idx++
+		}
+
+		// Gradually reduce the LTP gain.
+		for j := range silk_ltp_order {
+			B_Q14[j] = int16(silkSMULBB(harm_Gain_Q15, int32(B_Q14[j])) >> 15)
+		}
+		if d.indices.signalType != silk_type_no_voice_activity {
+			// Gradually reduce the excitation gain.
+			rand_scale_Q14 = int32(int16(silkSMULBB(rand_scale_Q14, rand_Gain_Q15) >> 15))
+		}
+
+		// Slowly increase the pitch lag.
+		p.pitchL_Q8 = silkSMLAWB(p.pitchL_Q8, p.pitchL_Q8, silk_pitch_drift_fac_Q16)
+		p.pitchL_Q8 = min(p.pitchL_Q8, int32(silk_max_pitch_lag_ms*d.fs_kHz)<<8)
+		lag = int(silkRShiftRound(p.pitchL_Q8, 8))
+	}
+
+	// LPC synthesis filtering.
+	sLPC_Q14 := sLTP_Q14[d.ltp_mem_length-silk_max_lpc_order:]
+	copy(sLPC_Q14, d.sLPC_Q14_buf[:])
+	for i := range d.frame_length {
+		// Start at order/2 to avoid a bias, silkSMLAWB rounds to -inf.
+		LPC_pred_Q10 := int32(d.LPC_order >> 1)
+		for j := range d.LPC_order {
+			LPC_pred_Q10 = silkSMLAWB(LPC_pred_Q10, sLPC_Q14[silk_max_lpc_order+i-j-1], int32(A_Q12[j]))
+		}
+
+		sLPC_Q14[silk_max_lpc_order+i] = silkAddSat32(sLPC_Q14[silk_max_lpc_order+i], silkLShiftSat32(LPC_pred_Q10, 4))
+
+		// Scale with the gain.
+		frame[i] = int16(silkSat16(silkRShiftRound(silkSMULWW(sLPC_Q14[silk_max_lpc_order+i], prevGain_Q10[1]), 8)))
+	}
+	copy(d.sLPC_Q14_buf[:], sLPC_Q14[d.frame_length:])
+
+	p.rand_seed = rand_seed
+	p.randScale_Q14 = int16(rand_scale_Q14)
+	for i := range silk_max_nb_subfr {
+		ctrl.pitchL[i] = lag
+	}
+}
+
+// plcGlueFrames smooths the transition from concealed frames to a good
+// frame, fading in the energy difference.
+func (d *silkChannelDecoder) plcGlueFrames(frame []int16, length int) {
+	p := &d.sPLC
+
+	if d.lossCnt != 0 {
+		// Calculate the energy of the concealed frame.
+		p.conc_energy, p.conc_energy_shift = silkSumSqrShift(frame[:length])
+		p.last_frame_lost = true
+		return
+	}
+
+	if p.last_frame_lost {
+		// Calculate the energy of the decoded frame, and normalize both
+		// energies.
+		energy, energy_shift := silkSumSqrShift(frame[:length])
+		if energy_shift > p.conc_energy_shift {
+			p.conc_energy >>= energy_shift - p.conc_energy_shift
+		} else if energy_shift < p.conc_energy_shift {
+			energy >>= p.conc_energy_shift - energy_shift
+		}
+
+		// Fade in the energy difference.
+		if energy > p.conc_energy {
+			LZ := silkCLZ32(p.conc_energy) - 1
+			p.conc_energy = silkLShift(p.conc_energy, int(LZ))
+			energy >>= max(24-LZ, 0)
+
+			frac_Q24 := p.conc_energy / max(energy, 1)
+
+			gain_Q16 := silkSqrtApprox(frac_Q24) << 4
+			slope_Q16 := ((1 << 16) - gain_Q16) / int32(length)
+			// Make the slope 4x steeper to avoid missing onsets after DTX.
+			slope_Q16 <<= 2
+
+			for i := range length {
+				frame[i] = int16(silkSMULWB(gain_Q16, int32(frame[i])))
+				gain_Q16 += slope_Q16
+				if gain_Q16 > 1<<16 {
+					break
+				}
+			}
+		}
+	}
+	p.last_frame_lost = false
+}
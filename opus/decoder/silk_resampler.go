@@ -0,0 +1,160 @@
+package decoder
+
+const (
+	silk_max_api_fs_khz              = 48
+	silk_resampler_max_batch_size_ms = 10
+	silk_resampler_order_fir_12      = 8
+)
+
+// silkResamplerDelay holds the delay compensation of the resampler, per
+// input rate (8, 12, 16 kHz) and output rate (8, 12, 16, 24, 48 kHz).
+var silkResamplerDelay = [3][5]int8{
+	{4, 0, 2, 0, 0},
+	{0, 9, 4, 7, 4},
+	{0, 3, 12, 7, 7},
+}
+
+func silkRateID(r int) int {
+	switch r {
+	case 8000:
+		return 0
+	case 12000:
+		return 1
+	case 16000:
+		return 2
+	case 24000:
+		return 3
+	}
+	return 4
+}
+
+// silkResampler converts the internal sample rate of the SILK decoder to
+// the output rate, by allpass-based 2x upsampling followed by FIR
+// interpolation. Only upsampling is supported.
+type silkResampler struct {
+	sIIR         [6]int32
+	sFIR         [silk_resampler_order_fir_12]int16
+	delayBuf     [silk_max_api_fs_khz]int16
+	inputDelay   int
+	Fs_in_kHz    int
+	Fs_out_kHz   int
+	batchSize    int
+	invRatio_Q16 int32
+}
+
+func (s *silkResampler) init(Fs_Hz_in, Fs_Hz_out int) {
+	*s = silkResampler{}
+	s.inputDelay = int(silkResamplerDelay[silkRateID(Fs_Hz_in)][silkRateID(Fs_Hz_out)])
+	s.Fs_in_kHz = Fs_Hz_in / 1000
+	s.Fs_out_kHz = Fs_Hz_out / 1000
+	s.batchSize = s.Fs_in_kHz * silk_resampler_max_batch_size_ms
+
+	// The ratio of input to output samples, rounded up.
+	const up2x = 1
+	s.invRatio_Q16 = int32((Fs_Hz_in<<(14+up2x))/Fs_Hz_out) << 2
+	for silkSMULWW(s.invRatio_Q16, int32(Fs_Hz_out)) < int32(Fs_Hz_in<<up2x) {
+		s.invRatio_Q16++
+	}
+}
+
+// resample resamples in to out. The input must be at least 1 ms long.
+func (s *silkResampler) resample(out []int16, in []int16) {
+	nSamples := s.Fs_in_kHz - s.inputDelay
+
+	copy(s.delayBuf[s.inputDelay:s.Fs_in_kHz], in[:nSamples])
+	s.resampleIIRFIR(out, s.delayBuf[:s.Fs_in_kHz])
+	s.resampleIIRFIR(out[s.Fs_out_kHz:], in[nSamples:len(in)-s.inputDelay])
+
+	copy(s.delayBuf[:s.inputDelay], in[len(in)-s.inputDelay:])
+}
+
+func (s *silkResampler) resampleIIRFIR(out []int16, in []int16) {
+	var buf [2*16*silk_resampler_max_batch_size_ms + silk_resampler_order_fir_12]int16
+
+	// Copy the buffered samples to the start of the buffer.
+	copy(buf[:], s.sFIR[:])
+
+	index_increment_Q16 := s.invRatio_Q16
+	var nSamplesIn int
+	for {
+		nSamplesIn = min(len(in), s.batchSize)
+
+		// Upsample 2x.
+		silkResamplerUp2HQ(&s.sIIR, buf[silk_resampler_order_fir_12:], in[:nSamplesIn])
+
+		// Interpolate the upsampled signal.
+		max_index_Q16 := int32(nSamplesIn) << (16 + 1)
+		for index_Q16 := int32(0); index_Q16 < max_index_Q16; index_Q16 += index_increment_Q16 {
+			table_index := silkSMULWB(index_Q16&0xffff, 12)
+			buf_ptr := buf[index_Q16>>16:]
+			fir0 := silkResamplerFracFIR12[table_index]
+			fir1 := silkResamplerFracFIR12[11-table_index]
+			res_Q15 := silkSMULBB(int32(buf_ptr[0]), int32(fir0[0]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[1]), int32(fir0[1]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[2]), int32(fir0[2]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[3]), int32(fir0[3]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[4]), int32(fir1[3]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[5]), int32(fir1[2]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[6]), int32(fir1[1]))
+			res_Q15 = silkSMLABB(res_Q15, int32(buf_ptr[7]), int32(fir1[0]))
+			out[0] = int16(silkSat16(silkRShiftRound(res_Q15, 15)))
+			out = out[1:]
+		}
+
+		in = in[nSamplesIn:]
+		if len(in) == 0 {
+			break
+		}
+		// Copy the last part of the filtered signal to the start of the
+		// buffer for the next batch.
+		copy(buf[:silk_resampler_order_fir_12], buf[nSamplesIn<<1:])
+	}
+
+	// Keep the last part of the filtered signal for the next call.
+	copy(s.sFIR[:], buf[nSamplesIn<<1:])
+}
+
+// silkResamplerUp2HQ upsamples by a factor 2 with allpass filters,
+// followed by a notch filter just above Nyquist.
+func silkResamplerUp2HQ(S *[6]int32, out []int16, in []int16) {
+	for k, v := range in {
+		// The state is in Q10.
+		in32 := int32(v) << 10
+
+		// Three allpass sections for the even output sample.
+		Y := in32 - S[0]
+		X := silkSMULWB(Y, int32(silkResamplerUp2Hq0[0]))
+		out32_1 := S[0] + X
+		S[0] = in32 + X
+
+		Y = out32_1 - S[1]
+		X = silkSMULWB(Y, int32(silkResamplerUp2Hq0[1]))
+		out32_2 := S[1] + X
+		S[1] = out32_1 + X
+
+		Y = out32_2 - S[2]
+		X = silkSMLAWB(Y, Y, int32(silkResamplerUp2Hq0[2]))
+		out32_1 = S[2] + X
+		S[2] = out32_2 + X
+
+		out[2*k] = int16(silkSat16(silkRShiftRound(out32_1, 10)))
+
+		// Three allpass sections for the odd output sample.
+		Y = in32 - S[3]
+		X = silkSMULWB(Y, int32(silkResamplerUp2Hq1[0]))
+		out32_1 = S[3] + X
+		S[3] = in32 + X
+
+		Y = out32_1 - S[4]
+		X = silkSMULWB(Y, int32(silkResamplerUp2Hq1[1]))
+		out32_2 = S[4] + X
+		S[4] = out32_1 + X
+
+		Y = out32_2 - S[5]
+		X = silkSMLAWB(Y, Y, int32(silkResamplerUp2Hq1[2]))
+		out32_1 = S[5] + X
+		S[5] = out32_2 + X
+
+		out[2*k+1] = int16(silkSat16(silkRShiftRound(out32_1, 10)))
+	}
+}
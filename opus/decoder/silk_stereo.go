@@ -0,0 +1,73 @@
+package decoder
+
+// silkStereoDecodePred decodes the mid/side predictors.
+func silkStereoDecodePred(rd *rangeDecoder, pred_Q13 *[2]int32) {
+	var ix [2][3]int
+
+	n := rd.decodeICDF(silkStereoPredJointICDF, 8)
+	ix[0][2] = n / 5
+	ix[1][2] = n - 5*ix[0][2]
+	for n := range 2 {
+		ix[n][0] = rd.decodeICDF(silkUniform3ICDF, 8)
+		ix[n][1] = rd.decodeICDF(silkUniform5ICDF, 8)
+	}
+
+	// Dequantize.
+	for n := range 2 {
+		ix[n][0] += 3 * ix[n][2]
+		low_Q13 := int32(silkStereoPredQuantQ13[ix[n][0]])
+		step_Q13 := silkSMULWB(int32(silkStereoPredQuantQ13[ix[n][0]+1])-low_Q13, 6554) // 0.5/5 in Q16
+		pred_Q13[n] = silkSMLABB(low_Q13, step_Q13, int32(2*ix[n][1]+1))
+	}
+
+	// Subtract the second from the first predictor, which helps when
+	// applying them.
+	pred_Q13[0] -= pred_Q13[1]
+}
+
+// silkStereoDecodeMidOnly decodes the flag that only the mid channel is
+// coded.
+func silkStereoDecodeMidOnly(rd *rangeDecoder) bool {
+	return rd.decodeICDF(silkStereoOnlyCodeMidICDF, 8) != 0
+}
+
+// msToLR converts the adaptive mid/side representation in x1 and x2 to
+// left/right. Both hold two samples of history in front of the frame.
+func (s *silkStereoState) msToLR(x1, x2 []int16, pred_Q13 [2]int32, fs_kHz, frame_length int) {
+	// Buffering.
+	copy(x1[:2], s.sMid[:])
+	copy(x2[:2], s.sSide[:])
+	copy(s.sMid[:], x1[frame_length:frame_length+2])
+	copy(s.sSide[:], x2[frame_length:frame_length+2])
+
+	// Interpolate the predictors and add the prediction to the side
+	// channel.
+	pred0_Q13 := s.pred_prev_Q13[0]
+	pred1_Q13 := s.pred_prev_Q13[1]
+	denom_Q16 := int32((1 << 16) / (silk_stereo_interp_len_ms * fs_kHz))
+	delta0_Q13 := silkRShiftRound(silkSMULBB(pred_Q13[0]-s.pred_prev_Q13[0], denom_Q16), 16)
+	delta1_Q13 := silkRShiftRound(silkSMULBB(pred_Q13[1]-s.pred_prev_Q13[1], denom_Q16), 16)
+	interp_len := silk_stereo_interp_len_ms * fs_kHz
+	for n := range frame_length {
+		if n < interp_len {
+			pred0_Q13 += delta0_Q13
+			pred1_Q13 += delta1_Q13
+		} else {
+			pred0_Q13 = pred_Q13[0]
+			pred1_Q13 = pred_Q13[1]
+		}
+		sum := (int32(x1[n]) + int32(x1[n+2]) + int32(x1[n+1])<<1) << 9 // Q11
+		sum = silkSMLAWB(int32(x2[n+1])<<8, sum, pred0_Q13)             // Q8
+		sum = silkSMLAWB(sum, int32(x1[n+1])<<11, pred1_Q13)            // Q8
+		x2[n+1] = int16(silkSat16(silkRShiftRound(sum, 8)))
+	}
+	s.pred_prev_Q13 = pred_Q13
+
+	// Convert to left/right.
+	for n := range frame_length {
+		sum := int32(x1[n+1]) + int32(x2[n+1])
+		diff := int32(x1[n+1]) - int32(x2[n+1])
+		x1[n+1] = int16(silkSat16(sum))
+		x2[n+1] = int16(silkSat16(diff))
+	}
+}
@@ -0,0 +1,368 @@
+package decoder
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The test vectors are in the format of the RFC 8251 test vectors: a .bit
+// file of packets as written by opus_demo, and the stereo output of the
+// reference decoder in a .dec file of 16-bit little-endian samples at
+// 48 kHz. The official vectors, testvector01 to testvector12 with their
+// .dec and m.dec files, are run from the directory given by -vectors:
+//
+//	go test ./opus/decoder -run TestOfficialVectors -vectors path/to/opus_newvectors
+//
+// The vectors in testdata were encoded and decoded with opus_demo of the
+// fixed-point build of libopus 1.3.1, and the stereo output must match
+// theirs exactly. They cover CELT from 2.5 to 20 ms, narrowband mono
+// CELT, SILK narrowband and wideband with 60 ms packets, hybrid fullband,
+// packets of 120 ms with mode switches, and lost packets.
+
+var vectorsDir = flag.String("vectors", "", "directory of the official RFC 8251 test vectors")
+
+// vectorPacket is a packet of a .bit file, with the final range of the
+// encoder. A packet of size 0 is lost.
+type vectorPacket struct {
+	data        []byte
+	final_range uint32
+}
+
+// readVector reads the packets of a .bit file: every packet is preceded
+// by its size and its final range, both 32-bit big-endian.
+func readVector(t *testing.T, name string) []vectorPacket {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var packets []vectorPacket
+	for len(b) > 0 {
+		if len(b) < 8 || int(binary.BigEndian.Uint32(b)) > len(b)-8 {
+			t.Fatalf("%s: truncated packet %d", name, len(packets))
+		}
+		size := int(binary.BigEndian.Uint32(b))
+		packets = append(packets, vectorPacket{b[8 : 8+size], binary.BigEndian.Uint32(b[4:])})
+		b = b[8+size:]
+	}
+	return packets
+}
+
+// readPCM reads 16-bit little-endian samples.
+func readPCM(t *testing.T, name string) []int16 {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm := make([]int16, len(b)/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(b[2*i:]))
+	}
+	return pcm
+}
+
+// decodeVector decodes the packets like opus_demo, concealing a lost
+// packet with the duration of the last one, and checks the final range
+// of every packet.
+func decodeVector(t *testing.T, packets []vectorPacket, channels int) []int16 {
+	t.Helper()
+	d, err := NewDecoder(channels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out []int16
+	pcm := make([]int16, 5760*channels)
+	for i, packet := range packets {
+		frame_size := 5760
+		var data []byte
+		if len(packet.data) > 0 {
+			data = packet.data
+		} else {
+			frame_size = d.last_packet_duration
+		}
+		n, err := d.Decode(data, pcm[:frame_size*channels])
+		if err != nil {
+			t.Fatalf("packet %d: %v", i, err)
+		}
+		if data != nil && d.FinalRange() != packet.final_range {
+			t.Fatalf("packet %d: final range %08x, expected %08x", i, d.FinalRange(), packet.final_range)
+		}
+		out = append(out, pcm[:n*channels]...)
+	}
+	return out
+}
+
+// The bands of the comparison, in bins of 100 Hz, and its window.
+var (
+	compare_bands = [...]int{0, 2, 4, 6, 8, 10, 12, 14, 16, 20, 24, 28, 32, 40, 48, 56, 68, 80, 96, 120, 156, 200}
+)
+
+const (
+	compare_nbands      = len(compare_bands) - 1
+	compare_nfreqs      = 240
+	compare_window_size = 480
+	compare_window_step = 120
+)
+
+// bandEnergy computes the power spectrum of every window of the signal in
+// ps, and the energy of every band of it in out, if not nil.
+func bandEnergy(out, ps []float32, in []float32, channels, frames int) {
+	window := make([]float32, compare_window_size)
+	c := make([]float32, compare_window_size)
+	s := make([]float32, compare_window_size)
+	x := make([]float32, channels*compare_window_size)
+	for i := range compare_window_size {
+		window[i] = 0.5 - 0.5*float32(math.Cos(float64(2*math.Pi/float32(compare_window_size-1)*float32(i))))
+		c[i] = float32(math.Cos(float64(2 * math.Pi / float32(compare_window_size) * float32(i))))
+		s[i] = float32(math.Sin(float64(2 * math.Pi / float32(compare_window_size) * float32(i))))
+	}
+
+	ps_size := compare_window_size / 2
+	for xi := range frames {
+		for ch := range channels {
+			for k := range compare_window_size {
+				x[ch*compare_window_size+k] = window[k] * in[(xi*compare_window_step+k)*channels+ch]
+			}
+		}
+		j := 0
+		for b := range compare_nbands {
+			var p [2]float32
+			for ; j < compare_bands[b+1]; j++ {
+				for ch := range channels {
+					var re, im float32
+					ti := 0
+					for k := range compare_window_size {
+						re += c[ti] * x[ch*compare_window_size+k]
+						im -= s[ti] * x[ch*compare_window_size+k]
+						ti += j
+						if ti >= compare_window_size {
+							ti -= compare_window_size
+						}
+					}
+					v := re*re + im*im + 100000
+					ps[(xi*ps_size+j)*channels+ch] = v
+					p[ch] += v
+				}
+			}
+			if out != nil {
+				for ch := range channels {
+					out[(xi*compare_nbands+b)*channels+ch] = p[ch] / float32(compare_bands[b+1]-compare_bands[b])
+				}
+			}
+		}
+	}
+}
+
+// compareQuality is a port of opus_compare at 48 kHz, which rates how
+// close the output of a decoder is to the reference, taking masking into
+// account. The reference is stereo; mono output is compared against the
+// average of its channels. A quality below 0 fails the test vector.
+func compareQuality(reference, output []int16, channels int) (float64, bool) {
+	frames_in := len(reference) / 2
+	if len(output) != frames_in*channels || frames_in < compare_window_size {
+		return 0, false
+	}
+
+	x := make([]float32, frames_in*channels)
+	y := make([]float32, len(output))
+	for i := range frames_in {
+		if channels == 1 {
+			x[i] = 0.5 * (float32(reference[2*i]) + float32(reference[2*i+1]))
+			continue
+		}
+		x[2*i] = float32(reference[2*i])
+		x[2*i+1] = float32(reference[2*i+1])
+	}
+	for i, v := range output {
+		y[i] = float32(v)
+	}
+
+	frames := (frames_in - compare_window_size + compare_window_step) / compare_window_step
+	xb := make([]float32, frames*compare_nbands*channels)
+	X := make([]float32, frames*compare_nfreqs*channels)
+	Y := make([]float32, frames*compare_nfreqs*channels)
+	bandEnergy(xb, X, x, channels, frames)
+	bandEnergy(nil, Y, y, channels, frames)
+
+	for xi := range frames {
+		// Frequency masking, 10 dB/Bark from low to high and 15 dB/Bark
+		// from high to low.
+		for b := 1; b < compare_nbands; b++ {
+			for ch := range channels {
+				xb[(xi*compare_nbands+b)*channels+ch] += 0.1 * xb[(xi*compare_nbands+b-1)*channels+ch]
+			}
+		}
+		for b := compare_nbands - 2; b >= 0; b-- {
+			for ch := range channels {
+				xb[(xi*compare_nbands+b)*channels+ch] += 0.03 * xb[(xi*compare_nbands+b+1)*channels+ch]
+			}
+		}
+		// Temporal masking, -3 dB/2.5 ms.
+		if xi > 0 {
+			for b := range compare_nbands {
+				for ch := range channels {
+					xb[(xi*compare_nbands+b)*channels+ch] += 0.5 * xb[((xi-1)*compare_nbands+b)*channels+ch]
+				}
+			}
+		}
+		// Some cross-talk is allowed.
+		if channels == 2 {
+			for b := range compare_nbands {
+				l := xb[(xi*compare_nbands+b)*channels]
+				r := xb[(xi*compare_nbands+b)*channels+1]
+				xb[(xi*compare_nbands+b)*channels] += 0.01 * r
+				xb[(xi*compare_nbands+b)*channels+1] += 0.01 * l
+			}
+		}
+		for b := range compare_nbands {
+			for j := compare_bands[b]; j < compare_bands[b+1]; j++ {
+				for ch := range channels {
+					X[(xi*compare_nfreqs+j)*channels+ch] += 0.1 * xb[(xi*compare_nbands+b)*channels+ch]
+					Y[(xi*compare_nfreqs+j)*channels+ch] += 0.1 * xb[(xi*compare_nbands+b)*channels+ch]
+				}
+			}
+		}
+	}
+
+	// Consecutive windows are averaged, to make the comparison slightly
+	// less sensitive.
+	for j := range compare_bands[compare_nbands] {
+		for ch := range channels {
+			x_prev := X[j*channels+ch]
+			y_prev := Y[j*channels+ch]
+			for xi := 1; xi < frames; xi++ {
+				i := (xi*compare_nfreqs+j)*channels + ch
+				x_next, y_next := X[i], Y[i]
+				X[i] += x_prev
+				Y[i] += y_prev
+				x_prev, y_prev = x_next, y_next
+			}
+		}
+	}
+
+	var err float64
+	for xi := range frames {
+		var ef float64
+		for b := range compare_nbands {
+			var eb float64
+			for j := compare_bands[b]; j < compare_bands[b+1]; j++ {
+				for ch := range channels {
+					re := Y[(xi*compare_nfreqs+j)*channels+ch] / X[(xi*compare_nfreqs+j)*channels+ch]
+					im := re - float32(math.Log(float64(re))) - 1
+					// The comparison is less sensitive around the
+					// SILK/CELT cross-over.
+					if j >= 79 && j <= 81 {
+						im *= 0.1
+					}
+					if j == 80 {
+						im *= 0.1
+					}
+					eb += float64(im)
+				}
+			}
+			eb /= float64((compare_bands[b+1] - compare_bands[b]) * channels)
+			ef += eb * eb
+		}
+		ef /= float64(compare_nbands)
+		ef *= ef
+		err += ef * ef
+	}
+	err = math.Pow(err/float64(frames), 1.0/16)
+	quality := 100 * (1 - 0.5*math.Log(1+err)/math.Log(1.13))
+	return quality, quality >= 0
+}
+
+func TestVectors(t *testing.T) {
+	names, err := filepath.Glob("testdata/*.bit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) == 0 {
+		t.Fatal("no test vectors in testdata")
+	}
+	for _, name := range names {
+		t.Run(filepath.Base(strings.TrimSuffix(name, ".bit")), func(t *testing.T) {
+			testVector(t, name, true)
+		})
+	}
+}
+
+func TestOfficialVectors(t *testing.T) {
+	if *vectorsDir == "" {
+		t.Skip("no directory of official test vectors given with -vectors")
+	}
+	for i := 1; i <= 12; i++ {
+		name := filepath.Join(*vectorsDir, fmt.Sprintf("testvector%02d.bit", i))
+		t.Run(filepath.Base(strings.TrimSuffix(name, ".bit")), func(t *testing.T) {
+			testVector(t, name, false)
+		})
+	}
+}
+
+// testVector decodes the vector in stereo and mono and compares the output
+// against the reference with opus_compare. If exact is set, the stereo
+// output must match the reference sample for sample.
+func testVector(t *testing.T, name string, exact bool) {
+	base := strings.TrimSuffix(name, ".bit")
+	packets := readVector(t, name)
+	reference := readPCM(t, base+".dec")
+
+	output := decodeVector(t, packets, 2)
+	if exact {
+		if len(output) != len(reference) {
+			t.Fatalf("%d samples, expected %d", len(output), len(reference))
+		}
+		for i := range output {
+			if output[i] != reference[i] {
+				t.Fatalf("sample %d of channel %d is %d, expected %d", i/2, i%2, output[i], reference[i])
+			}
+		}
+	}
+	quality, ok := compareQuality(reference, output, 2)
+	if !ok {
+		t.Errorf("stereo output fails with quality %.1f%%", quality)
+	}
+
+	// Mono output is compared against the mono reference of the official
+	// vectors, if the stereo reference fails.
+	output = decodeVector(t, packets, 1)
+	quality, ok = compareQuality(reference, output, 1)
+	if !ok {
+		if _, err := os.Stat(base + "m.dec"); err == nil {
+			mono := readPCM(t, base+"m.dec")
+			stereo := make([]int16, 2*len(mono))
+			for i, v := range mono {
+				stereo[2*i], stereo[2*i+1] = v, v
+			}
+			quality, ok = compareQuality(stereo, output, 1)
+		}
+	}
+	if !ok {
+		t.Errorf("mono output fails with quality %.1f%%", quality)
+	}
+}
+
+func TestCompareQuality(t *testing.T) {
+	// A signal compared against itself passes, against silence it fails.
+	reference := make([]int16, 2*4800)
+	for i := range 4800 {
+		v := int16(8000 * math.Sin(float64(i)*2*math.Pi*440/48000))
+		reference[2*i], reference[2*i+1] = v, v/2
+	}
+	quality, ok := compareQuality(reference, reference, 2)
+	if !ok || quality < 99.9 {
+		t.Errorf("identical signals have quality %.1f%%", quality)
+	}
+	_, ok = compareQuality(reference, make([]int16, len(reference)), 2)
+	if ok {
+		t.Error("silence passes")
+	}
+}
@@ -0,0 +1,122 @@
+package decoder
+
+// The vector quantization of the CELT band shapes (RFC 6716, section
+// 4.3.4).
+
+const (
+	spread_none       = 0
+	spread_light      = 1
+	spread_normal     = 2
+	spread_aggressive = 3
+)
+
+var spreadFactor = [3]int{15, 10, 5}
+
+func expRotation1(X []int16, len, stride int, c, s int32) {
+	ms := -s
+	for i := range len - stride {
+		x1 := int32(X[i])
+		x2 := int32(X[i+stride])
+		X[i+stride] = int16(extract16(pshr32(mac16_16(mult16_16(c, x2), s, x1), 15)))
+		X[i] = int16(extract16(pshr32(mac16_16(mult16_16(c, x1), ms, x2), 15)))
+	}
+	for i := len - 2*stride - 1; i >= 0; i-- {
+		x1 := int32(X[i])
+		x2 := int32(X[i+stride])
+		X[i+stride] = int16(extract16(pshr32(mac16_16(mult16_16(c, x2), s, x1), 15)))
+		X[i] = int16(extract16(pshr32(mac16_16(mult16_16(c, x1), ms, x2), 15)))
+	}
+}
+
+// expRotation spreads the energy of the len values of X, split in stride
+// blocks, to avoid tonal artifacts with few pulses. dir is negative to
+// undo the rotation of the encoder.
+func expRotation(X []int16, len, dir, stride, K, spread int) {
+	if 2*K >= len || spread == spread_none {
+		return
+	}
+	factor := spreadFactor[spread-1]
+
+	gain := extract16(celtDiv(mult16_16(q15_one, int32(len)), int32(len+factor*K)))
+	theta := mult16_16_q15(gain, gain) >> 1
+
+	c := celtCosNorm(theta)
+	s := celtCosNorm(sub16(q15_one, theta)) // sin(theta)
+
+	stride2 := 0
+	if len >= 8*stride {
+		// Compute sqrt(len/stride) with rounding, incrementing stride2 as
+		// long as (stride2+0.5)^2 < len/stride.
+		stride2 = 1
+		for (stride2*stride2+stride2)*stride+(stride>>2) < len {
+			stride2++
+		}
+	}
+	len /= stride
+	for i := range stride {
+		x := X[i*len:]
+		if dir < 0 {
+			if stride2 != 0 {
+				expRotation1(x, len, stride2, s, c)
+			}
+			expRotation1(x, len, 1, c, s)
+		} else {
+			expRotation1(x, len, 1, c, -s)
+			if stride2 != 0 {
+				expRotation1(x, len, stride2, s, -c)
+			}
+		}
+	}
+}
+
+// normaliseResidual scales the pulses of iy, of squared norm Ryy, to a
+// vector of norm gain in X.
+func normaliseResidual(iy []int, X []int16, N int, Ryy int32, gain int32) {
+	k := celtILog2(Ryy) >> 1
+	t := vshr32(Ryy, 2*(k-7))
+	g := extract16(mult16_16_p15(celtRsqrtNorm(t), gain))
+	for i := range N {
+		X[i] = int16(extract16(pshr32(mult16_16(g, int32(iy[i])), k+1)))
+	}
+}
+
+// extractCollapseMask returns the mask of the B blocks of iy that have
+// pulses.
+func extractCollapseMask(iy []int, N, B int) uint {
+	if B <= 1 {
+		return 1
+	}
+	N0 := N / B
+	var collapse_mask uint
+	for i := range B {
+		tmp := 0
+		for j := range N0 {
+			tmp |= iy[i*N0+j]
+		}
+		if tmp != 0 {
+			collapse_mask |= 1 << i
+		}
+	}
+	return collapse_mask
+}
+
+// algUnquant decodes the K pulses of a band of N values into X, with norm
+// gain, and returns the collapse mask of its B blocks.
+func algUnquant(X []int16, N, K, spread, B int, rd *rangeDecoder, gain int32) uint {
+	var iy [176]int
+	Ryy := decodePulses(iy[:N], N, K, rd)
+	normaliseResidual(iy[:N], X, N, Ryy, gain)
+	expRotation(X, N, -1, B, K, spread)
+	return extractCollapseMask(iy[:N], N, B)
+}
+
+// renormaliseVector scales the N values of X to norm gain.
+func renormaliseVector(X []int16, N int, gain int32) {
+	E := 1 + celtInnerProd(X, X, N)
+	k := celtILog2(E) >> 1
+	t := vshr32(E, 2*(k-7))
+	g := extract16(mult16_16_p15(celtRsqrtNorm(t), gain))
+	for i := range N {
+		X[i] = int16(extract16(pshr32(mult16_16(g, int32(X[i])), k+1)))
+	}
+}
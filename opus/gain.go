@@ -0,0 +1,117 @@
+package opus
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Names of the user comments holding the R128 normalization gains
+// (RFC 7845, section 5.2.1).
+const (
+	TrackGainComment = "R128_TRACK_GAIN"
+	AlbumGainComment = "R128_ALBUM_GAIN"
+)
+
+// opus_max_gain_length is the maximum number of characters of an R128 gain.
+const opus_max_gain_length = 6
+
+// GainMode selects which normalization gain is applied during playback.
+type GainMode int
+
+const (
+	// GainHeader applies only the output gain of the identification
+	// header.
+	GainHeader GainMode = iota
+	// GainTrack normalizes every track to the reference level.
+	GainTrack
+	// GainAlbum normalizes every album to the reference level, keeping the
+	// loudness differences between its tracks.
+	GainAlbum
+)
+
+func (m GainMode) String() string {
+	switch m {
+	case GainHeader:
+		return "header"
+	case GainTrack:
+		return "track"
+	case GainAlbum:
+		return "album"
+	}
+	return fmt.Sprintf("GainMode(%d)", int(m))
+}
+
+// PlaybackGain returns the gain in dB to apply to the decoded output for
+// the given mode. It is the output gain, plus the R128 gain of the mode,
+// plus offset. The R128 gains normalize to a reference level of -23 LUFS,
+// offset moves that level, e.g. 5 dB for -18 LUFS.
+//
+// In album mode the track gain is used if there is no album gain. If the
+// stream has neither, its loudness is unknown and only the output gain is
+// applied.
+func (info *OpusInfo) PlaybackGain(mode GainMode, offset float64) float64 {
+	gain := info.OutputGain
+
+	switch {
+	case mode == GainAlbum && info.HasAlbumGain:
+		gain += info.AlbumGain + offset
+	case mode != GainHeader && info.HasTrackGain:
+		gain += info.TrackGain + offset
+	}
+
+	return gain
+}
+
+// GainFactor converts a gain in dB to the factor to multiply samples by.
+func GainFactor(gain float64) float64 {
+	return math.Pow(10, gain/20)
+}
+
+// parseR128Gains parses the R128 gain comments into info. Each may occur
+// at most once, and must be a Q7.8 number in dB written as a base 10
+// integer of at most 6 characters, without whitespace. Gains that cannot
+// be parsed are ignored.
+func parseR128Gains(info *OpusInfo, c *conformance) error {
+	var err error
+
+	info.TrackGain, info.HasTrackGain, err = parseR128Gain(info.Comments, TrackGainComment, c)
+	if err != nil {
+		return err
+	}
+
+	info.AlbumGain, info.HasAlbumGain, err = parseR128Gain(info.Comments, AlbumGainComment, c)
+	if err != nil {
+		return err
+	}
+
+	if info.Comments.Has("REPLAYGAIN_TRACK_GAIN") || info.Comments.Has("REPLAYGAIN_ALBUM_GAIN") {
+		c.warn("ReplayGain comments are present, they should not be used (RFC 7845, section 5.2.1)")
+	}
+
+	return nil
+}
+
+func parseR128Gain(comments Comments, name string, c *conformance) (float64, bool, error) {
+	values := comments.Get(name)
+	if len(values) == 0 {
+		return 0, false, nil
+	}
+
+	if len(values) > 1 {
+		err := c.violation("%s occurs %d times, expected at most once (RFC 7845, section 5.2.1)", name, len(values))
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	value := values[0]
+	q78, err := strconv.ParseInt(value, 10, 16)
+	if err != nil || len(value) > opus_max_gain_length || strings.TrimSpace(value) != value {
+		err := c.violation("%s=%s is not a Q7.8 integer from -32768 to 32767 (RFC 7845, section 5.2.1)", name, value)
+		return 0, false, err
+	}
+
+	return float64(q78) / 256.0, true, nil
+}
@@ -0,0 +1,64 @@
+package opus
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestPlaybackGain(t *testing.T) {
+	// An output gain of 1.5 dB, a track gain of -4 dB and an album gain of
+	// -2 dB.
+	id := []byte(testIDHeader)
+	id[16], id[17] = 0x80, 0x01
+	both := testCommentHeader("R128_TRACK_GAIN=-1024", "R128_ALBUM_GAIN=-512")
+	track := testCommentHeader("R128_TRACK_GAIN=-1024")
+	none := testCommentHeader("TITLE=a")
+
+	tests := []struct {
+		name     string
+		comments []byte
+		mode     GainMode
+		offset   float64
+		gain     float64
+	}{
+		{"header", both, GainHeader, 5, 1.5},
+		{"track", both, GainTrack, 0, -2.5},
+		{"track with offset", both, GainTrack, 5, 2.5},
+		{"album", both, GainAlbum, 0, -0.5},
+		{"album with offset", both, GainAlbum, -3, -3.5},
+		{"album without album gain", track, GainAlbum, 5, 2.5},
+		{"track without gains", none, GainTrack, 5, 1.5},
+		{"album without gains", none, GainAlbum, 5, 1.5},
+	}
+	for _, test := range tests {
+		stream := writeTestStream(t, id, test.comments, 10)
+		info, err := ParseInfoReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		gain := info.PlaybackGain(test.mode, test.offset)
+		if gain != test.gain {
+			t.Errorf("%s: gain %v dB, expected %v dB", test.name, gain, test.gain)
+		}
+	}
+}
+
+func TestGainFactor(t *testing.T) {
+	tests := []struct {
+		gain   float64
+		factor float64
+	}{
+		{0, 1},
+		{20, 10},
+		{-20, 0.1},
+		{6, 1.9953},
+		{-6, 0.5012},
+	}
+	for _, test := range tests {
+		factor := GainFactor(test.gain)
+		if math.Abs(factor-test.factor) > 1e-4 {
+			t.Errorf("%v dB: factor %v, expected %v", test.gain, factor, test.factor)
+		}
+	}
+}
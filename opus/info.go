@@ -1,13 +1,14 @@
 package opus
 
 import (
-	"bufio"
 	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/steabert/gopus/binary"
 	"github.com/steabert/gopus/ogg"
@@ -18,77 +19,233 @@ const (
 	opus_id_header_magic_sig      = 0x646165487375704f // "OpusHead"
 	opus_comment_header_size      = 16
 	opus_comment_header_magic_sig = 0x736761547375704f // "OpusTags"
+	opus_granule_rate             = 48000
 )
 
 type OpusInfo struct {
-	Vendor        string
-	Comments      map[string]string
+	Vendor   string
+	Comments Comments
+	// CommentData is the binary data following the user comments in the
+	// comment header. It is only kept when the least-significant bit of
+	// its first byte is set, otherwise it is padding (RFC 7845, section 5.2).
+	CommentData   []byte
 	SampleRate    uint32
 	PreSkip       uint16
 	OutputGain    float64
 	Channels      uint8
 	MappingFamily uint8
+	// TrackGain and AlbumGain are the gains in dB of the R128_TRACK_GAIN
+	// and R128_ALBUM_GAIN comments, relative to the output gain. They are
+	// only valid if HasTrackGain and HasAlbumGain are set.
+	TrackGain    float64
+	AlbumGain    float64
+	HasTrackGain bool
+	HasAlbumGain bool
+	// StreamCount, CoupledCount and ChannelMapping describe how the
+	// output channels are coded, see RFC 7845, section 5.1.1.
+	StreamCount    uint8
+	CoupledCount   uint8
+	ChannelMapping []uint8
+	// AmbisonicOrder and NonDiegetic describe the sound field of mapping
+	// families 2 and 3. For family 3, DemixingMatrix holds the Q15 gains
+	// from decoded to output channels in column-major order, one column
+	// per decoded channel (RFC 8486, section 3.2).
+	AmbisonicOrder int
+	NonDiegetic    bool
+	DemixingMatrix []int16
+	Duration       time.Duration
+	// The audio properties are only determined when parsing with the
+	// Properties option, or from a reader that cannot seek. The bitrates
+	// are in bits per second, the peak is the highest over a second of
+	// audio.
+	AudioBytes     int64
+	AudioPackets   int64
+	AudioPages     int64
+	AverageBitrate int
+	PeakBitrate    int
+	// Warnings lists the ways in which the stream does not conform to
+	// RFC 7845. In strict mode, only deviations from recommendations
+	// are listed.
+	Warnings []string
+
+	// invalidComments holds the user comments that are not of the form
+	// NAME=value, which UpdateTags keeps.
+	invalidComments [][]byte
 }
 
+// ParseInfo parses the Opus headers of the file at path, in lenient mode.
 func ParseInfo(path string) (OpusInfo, error) {
-	var info OpusInfo
+	return ParseOptions{}.ParseInfo(path)
+}
+
+// ParseInfoReader parses the Opus headers of the stream read from r, in
+// lenient mode.
+func ParseInfoReader(r io.Reader) (OpusInfo, error) {
+	return ParseOptions{}.ParseInfoReader(r)
+}
+
+// ParseInfoFS parses the Opus headers of the named file in fsys, in
+// lenient mode.
+func ParseInfoFS(fsys fs.FS, name string) (OpusInfo, error) {
+	return ParseOptions{}.ParseInfoFS(fsys, name)
+}
 
+// ParseInfo parses the Opus headers of the file at path.
+func (opts ParseOptions) ParseInfo(path string) (OpusInfo, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		return info, err
+		return OpusInfo{}, err
 	}
 	defer f.Close()
 
-	r := bufio.NewReader(f)
-
-	// Parse the identification header. This is a single-page header
-	// at the "Beginning Of Stream" that has to be complete.
+	return opts.ParseInfoReader(f)
+}
 
-	var page ogg.Page
-	err = ogg.ParsePage(r, &page)
+// ParseInfoFS parses the Opus headers of the named file in fsys.
+func (opts ParseOptions) ParseInfoFS(fsys fs.FS, name string) (OpusInfo, error) {
+	f, err := fsys.Open(name)
 	if err != nil {
-		return info, fmt.Errorf("invalid OGG stream, %v", err)
+		return OpusInfo{}, err
 	}
+	defer f.Close()
+
+	return opts.ParseInfoReader(f)
+}
+
+// ParseInfoReader parses the Opus headers of the stream read from r.
+//
+// If r is an io.ReadSeeker, the duration is looked up from the end of the
+// stream. Otherwise the whole stream is read, and the audio properties
+// are always determined.
+func (opts ParseOptions) ParseInfoReader(r io.Reader) (OpusInfo, error) {
+	var info OpusInfo
+	c := conformance{mode: opts.Mode}
+
+	rs, seekable := r.(io.ReadSeeker)
+	var start int64
+	if seekable {
+		var err error
+		start, err = rs.Seek(0, io.SeekCurrent)
+		seekable = err == nil
+	}
+
+	d := ogg.NewDemuxer(r)
+	opts.recoverPages(d.Pages(), &c)
+
+	// Parse the identification header. This is the first packet at the
+	// "Beginning Of Stream" of the Opus logical bitstream. Other logical
+	// bitstreams multiplexed into the file are skipped.
+
+	var packet ogg.Packet
+	for {
+		err := d.ReadPacket(&packet)
+		if err == io.EOF {
+			return info, noStreamError(d.Pages())
+		}
+		if err != nil {
+			return info, fmt.Errorf("invalid OGG stream, %v", err)
+		}
 
-	if !page.FirstPage || !page.Complete {
-		return info, fmt.Errorf("invalid identification header page, %v", err)
+		if packet.FirstPacket && bytes.HasPrefix(packet.Data, []byte("OpusHead")) {
+			break
+		}
 	}
 
-	err = parseIDHeader(bytes.NewReader(page.Body), &info)
+	serial := packet.SerialNumber
+
+	err := parseIDHeader(bytes.NewReader(packet.Data), &info, &c)
 	if err != nil {
 		return info, fmt.Errorf("invalid identification header, %v", err)
 	}
 
-	// Parse the comment header. This can span multiple pages.
+	err = c.checkIDHeaderPage(d.Page())
+	if err != nil {
+		return info, err
+	}
+
+	// Parse the comment header. This is the second packet
+	// and can span multiple pages.
 
-	var commentHeaderPages []io.Reader
 	for {
-		var page ogg.Page
-		err := ogg.ParsePage(r, &page)
+		err = d.ReadPacket(&packet)
 		if err != nil {
 			return info, fmt.Errorf("invalid OGG stream, %v", err)
 		}
 
-		commentHeaderPages = append(commentHeaderPages, bytes.NewReader(page.Body))
-
-		if page.Complete {
+		if packet.SerialNumber == serial {
 			break
 		}
 	}
 
-	err = parseCommentHeader(io.MultiReader(commentHeaderPages...), &info)
+	err = parseCommentHeader(bytes.NewReader(packet.Data), &info, &c)
 	if err != nil {
-		return info, fmt.Errorf("invalid identification header, %v", err)
+		return info, fmt.Errorf("invalid comment header, %v", err)
+	}
+
+	err = c.checkCommentHeaderPage(d.Page(), &packet)
+	if err != nil {
+		return info, err
+	}
+
+	info.Warnings = c.warnings
+
+	if !seekable {
+		// Continue with the packets after the comment header.
+		s := propertyScanner{info: &info, serial: serial, packets: 1}
+		if s.addPacket(&packet, d.Page()) {
+			s.finish()
+		} else {
+			err = s.scan(d)
+		}
+		info.Warnings = c.warnings
+		if err != nil {
+			return info, fmt.Errorf("failed to determine audio properties, %v", err)
+		}
+		return info, nil
+	}
+
+	if opts.Properties {
+		err = opts.scanProperties(rs, start, serial, &info, &c)
+		info.Warnings = c.warnings
+		if err != nil {
+			return info, fmt.Errorf("failed to determine audio properties, %v", err)
+		}
+		return info, nil
 	}
 
+	// The duration follows from the granule position of the last page,
+	// so only the end of the stream has to be read.
+
+	granule, err := ogg.LastGranule(rs, serial)
+	if err != nil {
+		return info, fmt.Errorf("failed to determine duration, %v", err)
+	}
+	info.Duration = granuleDuration(granule - int64(info.PreSkip))
+
 	return info, nil
 }
 
+// noStreamError returns the error for a stream without Opus headers, which
+// may have been skipped as damaged data.
+func noStreamError(pr *ogg.PageReader) error {
+	if pr.TotalSkipped() > 0 {
+		return fmt.Errorf("no Opus stream found, %d bytes of damaged data skipped", pr.TotalSkipped())
+	}
+	return errors.New("no Opus stream found")
+}
+
+// granuleDuration converts a number of 48 kHz samples to a duration.
+func granuleDuration(samples int64) time.Duration {
+	if samples <= 0 {
+		return 0
+	}
+	seconds := samples / opus_granule_rate
+	remainder := samples % opus_granule_rate
+	return time.Duration(seconds)*time.Second + time.Duration(remainder)*time.Second/opus_granule_rate
+}
+
 // parseHeader parses an Opus identification (ID) header.
-// The reader's underlying data _must_ be able to support
-// the mininum size of a header, otherwise the parser will
-// panic.
-func parseIDHeader(r io.Reader, info *OpusInfo) (err error) {
+func parseIDHeader(r *bytes.Reader, info *OpusInfo, c *conformance) (err error) {
 	br := binary.NewReader(r)
 
 	//	0                   1                   2                   3
@@ -125,8 +282,14 @@ func parseIDHeader(r io.Reader, info *OpusInfo) (err error) {
 		return errors.New("expected magic signature 'OpusHead'")
 	}
 
+	// The upper four bits are the major version, streams with a major
+	// version other than 0 are incompatible.
+	if version>>4 != 0 {
+		return fmt.Errorf("unsupported version %d", version)
+	}
+
 	if version != 1 {
-		return errors.New("expected version=1")
+		c.warn("version %d, expected 1 (RFC 7845, section 5.1)", version)
 	}
 
 	info.Channels = channel_count
@@ -135,10 +298,22 @@ func parseIDHeader(r io.Reader, info *OpusInfo) (err error) {
 	info.OutputGain = float64(int16(output_gain)) / float64(256.0)
 	info.MappingFamily = mapping_family
 
+	err = parseChannelMapping(r, info, c)
+	if err != nil {
+		return fmt.Errorf("invalid channel mapping, %v", err)
+	}
+
+	// Only a later minor version may add fields to the header.
+	if r.Len() > 0 && version <= 1 {
+		return c.violation("%d bytes of unspecified data after the header (RFC 7845, section 5.1)", r.Len())
+	}
+
 	return nil
 }
 
-func parseCommentHeader(r io.Reader, info *OpusInfo) error {
+// parseCommentHeader parses an Opus comment header. Lengths are checked
+// against the size of the header before anything is allocated.
+func parseCommentHeader(r *bytes.Reader, info *OpusInfo, c *conformance) error {
 	br := binary.NewReader(r)
 
 	//  0                   1                   2                   3
@@ -172,7 +347,7 @@ func parseCommentHeader(r io.Reader, info *OpusInfo) error {
 	}
 
 	if capture_pattern != opus_comment_header_magic_sig {
-		return errors.New("expected magic signature 'OpusHead'")
+		return errors.New("expected magic signature 'OpusTags'")
 	}
 
 	vendor_string_length := br.ReadUint32()
@@ -180,24 +355,45 @@ func parseCommentHeader(r io.Reader, info *OpusInfo) error {
 		return br.Err()
 	}
 
+	if int64(vendor_string_length) > int64(r.Len()) {
+		return fmt.Errorf("vendor string length %d exceeds the header", vendor_string_length)
+	}
+
 	vendor_string := make([]byte, vendor_string_length)
 	_, err := io.ReadFull(r, vendor_string)
 	if err != nil {
 		return err
 	}
 
+	if !utf8.Valid(vendor_string) {
+		err = c.violation("vendor string is not valid UTF-8 (RFC 7845, section 5.2)")
+		if err != nil {
+			return err
+		}
+	}
+
 	user_comment_list_length := br.ReadUint32()
 	if br.Err() != nil {
 		return br.Err()
 	}
 
-	user_comments := make(map[string]string, user_comment_list_length)
+	// Every user comment takes at least 4 bytes for its length.
+	if int64(user_comment_list_length) > int64(r.Len()/4) {
+		return fmt.Errorf("user comment list length %d exceeds the header", user_comment_list_length)
+	}
+
+	user_comments := make(Comments, 0, user_comment_list_length)
+	var invalid_comments [][]byte
 	for range user_comment_list_length {
 		user_comment_string_length := br.ReadUint32()
 		if br.Err() != nil {
 			return br.Err()
 		}
 
+		if int64(user_comment_string_length) > int64(r.Len()) {
+			return fmt.Errorf("user comment length %d exceeds the header", user_comment_string_length)
+		}
+
 		user_comment_string := make([]byte, user_comment_string_length)
 		_, err := io.ReadFull(r, user_comment_string)
 		if err != nil {
@@ -205,13 +401,52 @@ func parseCommentHeader(r io.Reader, info *OpusInfo) error {
 		}
 		key, value, found := bytes.Cut(user_comment_string, []byte("="))
 		if !found {
+			err = c.violation("user comment %q is not of the form NAME=value (RFC 7845, section 5.2)", user_comment_string)
+			if err != nil {
+				return err
+			}
+			invalid_comments = append(invalid_comments, user_comment_string)
 			continue
 		}
-		user_comments[strings.ToUpper(string(key))] = string(value)
+
+		err = checkComment(key, value, c)
+		if err != nil {
+			return err
+		}
+		user_comments = append(user_comments, Comment{Name: string(key), Value: string(value)})
 	}
 
 	info.Vendor = string(vendor_string)
 	info.Comments = user_comments
+	info.invalidComments = invalid_comments
+	info.CommentData = nil
+
+	if r.Len() > 0 {
+		data := make([]byte, r.Len())
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return err
+		}
+		if data[0]&0x01 == 0x01 {
+			info.CommentData = data
+		}
+	}
+
+	return parseR128Gains(info, c)
+}
+
+// checkComment checks that a comment's field name consists of printable
+// ASCII characters other than '=', and that its value is valid UTF-8.
+func checkComment(key, value []byte, c *conformance) error {
+	for _, ch := range key {
+		if ch < 0x20 || ch > 0x7d {
+			return c.violation("user comment field name %q contains invalid characters (RFC 7845, section 5.2)", key)
+		}
+	}
+
+	if !utf8.Valid(value) {
+		return c.violation("user comment %q is not valid UTF-8 (RFC 7845, section 5.2)", key)
+	}
 
 	return nil
 }
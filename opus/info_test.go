@@ -0,0 +1,172 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// testIDHeader is the identification header of a stereo stream with a
+// pre-skip of 312 samples.
+const testIDHeader = "OpusHead\x01\x02\x38\x01\x80\xbb\x00\x00\x00\x00\x00"
+
+// testCommentHeader returns a comment header with the given comments.
+func testCommentHeader(comments ...string) []byte {
+	b := []byte("OpusTags")
+	b = binary.LittleEndian.AppendUint32(b, 6)
+	b = append(b, "gopus "...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(comments)))
+	for _, comment := range comments {
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(comment)))
+		b = append(b, comment...)
+	}
+	return b
+}
+
+// testAudioPacket is a 20 ms CELT packet.
+var testAudioPacket = append([]byte{0xfc}, bytes.Repeat([]byte{0x55}, 99)...)
+
+// writeTestStream returns an Ogg Opus stream with the given headers, each
+// on its own page, followed by count audio packets of 20 ms, 10 per page.
+func writeTestStream(t testing.TB, id, comment []byte, count int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket(id, 0)
+	w.Flush()
+	w.WritePacket(comment, 0)
+	w.Flush()
+	granule := int64(0)
+	for i := range count {
+		granule += 960
+		w.WritePacket(testAudioPacket, granule)
+		if i%10 == 9 {
+			w.Flush()
+		}
+	}
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// corruptPage flips a byte of the body of the nth page of a stream, so
+// that its checksum fails.
+func corruptPage(t *testing.T, stream []byte, n int) []byte {
+	t.Helper()
+	stream = bytes.Clone(stream)
+	r := bytes.NewReader(stream)
+	var page ogg.Page
+	for range n + 1 {
+		err := ogg.ParsePage(r, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream[len(stream)-r.Len()-1] ^= 0xff
+	return stream
+}
+
+func TestParseInfoReader(t *testing.T) {
+	stream := writeTestStream(t, []byte(testIDHeader), testCommentHeader("TITLE=a"), 100)
+	for _, opts := range []ParseOptions{{}, {Properties: true}, {Mode: Strict}} {
+		info, err := opts.ParseInfoReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Channels != 2 || info.PreSkip != 312 || info.Comments.Value("TITLE") != "a" {
+			t.Fatalf("unexpected headers %+v", info)
+		}
+		if info.Duration != 2*time.Second-312*time.Second/opus_granule_rate {
+			t.Fatalf("duration %v", info.Duration)
+		}
+		if len(info.Warnings) > 0 {
+			t.Fatalf("unexpected warnings %q", info.Warnings)
+		}
+	}
+}
+
+func TestParseInfoChecksum(t *testing.T) {
+	stream := writeTestStream(t, []byte(testIDHeader), testCommentHeader(), 100)
+
+	// A damaged identification header page fails the stream, unless it
+	// is recovered from, which skips the Opus stream.
+	damaged := corruptPage(t, stream, 0)
+	tests := []struct {
+		opts ParseOptions
+		err  string
+	}{
+		{ParseOptions{}, "checksum mismatch"},
+		{ParseOptions{Mode: Strict}, "checksum mismatch"},
+		{ParseOptions{Mode: Strict, Recover: true}, "checksum mismatch"},
+		{ParseOptions{Recover: true}, "no Opus stream found, 47 bytes of damaged data skipped"},
+	}
+	for _, test := range tests {
+		_, err := test.opts.ParseInfoReader(bytes.NewReader(damaged))
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%+v: expected error %q, got %v", test.opts, test.err, err)
+		}
+	}
+
+	// A damaged audio page is skipped with a warning.
+	damaged = corruptPage(t, stream, 5)
+	for _, opts := range []ParseOptions{{Properties: true}, {Properties: true, Mode: Strict, Recover: true}} {
+		_, err := opts.ParseInfoReader(bytes.NewReader(damaged))
+		if err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Errorf("%+v: expected a checksum error, got %v", opts, err)
+		}
+	}
+	info, err := ParseOptions{Properties: true, Recover: true}.ParseInfoReader(bytes.NewReader(damaged))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Warnings) != 1 || !strings.Contains(info.Warnings[0], "checksum mismatch") {
+		t.Fatalf("expected a checksum warning, got %q", info.Warnings)
+	}
+	if info.AudioPackets != 90 {
+		t.Fatalf("expected 90 audio packets, got %d", info.AudioPackets)
+	}
+}
+
+func TestParseInfoJunk(t *testing.T) {
+	stream := writeTestStream(t, []byte(testIDHeader), testCommentHeader(), 10)
+	stream = append([]byte("ID3\x04\x00\x00\x00\x00\x00\x00"), stream...)
+
+	_, err := ParseInfoReader(bytes.NewReader(stream))
+	if err == nil {
+		t.Fatal("expected junk to fail without recovery")
+	}
+	info, err := ParseOptions{Recover: true}.ParseInfoReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Warnings) != 1 || !strings.Contains(info.Warnings[0], "skipped 10 bytes of damaged data at offset 0") {
+		t.Fatalf("expected a warning for the junk, got %q", info.Warnings)
+	}
+}
+
+func BenchmarkParseInfo(b *testing.B) {
+	stream := writeTestStream(b, []byte(testIDHeader), testCommentHeader("TITLE=a", "ARTIST=b"), 3000)
+
+	for _, opts := range []ParseOptions{{}, {Properties: true}} {
+		name := "Headers"
+		if opts.Properties {
+			name = "Properties"
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(stream)))
+			b.ReportAllocs()
+			for range b.N {
+				_, err := opts.ParseInfoReader(bytes.NewReader(stream))
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,308 @@
+// Package framing parses the framing of Opus packets, the TOC byte and
+// the frame lengths (RFC 6716, section 3), for both the opus package and
+// its decoder.
+package framing
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// MaxFrameSize is the largest size of a frame in bytes.
+	MaxFrameSize = 1275
+	// MaxPacketDuration is the longest duration of a packet, in samples at
+	// 48 kHz (120 ms).
+	MaxPacketDuration = 5760
+	// MaxFrames is the largest number of frames in a packet, 120 ms of
+	// 2.5 ms frames.
+	MaxFrames = 48
+)
+
+// The coding modes.
+const (
+	ModeSILK = iota
+	ModeHybrid
+	ModeCELT
+)
+
+// The audio bandwidths.
+const (
+	Narrowband = iota
+	Mediumband
+	Wideband
+	SuperWideband
+	Fullband
+)
+
+// Config is the meaning of a configuration number of the TOC byte.
+type Config struct {
+	Mode      int
+	Bandwidth int
+	// FrameSize is the number of samples of a frame at 48 kHz.
+	FrameSize int
+}
+
+// Configs maps the configuration numbers to their mode, bandwidth and
+// frame size (RFC 6716, section 3.1, table 2).
+var Configs = func() [32]Config {
+	var configs [32]Config
+	silk := []int{480, 960, 1920, 2880}
+	hybrid := []int{480, 960}
+	celt := []int{120, 240, 480, 960}
+	for i := range 32 {
+		switch {
+		case i < 12:
+			configs[i] = Config{ModeSILK, i / 4, silk[i%4]}
+		case i < 16:
+			configs[i] = Config{ModeHybrid, SuperWideband + (i-12)/2, hybrid[i%2]}
+		default:
+			bandwidth := (i - 16) / 4
+			if bandwidth != Narrowband {
+				// CELT has no mediumband, the configurations go from
+				// narrowband to wideband.
+				bandwidth++
+			}
+			configs[i] = Config{ModeCELT, bandwidth, celt[i%4]}
+		}
+	}
+	return configs
+}()
+
+// TOC returns the configuration of a TOC byte.
+func TOC(toc byte) Config {
+	return Configs[toc>>3]
+}
+
+// Channels returns the number of channels of a TOC byte.
+func Channels(toc byte) int {
+	if toc&0x04 != 0 {
+		return 2
+	}
+	return 1
+}
+
+// Packet is an Opus packet split into its frames.
+type Packet struct {
+	// Code is the frame count code of the TOC byte.
+	Code uint8
+	// VBR is set if the frames of a code 3 packet differ in size.
+	VBR bool
+	// Count is the number of frames, Frames holds them. The frames point
+	// into the data of the packet.
+	Count  int
+	Frames [MaxFrames][]byte
+	// Padding is the number of bytes of a code 3 packet spent on padding,
+	// including the bytes coding its length.
+	Padding int
+	// Size is the size of the packet, which for a self-delimited packet
+	// may be followed by other data.
+	Size int
+}
+
+// Samples returns the number of samples at 48 kHz of a packet, from its
+// TOC byte and frame count.
+func Samples(data []byte) (int, error) {
+	if len(data) == 0 {
+		return 0, errors.New("packet is empty (RFC 6716, R1)")
+	}
+	count := 1
+	switch data[0] & 0x3 {
+	case 1, 2:
+		count = 2
+	case 3:
+		if len(data) < 2 {
+			return 0, errors.New("code 3 packet has no frame count (RFC 6716, R6)")
+		}
+		count = int(data[1] & 0x3f)
+		if count == 0 {
+			return 0, errors.New("code 3 packet has no frames (RFC 6716, R5)")
+		}
+	}
+	samples := count * TOC(data[0]).FrameSize
+	if samples > MaxPacketDuration {
+		return 0, fmt.Errorf("packet of %d samples exceeds 120 ms (RFC 6716, R5)", samples)
+	}
+	return samples, nil
+}
+
+// Parse splits a packet into its frames (RFC 6716, section 3.2), checking
+// the requirements R1 to R7 of section 3.4. A self-delimited packet (RFC
+// 6716, appendix B) also codes the size of its last frame, and may be
+// followed by other data, which is not part of the packet.
+func Parse(data []byte, self_delimited bool, packet *Packet) error {
+	//  0 1 2 3 4 5 6 7
+	// +-+-+-+-+-+-+-+-+
+	// | config  |s| c |
+	// +-+-+-+-+-+-+-+-+
+	if len(data) == 0 {
+		return errors.New("packet is empty (RFC 6716, R1)")
+	}
+
+	toc := data[0]
+	packet.Code = toc & 0x3
+	packet.VBR = false
+	packet.Count = 0
+	packet.Padding = 0
+	packet.Size = 0
+
+	var sizes [MaxFrames]int
+	// offset is where the next length or frame starts, length is the
+	// number of bytes left for them, without the padding.
+	offset := 1
+	length := len(data) - 1
+	padding := 0
+	count := 1
+	last_size := length
+	// cbr is set when all frames have the same size.
+	cbr := false
+	switch packet.Code {
+	case 0:
+		// One frame.
+
+	case 1:
+		// Two frames of equal size.
+		count = 2
+		cbr = true
+		if !self_delimited {
+			if length%2 != 0 {
+				return fmt.Errorf("code 1 packet of %d bytes cannot hold 2 equal frames (RFC 6716, R3)", len(data))
+			}
+			last_size = length / 2
+			sizes[0] = last_size
+		}
+
+	case 2:
+		// Two frames, the size of the first is coded.
+		count = 2
+		size, n := parseSize(data[offset:])
+		if size < 0 || size > length-n {
+			return errors.New("code 2 packet has an invalid frame length (RFC 6716, R4)")
+		}
+		offset += n
+		length -= n
+		sizes[0] = size
+		last_size = length - size
+
+	case 3:
+		//  0 1 2 3 4 5 6 7
+		// +-+-+-+-+-+-+-+-+
+		// |v|p|     M     |
+		// +-+-+-+-+-+-+-+-+
+		if length < 1 {
+			return errors.New("code 3 packet has no frame count (RFC 6716, R6)")
+		}
+		ch := data[offset]
+		offset++
+		length--
+		count = int(ch & 0x3f)
+		packet.VBR = ch&0x80 != 0
+		cbr = !packet.VBR
+		if count == 0 {
+			return errors.New("code 3 packet has no frames (RFC 6716, R5)")
+		}
+		if count*TOC(toc).FrameSize > MaxPacketDuration {
+			return fmt.Errorf("code 3 packet of %d frames exceeds 120 ms (RFC 6716, R5)", count)
+		}
+
+		// The padding length is coded in bytes of which 255 means 254
+		// bytes of padding and another length byte.
+		if ch&0x40 != 0 {
+			for {
+				if length <= 0 {
+					return errors.New("code 3 packet has an invalid padding length (RFC 6716, R6, R7)")
+				}
+				p := int(data[offset])
+				offset++
+				length--
+				packet.Padding++
+				if p < 255 {
+					padding += p
+					break
+				}
+				padding += 254
+			}
+			if padding > length {
+				return fmt.Errorf("padding of %d bytes exceeds the packet (RFC 6716, R6, R7)", padding)
+			}
+			length -= padding
+			packet.Padding += padding
+		}
+		last_size = length
+
+		if packet.VBR {
+			// The sizes of all frames but the last are coded.
+			for i := range count - 1 {
+				size, n := parseSize(data[offset : offset+length])
+				if size < 0 {
+					return errors.New("code 3 packet is too short for its frame lengths (RFC 6716, R7)")
+				}
+				offset += n
+				length -= n
+				sizes[i] = size
+				last_size -= n + size
+			}
+			if last_size < 0 {
+				return errors.New("frame lengths exceed the packet (RFC 6716, R7)")
+			}
+		} else if !self_delimited {
+			last_size = length / count
+			if last_size*count != length {
+				return fmt.Errorf("%d bytes cannot hold %d equal frames (RFC 6716, R6)", length, count)
+			}
+			for i := range count - 1 {
+				sizes[i] = last_size
+			}
+		}
+	}
+
+	if self_delimited {
+		// The size of the last frame is coded, and applies to all frames
+		// of equal size.
+		size, n := parseSize(data[offset : offset+length])
+		if size < 0 || size > length-n {
+			return errors.New("self-delimited packet has an invalid frame length (RFC 6716, appendix B)")
+		}
+		offset += n
+		length -= n
+		if cbr {
+			if size*count > length {
+				return fmt.Errorf("%d frames of %d bytes exceed the packet (RFC 6716, appendix B)", count, size)
+			}
+			for i := range count - 1 {
+				sizes[i] = size
+			}
+		} else if n+size > last_size {
+			return errors.New("frame lengths exceed the packet (RFC 6716, appendix B)")
+		}
+		last_size = size
+	}
+	sizes[count-1] = last_size
+
+	for i := range count {
+		// Coded lengths are at most 1275 bytes, only the last frame or
+		// frames of equal size can be larger.
+		if sizes[i] > MaxFrameSize {
+			return fmt.Errorf("frame of %d bytes exceeds %d bytes (RFC 6716, R2)", sizes[i], MaxFrameSize)
+		}
+		packet.Frames[i] = data[offset : offset+sizes[i]]
+		offset += sizes[i]
+	}
+	packet.Count = count
+	packet.Size = offset + padding
+	return nil
+}
+
+// parseSize parses a frame length coded in one or two bytes, and returns
+// the length and the number of bytes used, or -1 if data is too short.
+func parseSize(data []byte) (size int, n int) {
+	switch {
+	case len(data) < 1:
+		return -1, -1
+	case data[0] < 252:
+		return int(data[0]), 1
+	case len(data) < 2:
+		return -1, -1
+	}
+	return 4*int(data[1]) + int(data[0]), 2
+}
@@ -0,0 +1,100 @@
+package framing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	frame := func(size int, b byte) []byte {
+		return bytes.Repeat([]byte{b}, size)
+	}
+	join := func(parts ...[]byte) []byte {
+		return bytes.Join(parts, nil)
+	}
+
+	tests := []struct {
+		name           string
+		data           []byte
+		self_delimited bool
+		sizes          []int
+		padding        int
+		size           int
+		err            string
+	}{
+		{name: "code 0", data: join([]byte{0xfc}, frame(10, 1)), sizes: []int{10}, size: 11},
+		{name: "code 1", data: join([]byte{0xfd}, frame(20, 1)), sizes: []int{10, 10}, size: 21},
+		{name: "code 2", data: join([]byte{0xfe, 3}, frame(10, 1)), sizes: []int{3, 7}, size: 12},
+		{name: "code 3 CBR", data: join([]byte{0xff, 0x03}, frame(9, 1)), sizes: []int{3, 3, 3}, size: 11},
+		{name: "code 3 VBR", data: join([]byte{0xff, 0x83, 1, 2}, frame(6, 1)), sizes: []int{1, 2, 3}, size: 10},
+		{name: "code 3 padding", data: join([]byte{0xff, 0x42, 255, 2}, frame(4, 1), frame(256, 0)), sizes: []int{2, 2}, padding: 258, size: 264},
+		{name: "self-delimited code 0", data: join([]byte{0xfc, 4}, frame(4, 1), frame(5, 2)), self_delimited: true, sizes: []int{4}, size: 6},
+		{name: "self-delimited code 1", data: join([]byte{0xfd, 4}, frame(8, 1), frame(5, 2)), self_delimited: true, sizes: []int{4, 4}, size: 10},
+		{name: "self-delimited code 3 padding", data: join([]byte{0xff, 0x42, 3, 2}, frame(4, 1), frame(3, 0), frame(5, 2)), self_delimited: true, sizes: []int{2, 2}, padding: 4, size: 11},
+		{name: "empty", data: nil, err: "R1"},
+		{name: "frame too large", data: join([]byte{0xfc}, frame(1276, 1)), err: "R2"},
+		{name: "code 1 odd", data: join([]byte{0xfd}, frame(5, 1)), err: "R3"},
+		{name: "code 2 length", data: []byte{0xfe, 10, 1}, err: "R4"},
+		{name: "code 3 no frames", data: []byte{0xff, 0x00}, err: "R5"},
+		{name: "code 3 too long", data: []byte{0x1b, 0x03, 0}, err: "R5"},
+		{name: "code 3 padding length", data: []byte{0xff, 0x41, 10, 0}, err: "R6, R7"},
+		{name: "code 3 frame lengths", data: []byte{0xff, 0x82, 10, 0}, err: "R7"},
+		{name: "self-delimited length", data: []byte{0xfc, 10, 0}, self_delimited: true, err: "appendix B"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var packet Packet
+			err := Parse(test.data, test.self_delimited, &packet)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("expected error %q, got %v", test.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if packet.Count != len(test.sizes) || packet.Padding != test.padding || packet.Size != test.size {
+				t.Fatalf("%d frames, padding %d, size %d", packet.Count, packet.Padding, packet.Size)
+			}
+			for i, size := range test.sizes {
+				if len(packet.Frames[i]) != size || bytes.Count(packet.Frames[i], []byte{0}) != 0 {
+					t.Fatalf("frame %d: %x", i, packet.Frames[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSamples(t *testing.T) {
+	tests := []struct {
+		data    []byte
+		samples int
+	}{
+		{[]byte{0x00}, 480},
+		{[]byte{0x19}, 5760},
+		{[]byte{0xfb, 0x06}, 5760},
+		{[]byte{0x7a}, 1920},
+	}
+	for _, test := range tests {
+		samples, err := Samples(test.data)
+		if err != nil || samples != test.samples {
+			t.Errorf("%x: %d samples, %v, expected %d", test.data, samples, err, test.samples)
+		}
+	}
+
+	_, err := Samples([]byte{0x1b, 0x03})
+	if err == nil {
+		t.Error("expected 3 frames of 60 ms to exceed 120 ms")
+	}
+
+	// A code 3 packet without frames fails as it does in Parse.
+	data := []byte{0x03, 0x00}
+	_, err = Samples(data)
+	var packet Packet
+	parse_err := Parse(data, false, &packet)
+	if err == nil || parse_err == nil || err.Error() != parse_err.Error() {
+		t.Errorf("%x: Samples returned %v, Parse returned %v", data, err, parse_err)
+	}
+}
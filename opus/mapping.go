@@ -0,0 +1,235 @@
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/steabert/gopus/binary"
+)
+
+// Channel mapping families defined by RFC 7845, section 5.1.1.
+const (
+	MappingFamilyRTP        = 0
+	MappingFamilyVorbis     = 1
+	MappingFamilyAmbisonic  = 2
+	MappingFamilyProjection = 3
+	MappingFamilyUndefined  = 255
+)
+
+// opus_max_ambisonic_order is the highest ambisonic order allowed by
+// RFC 8486, section 3.1.
+const opus_max_ambisonic_order = 14
+
+// opus_silent_channel is the channel mapping index of a channel that is
+// not coded and decodes to silence.
+const opus_silent_channel = 255
+
+// Speaker is the position of an output channel.
+type Speaker uint8
+
+const (
+	SpeakerUnknown Speaker = iota
+	SpeakerMono
+	SpeakerLeft
+	SpeakerRight
+	SpeakerCenter
+	SpeakerFrontLeft
+	SpeakerFrontRight
+	SpeakerSideLeft
+	SpeakerSideRight
+	SpeakerRearLeft
+	SpeakerRearRight
+	SpeakerRearCenter
+	SpeakerLFE
+)
+
+var speakerNames = [...]string{
+	SpeakerUnknown:    "unknown",
+	SpeakerMono:       "mono",
+	SpeakerLeft:       "left",
+	SpeakerRight:      "right",
+	SpeakerCenter:     "center",
+	SpeakerFrontLeft:  "front left",
+	SpeakerFrontRight: "front right",
+	SpeakerSideLeft:   "side left",
+	SpeakerSideRight:  "side right",
+	SpeakerRearLeft:   "rear left",
+	SpeakerRearRight:  "rear right",
+	SpeakerRearCenter: "rear center",
+	SpeakerLFE:        "LFE",
+}
+
+func (s Speaker) String() string {
+	if int(s) < len(speakerNames) {
+		return speakerNames[s]
+	}
+	return speakerNames[SpeakerUnknown]
+}
+
+// vorbisChannelOrder is the speaker layout for each channel count of
+// mapping family 1, following the Vorbis channel order (RFC 7845,
+// section 5.1.1.2).
+var vorbisChannelOrder = [...][]Speaker{
+	1: {SpeakerMono},
+	2: {SpeakerLeft, SpeakerRight},
+	3: {SpeakerLeft, SpeakerCenter, SpeakerRight},
+	4: {SpeakerFrontLeft, SpeakerFrontRight, SpeakerRearLeft, SpeakerRearRight},
+	5: {SpeakerFrontLeft, SpeakerCenter, SpeakerFrontRight, SpeakerRearLeft, SpeakerRearRight},
+	6: {SpeakerFrontLeft, SpeakerCenter, SpeakerFrontRight, SpeakerRearLeft, SpeakerRearRight, SpeakerLFE},
+	7: {SpeakerFrontLeft, SpeakerCenter, SpeakerFrontRight, SpeakerSideLeft, SpeakerSideRight, SpeakerRearCenter, SpeakerLFE},
+	8: {SpeakerFrontLeft, SpeakerCenter, SpeakerFrontRight, SpeakerSideLeft, SpeakerSideRight, SpeakerRearLeft, SpeakerRearRight, SpeakerLFE},
+}
+
+// Speakers returns the speaker position of every output channel, in
+// output order. Channels of mapping families without a defined layout,
+// including ambisonic channels, are SpeakerUnknown. The non-diegetic
+// stereo pair of an ambisonic stream is reported as left and right.
+func (info *OpusInfo) Speakers() []Speaker {
+	speakers := make([]Speaker, info.Channels)
+	switch info.MappingFamily {
+	case MappingFamilyRTP, MappingFamilyVorbis:
+		if int(info.Channels) < len(vorbisChannelOrder) {
+			copy(speakers, vorbisChannelOrder[info.Channels])
+		}
+	case MappingFamilyAmbisonic, MappingFamilyProjection:
+		if info.NonDiegetic {
+			speakers[info.Channels-2] = SpeakerLeft
+			speakers[info.Channels-1] = SpeakerRight
+		}
+	}
+	return speakers
+}
+
+// IsAmbisonic reports whether the stream holds an ambisonic sound field
+// (mapping family 2 or 3, RFC 8486).
+func (info *OpusInfo) IsAmbisonic() bool {
+	return info.MappingFamily == MappingFamilyAmbisonic || info.MappingFamily == MappingFamilyProjection
+}
+
+// DemixingCoefficient returns the gain applied to a decoded channel for
+// an output channel, from the demixing matrix of mapping family 3.
+func (info *OpusInfo) DemixingCoefficient(output, decoded int) float64 {
+	return float64(info.DemixingMatrix[decoded*int(info.Channels)+output]) / 32768.0
+}
+
+// ambisonicOrder returns the ambisonic order for a channel count of
+// mapping family 2 or 3, which must be (1+n)^2 + 2j channels for an order
+// n of 0 to 14 and j of 0 or 1, where j indicates a non-diegetic stereo
+// pair (RFC 8486, section 3.1).
+func ambisonicOrder(channels int) (order int, nondiegetic bool, ok bool) {
+	for j := range 2 {
+		components := channels - 2*j
+		for n := 0; n <= opus_max_ambisonic_order; n++ {
+			if (n+1)*(n+1) == components {
+				return n, j == 1, true
+			}
+		}
+	}
+	return 0, false, false
+}
+
+// parseChannelMapping parses the channel mapping table that follows the
+// mapping family in the identification header, and validates it
+// against the channel count and the mapping family. For family 0 the
+// table is absent and implied by the channel count.
+func parseChannelMapping(r io.Reader, info *OpusInfo, c *conformance) error {
+	if info.Channels == 0 {
+		return errors.New("expected at least 1 channel")
+	}
+
+	if info.MappingFamily == MappingFamilyRTP {
+		if info.Channels > 2 {
+			return fmt.Errorf("mapping family 0 allows 1 or 2 channels, got %d", info.Channels)
+		}
+		info.StreamCount = 1
+		info.CoupledCount = info.Channels - 1
+		info.ChannelMapping = []uint8{0, 1}[:info.Channels]
+		return nil
+	}
+
+	//  0                   1                   2                   3
+	//  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	//                                                 +-+-+-+-+-+-+-+-+
+	//                                                 | Stream Count  |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// | Coupled Count |              Channel Mapping...               :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	//
+	// For mapping family 3 the channel mapping is replaced by a demixing
+	// matrix of 16-bit coefficients (RFC 8486, section 3.2).
+	br := binary.NewReader(r)
+	stream_count := br.ReadUint8()
+	coupled_count := br.ReadUint8()
+	if br.Err() != nil {
+		return br.Err()
+	}
+
+	if info.MappingFamily == MappingFamilyVorbis && info.Channels > 8 {
+		err := c.violation("mapping family 1 allows 1 to 8 channels, got %d (RFC 7845, section 5.1.1.2)", info.Channels)
+		if err != nil {
+			return err
+		}
+	}
+
+	if info.MappingFamily > MappingFamilyProjection && info.MappingFamily != MappingFamilyUndefined {
+		c.warn("unknown mapping family %d, treated as 255 (RFC 7845, section 5.1.1.4)", info.MappingFamily)
+	}
+
+	info.AmbisonicOrder = 0
+	info.NonDiegetic = false
+	if info.IsAmbisonic() {
+		order, nondiegetic, ok := ambisonicOrder(int(info.Channels))
+		if !ok {
+			return fmt.Errorf("%d channels is not a valid ambisonic channel count", info.Channels)
+		}
+		info.AmbisonicOrder = order
+		info.NonDiegetic = nondiegetic
+	}
+
+	if stream_count == 0 {
+		return errors.New("expected at least 1 stream")
+	}
+
+	if coupled_count > stream_count {
+		return fmt.Errorf("coupled stream count %d exceeds stream count %d", coupled_count, stream_count)
+	}
+
+	decoded_channels := int(stream_count) + int(coupled_count)
+	if decoded_channels > 255 {
+		return fmt.Errorf("stream count %d plus coupled stream count %d exceeds 255", stream_count, coupled_count)
+	}
+
+	info.StreamCount = stream_count
+	info.CoupledCount = coupled_count
+	info.ChannelMapping = nil
+	info.DemixingMatrix = nil
+
+	if info.MappingFamily == MappingFamilyProjection {
+		demixing_matrix := make([]int16, int(info.Channels)*decoded_channels)
+		for i := range demixing_matrix {
+			demixing_matrix[i] = int16(br.ReadUint16())
+		}
+		if br.Err() != nil {
+			return br.Err()
+		}
+		info.DemixingMatrix = demixing_matrix
+		return nil
+	}
+
+	channel_mapping := make([]uint8, info.Channels)
+	_, err := io.ReadFull(r, channel_mapping)
+	if err != nil {
+		return err
+	}
+
+	for channel, index := range channel_mapping {
+		if index != opus_silent_channel && int(index) >= decoded_channels {
+			return fmt.Errorf("channel %d maps to decoded channel %d, but there are only %d", channel, index, decoded_channels)
+		}
+	}
+
+	info.ChannelMapping = channel_mapping
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package opus
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+)
+
+// testMappingHeader returns an identification header with the given
+// channel count, mapping family and channel mapping table.
+func testMappingHeader(channels, family uint8, table ...byte) []byte {
+	id := []byte(testIDHeader)
+	id[9] = channels
+	id[18] = family
+	return append(id, table...)
+}
+
+func TestSpeakers(t *testing.T) {
+	// A 5.1 stream as laid out by libopus: the front and rear pairs are
+	// coupled streams, the center and LFE channels mono streams.
+	id := testMappingHeader(6, MappingFamilyVorbis, 4, 2, 0, 4, 1, 2, 3, 5)
+	stream := writeTestStream(t, id, testCommentHeader(), 10)
+	info, err := ParseOptions{Mode: Strict}.ParseInfoReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	speakers := []Speaker{SpeakerFrontLeft, SpeakerCenter, SpeakerFrontRight, SpeakerRearLeft, SpeakerRearRight, SpeakerLFE}
+	if !slices.Equal(info.Speakers(), speakers) {
+		t.Fatalf("speakers %v, expected %v", info.Speakers(), speakers)
+	}
+
+	// The first 2*CoupledCount decoded channels are the left and right
+	// channels of the coupled streams, the rest one mono stream each.
+	type assignment struct {
+		stream  int
+		coupled bool
+	}
+	expected := []assignment{{0, true}, {2, false}, {0, true}, {1, true}, {1, true}, {3, false}}
+	for channel, index := range info.ChannelMapping {
+		got := assignment{int(index) - int(info.CoupledCount), false}
+		if int(index) < 2*int(info.CoupledCount) {
+			got = assignment{int(index) / 2, true}
+		}
+		if got != expected[channel] {
+			t.Errorf("%v channel in stream %+v, expected %+v", speakers[channel], got, expected[channel])
+		}
+	}
+
+	tests := []struct {
+		id       []byte
+		speakers []Speaker
+	}{
+		{testMappingHeader(1, MappingFamilyRTP), []Speaker{SpeakerMono}},
+		{[]byte(testIDHeader), []Speaker{SpeakerLeft, SpeakerRight}},
+		{testMappingHeader(3, MappingFamilyVorbis, 2, 1, 0, 2, 1), []Speaker{SpeakerLeft, SpeakerCenter, SpeakerRight}},
+		{testMappingHeader(2, MappingFamilyUndefined, 2, 0, 0, 1), []Speaker{SpeakerUnknown, SpeakerUnknown}},
+	}
+	for _, test := range tests {
+		stream := writeTestStream(t, test.id, testCommentHeader(), 10)
+		info, err := ParseOptions{Mode: Strict}.ParseInfoReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !slices.Equal(info.Speakers(), test.speakers) {
+			t.Errorf("%d channels of family %d: speakers %v, expected %v",
+				info.Channels, info.MappingFamily, info.Speakers(), test.speakers)
+		}
+	}
+}
+
+func TestAmbisonicOrder(t *testing.T) {
+	tests := []struct {
+		channels    int
+		order       int
+		nondiegetic bool
+		ok          bool
+	}{
+		{1, 0, false, true},
+		{3, 0, true, true},
+		{4, 1, false, true},
+		{6, 1, true, true},
+		{9, 2, false, true},
+		{11, 2, true, true},
+		{16, 3, false, true},
+		{18, 3, true, true},
+		{225, 14, false, true},
+		{227, 14, true, true},
+		{2, 0, false, false},
+		{5, 0, false, false},
+		{8, 0, false, false},
+		{10, 0, false, false},
+		{17, 0, false, false},
+	}
+	for _, test := range tests {
+		order, nondiegetic, ok := ambisonicOrder(test.channels)
+		if order != test.order || nondiegetic != test.nondiegetic || ok != test.ok {
+			t.Errorf("%d channels: order %d, non-diegetic %v, %v", test.channels, order, nondiegetic, ok)
+		}
+	}
+
+	// Channel counts that are not ambisonic fail the header.
+	stream := writeTestStream(t, testMappingHeader(5, MappingFamilyAmbisonic, 5, 0, 0, 1, 2, 3, 4), testCommentHeader(), 10)
+	_, err := ParseInfoReader(bytes.NewReader(stream))
+	if err == nil {
+		t.Fatal("expected an error for 5 ambisonic channels")
+	}
+}
+
+func TestDemixingMatrix(t *testing.T) {
+	// First order ambisonics with a non-diegetic pair, in 3 coupled
+	// streams: the matrix has 6 rows (output channels) and 6 columns
+	// (decoded channels), stored column by column.
+	var matrix []byte
+	for i := range 36 {
+		coefficient := int16(i*1024 - 16384)
+		matrix = append(matrix, byte(coefficient), byte(uint16(coefficient)>>8))
+	}
+	id := testMappingHeader(6, MappingFamilyProjection, append([]byte{3, 3}, matrix...)...)
+	stream := writeTestStream(t, id, testCommentHeader(), 10)
+	info, err := ParseOptions{Mode: Strict}.ParseInfoReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.AmbisonicOrder != 1 || !info.NonDiegetic || len(info.DemixingMatrix) != 36 {
+		t.Fatalf("order %d, non-diegetic %v, %d coefficients", info.AmbisonicOrder, info.NonDiegetic, len(info.DemixingMatrix))
+	}
+	for decoded := range 6 {
+		for output := range 6 {
+			expected := float64((decoded*6+output)*1024-16384) / 32768
+			if info.DemixingCoefficient(output, decoded) != expected {
+				t.Fatalf("coefficient of decoded channel %d for output %d is %v, expected %v",
+					decoded, output, info.DemixingCoefficient(output, decoded), expected)
+			}
+		}
+	}
+	speakers := []Speaker{SpeakerUnknown, SpeakerUnknown, SpeakerUnknown, SpeakerUnknown, SpeakerLeft, SpeakerRight}
+	if !slices.Equal(info.Speakers(), speakers) {
+		t.Fatalf("speakers %v, expected %v", info.Speakers(), speakers)
+	}
+
+	// The matrix must hold a coefficient for every pair of output and
+	// decoded channel.
+	id = testMappingHeader(6, MappingFamilyProjection, append([]byte{3, 3}, matrix[:70]...)...)
+	stream = writeTestStream(t, id, testCommentHeader(), 10)
+	_, err = ParseInfoReader(bytes.NewReader(stream))
+	if err == nil {
+		t.Fatal("expected an error for a truncated demixing matrix")
+	}
+	id = testMappingHeader(6, MappingFamilyProjection, append([]byte{3, 2}, matrix...)...)
+	stream = writeTestStream(t, id, testCommentHeader(), 10)
+	_, err = ParseOptions{Mode: Strict}.ParseInfoReader(bytes.NewReader(stream))
+	if err == nil {
+		t.Fatal("expected an error for a demixing matrix with extra columns")
+	}
+}
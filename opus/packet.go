@@ -0,0 +1,183 @@
+package opus
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/steabert/gopus/opus/internal/framing"
+)
+
+// CodingMode is the coding mode of an Opus frame.
+type CodingMode int
+
+const (
+	// ModeSILK is the linear prediction mode, used for speech.
+	ModeSILK CodingMode = iota
+	// ModeHybrid combines SILK for the lower and CELT for the higher
+	// frequencies.
+	ModeHybrid
+	// ModeCELT is the MDCT mode, used for music.
+	ModeCELT
+)
+
+func (m CodingMode) String() string {
+	switch m {
+	case ModeSILK:
+		return "SILK"
+	case ModeHybrid:
+		return "Hybrid"
+	case ModeCELT:
+		return "CELT"
+	}
+	return fmt.Sprintf("CodingMode(%d)", int(m))
+}
+
+func (m CodingMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Bandwidth is the audio bandwidth of an Opus frame.
+type Bandwidth int
+
+const (
+	BandwidthNarrowband    Bandwidth = iota // 4 kHz
+	BandwidthMediumband                     // 6 kHz
+	BandwidthWideband                       // 8 kHz
+	BandwidthSuperWideband                  // 12 kHz
+	BandwidthFullband                       // 20 kHz
+)
+
+var bandwidthNames = [...]string{
+	BandwidthNarrowband:    "narrowband",
+	BandwidthMediumband:    "mediumband",
+	BandwidthWideband:      "wideband",
+	BandwidthSuperWideband: "super-wideband",
+	BandwidthFullband:      "fullband",
+}
+
+func (b Bandwidth) String() string {
+	if b >= 0 && int(b) < len(bandwidthNames) {
+		return bandwidthNames[b]
+	}
+	return fmt.Sprintf("Bandwidth(%d)", int(b))
+}
+
+func (b Bandwidth) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+// SampleRate returns the sample rate at which the bandwidth is coded.
+func (b Bandwidth) SampleRate() int {
+	switch b {
+	case BandwidthNarrowband:
+		return 8000
+	case BandwidthMediumband:
+		return 12000
+	case BandwidthWideband:
+		return 16000
+	case BandwidthSuperWideband:
+		return 24000
+	}
+	return 48000
+}
+
+// Packet is an Opus audio packet, split into its frames.
+type Packet struct {
+	// Config is the configuration number of the TOC byte, which
+	// determines Mode, Bandwidth and FrameSize.
+	Config    uint8
+	Mode      CodingMode
+	Bandwidth Bandwidth
+	// FrameSize is the number of samples of every frame at 48 kHz.
+	FrameSize int
+	Stereo    bool
+	// Code is the frame count code of the TOC byte: 0 for a single frame,
+	// 1 for two frames of equal size, 2 for two frames of different
+	// sizes and 3 for an arbitrary number of frames.
+	Code uint8
+	// VBR is set if the frames of a code 3 packet differ in size.
+	VBR bool
+	// Frames holds the compressed frames. A frame of size 0 means the
+	// frame is not transmitted (DTX) or lost. The frames point into the
+	// data of the packet.
+	Frames [][]byte
+	// Padding is the number of bytes of a code 3 packet spent on padding,
+	// including the bytes coding its length.
+	Padding int
+}
+
+// Samples returns the number of samples of the packet at 48 kHz.
+func (p *Packet) Samples() int {
+	return p.FrameSize * len(p.Frames)
+}
+
+// Duration returns the duration of the packet.
+func (p *Packet) Duration() time.Duration {
+	return time.Duration(p.Samples()) * time.Second / opus_granule_rate
+}
+
+// ErrMalformedPacket is returned for a packet that violates one of the
+// requirements R1 to R7 of RFC 6716, section 3.4.
+var ErrMalformedPacket = errors.New("malformed packet")
+
+func malformed(format string, args ...any) error {
+	return fmt.Errorf("%w, %s", ErrMalformedPacket, fmt.Sprintf(format, args...))
+}
+
+// ParsePacket parses an Opus packet (RFC 6716, section 3). The frames of
+// the packet point into data.
+func ParsePacket(data []byte, packet *Packet) error {
+	_, err := parsePacket(data, false, packet)
+	return err
+}
+
+// parseMultistreamPacket parses a packet of a stream of the given number
+// of Opus streams (RFC 7845, section 5.1.1), in which the packets of all
+// streams but the last are self-delimited (RFC 6716, appendix B). The
+// packet of the first stream is parsed into packet. It returns the number
+// of frame bytes of all streams.
+func parseMultistreamPacket(data []byte, streams int, packet *Packet) (frame_bytes int, err error) {
+	var other Packet
+	for s := range streams {
+		p := packet
+		if s > 0 {
+			p = &other
+		}
+		size, err := parsePacket(data, s < streams-1, p)
+		if err != nil {
+			return 0, fmt.Errorf("stream %d: %w", s, err)
+		}
+		if p.Samples() != packet.Samples() {
+			return 0, malformed("stream %d has %d samples, stream 0 has %d (RFC 7845, section 5.1.1)", s, p.Samples(), packet.Samples())
+		}
+		for _, frame := range p.Frames {
+			frame_bytes += len(frame)
+		}
+		data = data[size:]
+	}
+	return frame_bytes, nil
+}
+
+// parsePacket parses a packet, which may be self-delimited, and returns
+// its size.
+func parsePacket(data []byte, self_delimited bool, packet *Packet) (int, error) {
+	var frames framing.Packet
+	err := framing.Parse(data, self_delimited, &frames)
+	if err != nil {
+		return 0, malformed("%v", err)
+	}
+
+	config := framing.TOC(data[0])
+	packet.Config = data[0] >> 3
+	packet.Mode = CodingMode(config.Mode)
+	packet.Bandwidth = Bandwidth(config.Bandwidth)
+	packet.FrameSize = config.FrameSize
+	packet.Stereo = framing.Channels(data[0]) == 2
+	packet.Code = frames.Code
+	packet.VBR = frames.VBR
+	packet.Frames = append(packet.Frames[:0], frames.Frames[:frames.Count]...)
+	packet.Padding = frames.Padding
+
+	return frames.Size, nil
+}
@@ -0,0 +1,336 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/base64"
+	bin "encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strconv"
+	"strings"
+)
+
+// PictureComment is the name of the user comment that holds embedded
+// pictures, as base64-encoded FLAC picture blocks.
+const PictureComment = "METADATA_BLOCK_PICTURE"
+
+// picture_url_mime is the MIME type of a picture whose data is a URL
+// instead of the picture itself.
+const picture_url_mime = "-->"
+
+// PictureType is what a picture shows, as defined for the ID3v2 APIC frame.
+type PictureType uint32
+
+const (
+	PictureOther PictureType = iota
+	PictureFileIcon
+	PictureOtherFileIcon
+	PictureFrontCover
+	PictureBackCover
+	PictureLeaflet
+	PictureMedia
+	PictureLeadArtist
+	PictureArtist
+	PictureConductor
+	PictureBand
+	PictureComposer
+	PictureLyricist
+	PictureRecordingLocation
+	PictureDuringRecording
+	PictureDuringPerformance
+	PictureScreenCapture
+	PictureBrightFish
+	PictureIllustration
+	PictureBandLogo
+	PicturePublisherLogo
+)
+
+var pictureTypeNames = [...]string{
+	PictureOther:             "other",
+	PictureFileIcon:          "file icon",
+	PictureOtherFileIcon:     "other file icon",
+	PictureFrontCover:        "front cover",
+	PictureBackCover:         "back cover",
+	PictureLeaflet:           "leaflet",
+	PictureMedia:             "media",
+	PictureLeadArtist:        "lead artist",
+	PictureArtist:            "artist",
+	PictureConductor:         "conductor",
+	PictureBand:              "band",
+	PictureComposer:          "composer",
+	PictureLyricist:          "lyricist",
+	PictureRecordingLocation: "recording location",
+	PictureDuringRecording:   "during recording",
+	PictureDuringPerformance: "during performance",
+	PictureScreenCapture:     "screen capture",
+	PictureBrightFish:        "bright colored fish",
+	PictureIllustration:      "illustration",
+	PictureBandLogo:          "band logo",
+	PicturePublisherLogo:     "publisher logo",
+}
+
+func (t PictureType) String() string {
+	if int(t) < len(pictureTypeNames) {
+		return pictureTypeNames[t]
+	}
+	return fmt.Sprintf("PictureType(%d)", uint32(t))
+}
+
+// ParsePictureType returns the picture type with the given name, as
+// returned by String, or given as a number.
+func ParsePictureType(name string) (PictureType, error) {
+	for t, s := range pictureTypeNames {
+		if strings.EqualFold(s, name) {
+			return PictureType(t), nil
+		}
+	}
+	t, err := strconv.ParseUint(name, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unknown picture type %q", name)
+	}
+	return PictureType(t), nil
+}
+
+// Picture is an embedded picture. Width, Height, Depth (bits per pixel)
+// and Colors (for indexed pictures) may be 0 if unknown.
+type Picture struct {
+	Type        PictureType
+	MIME        string
+	Description string
+	Width       uint32
+	Height      uint32
+	Depth       uint32
+	Colors      uint32
+	Data        []byte
+}
+
+// IsURL reports whether the data of the picture is a URL to the picture
+// instead of the picture itself.
+func (p *Picture) IsURL() bool {
+	return p.MIME == picture_url_mime
+}
+
+// pictureMIME returns the MIME type of a GIF, JPEG or PNG image from its
+// magic bytes, or application/octet-stream for other data.
+func pictureMIME(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "image/png"
+	case bytes.HasPrefix(data, []byte("\xff\xd8\xff")):
+		return "image/jpeg"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "image/gif"
+	}
+	return "application/octet-stream"
+}
+
+// NewPicture creates a picture from the contents of an image file. The
+// MIME type is detected from the data, and for GIF, JPEG and PNG images
+// so are the dimensions and color depth.
+func NewPicture(t PictureType, description string, data []byte) Picture {
+	p := Picture{
+		Type:        t,
+		MIME:        pictureMIME(data),
+		Description: description,
+		Data:        data,
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err == nil {
+		p.Width = uint32(config.Width)
+		p.Height = uint32(config.Height)
+		switch model := config.ColorModel.(type) {
+		case color.Palette:
+			p.Depth = 8
+			p.Colors = uint32(len(model))
+		default:
+			p.Depth = colorDepth(model)
+		}
+	}
+
+	return p
+}
+
+// colorDepth returns the bits per pixel of the standard color models.
+func colorDepth(model color.Model) uint32 {
+	switch model {
+	case color.GrayModel, color.AlphaModel:
+		return 8
+	case color.Gray16Model, color.Alpha16Model:
+		return 16
+	case color.YCbCrModel:
+		return 24
+	case color.RGBAModel, color.NRGBAModel, color.CMYKModel:
+		return 32
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 64
+	}
+	return 0
+}
+
+// ParsePicture decodes the value of a METADATA_BLOCK_PICTURE comment.
+func ParsePicture(value string) (Picture, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return Picture{}, fmt.Errorf("invalid base64 encoding, %v", err)
+	}
+	return parsePictureBlock(data)
+}
+
+// parsePictureBlock parses a FLAC picture metadata block, without the
+// metadata block header. All numbers are big-endian.
+func parsePictureBlock(data []byte) (Picture, error) {
+	//  0                   1                   2                   3
+	//  0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                         Picture Type                          |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                        MIME Type Length                       |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// :                         MIME Type...                          :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                       Description Length                      |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// :                        Description...                         :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                             Width                             |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                             Height                            |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                          Color Depth                          |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                          Colors Used                          |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// |                          Data Length                          |
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	// :                            Data...                            :
+	// +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+	var p Picture
+	var err error
+
+	readUint32 := func() uint32 {
+		if err != nil {
+			return 0
+		}
+		if len(data) < 4 {
+			err = errors.New("picture block is truncated")
+			return 0
+		}
+		v := bin.BigEndian.Uint32(data)
+		data = data[4:]
+		return v
+	}
+	readBytes := func() []byte {
+		length := readUint32()
+		if err != nil {
+			return nil
+		}
+		if int64(length) > int64(len(data)) {
+			err = fmt.Errorf("length %d exceeds the picture block", length)
+			return nil
+		}
+		v := data[:length:length]
+		data = data[length:]
+		return v
+	}
+
+	p.Type = PictureType(readUint32())
+	p.MIME = string(readBytes())
+	p.Description = string(readBytes())
+	p.Width = readUint32()
+	p.Height = readUint32()
+	p.Depth = readUint32()
+	p.Colors = readUint32()
+	p.Data = readBytes()
+
+	if err != nil {
+		return Picture{}, err
+	}
+
+	return p, nil
+}
+
+// Encode returns the picture as the value of a METADATA_BLOCK_PICTURE
+// comment.
+func (p *Picture) Encode() string {
+	data := make([]byte, 0, 32+len(p.MIME)+len(p.Description)+len(p.Data))
+	data = bin.BigEndian.AppendUint32(data, uint32(p.Type))
+	data = bin.BigEndian.AppendUint32(data, uint32(len(p.MIME)))
+	data = append(data, p.MIME...)
+	data = bin.BigEndian.AppendUint32(data, uint32(len(p.Description)))
+	data = append(data, p.Description...)
+	data = bin.BigEndian.AppendUint32(data, p.Width)
+	data = bin.BigEndian.AppendUint32(data, p.Height)
+	data = bin.BigEndian.AppendUint32(data, p.Depth)
+	data = bin.BigEndian.AppendUint32(data, p.Colors)
+	data = bin.BigEndian.AppendUint32(data, uint32(len(p.Data)))
+	data = append(data, p.Data...)
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// Pictures decodes the embedded pictures, in order. Pictures that cannot
+// be decoded are skipped, an error describing them is returned along with
+// the others.
+func (cs Comments) Pictures() ([]Picture, error) {
+	var pictures []Picture
+	var errs []error
+	for i, value := range cs.Get(PictureComment) {
+		p, err := ParsePicture(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("picture %d, %v", i, err))
+			continue
+		}
+		pictures = append(pictures, p)
+	}
+	return pictures, errors.Join(errs...)
+}
+
+// AddPicture appends an embedded picture.
+func (cs *Comments) AddPicture(p Picture) {
+	cs.Add(PictureComment, p.Encode())
+}
+
+// SetPicture replaces the embedded pictures of the same type as p by p.
+// Without such pictures, p is appended. Pictures that cannot be decoded
+// are kept.
+func (cs *Comments) SetPicture(p Picture) {
+	result := make(Comments, 0, len(*cs)+1)
+	added := false
+	for _, c := range *cs {
+		if strings.EqualFold(c.Name, PictureComment) {
+			old, err := ParsePicture(c.Value)
+			if err == nil && old.Type == p.Type {
+				if !added {
+					result = append(result, Comment{Name: c.Name, Value: p.Encode()})
+					added = true
+				}
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+	if !added {
+		result = append(result, Comment{Name: PictureComment, Value: p.Encode()})
+	}
+	*cs = result
+}
+
+// DeletePictures removes the embedded pictures of the given type.
+func (cs *Comments) DeletePictures(t PictureType) {
+	result := make(Comments, 0, len(*cs))
+	for _, c := range *cs {
+		if strings.EqualFold(c.Name, PictureComment) {
+			old, err := ParsePicture(c.Value)
+			if err == nil && old.Type == t {
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+	*cs = result
+}
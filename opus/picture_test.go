@@ -0,0 +1,123 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewPicture(t *testing.T) {
+	img := image.NewPaletted(image.Rect(0, 0, 3, 2), color.Palette{color.Black, color.White})
+	encoders := []struct {
+		mime   string
+		encode func(b *bytes.Buffer) error
+	}{
+		{"image/png", func(b *bytes.Buffer) error { return png.Encode(b, img) }},
+		{"image/jpeg", func(b *bytes.Buffer) error { return jpeg.Encode(b, img, nil) }},
+		{"image/gif", func(b *bytes.Buffer) error { return gif.Encode(b, img, nil) }},
+	}
+	for _, e := range encoders {
+		var b bytes.Buffer
+		err := e.encode(&b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewPicture(PictureFrontCover, "", b.Bytes())
+		if p.MIME != e.mime || p.Width != 3 || p.Height != 2 {
+			t.Errorf("%s: got %s, %dx%d", e.mime, p.MIME, p.Width, p.Height)
+		}
+	}
+
+	p := NewPicture(PictureFrontCover, "", []byte("not an image"))
+	if p.MIME != "application/octet-stream" || p.Width != 0 {
+		t.Errorf("unknown data: got %s, %dx%d", p.MIME, p.Width, p.Height)
+	}
+}
+
+func TestParsePictureType(t *testing.T) {
+	tests := []struct {
+		name string
+		t    PictureType
+		ok   bool
+	}{
+		{"front cover", PictureFrontCover, true},
+		{"Band Logo", PictureBandLogo, true},
+		{"3", PictureFrontCover, true},
+		{"21", PictureType(21), true},
+		{"4294967295", PictureType(4294967295), true},
+		{"4294967296", 0, false},
+		{"3abc", 0, false},
+		{"3 front", 0, false},
+		{" 3", 0, false},
+		{"-1", 0, false},
+		{"cover", 0, false},
+		{"", 0, false},
+	}
+	for _, test := range tests {
+		pt, err := ParsePictureType(test.name)
+		if (err == nil) != test.ok || pt != test.t {
+			t.Errorf("%q: picture type %d, %v", test.name, pt, err)
+		}
+	}
+}
+
+func TestPictureEncode(t *testing.T) {
+	p := Picture{
+		Type:        PictureBackCover,
+		MIME:        "image/png",
+		Description: "back",
+		Width:       640,
+		Height:      480,
+		Depth:       24,
+		Colors:      0,
+		Data:        []byte("\x89PNG\r\n\x1a\nimage data"),
+	}
+	decoded, err := ParsePicture(p.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, p) {
+		t.Fatalf("decoded %+v, expected %+v", decoded, p)
+	}
+
+	block, err := base64.StdEncoding.DecodeString(p.Encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := func(block []byte) string {
+		return base64.StdEncoding.EncodeToString(block)
+	}
+	// The data length is the last field before the data.
+	data_length := len(block) - len(p.Data) - 4
+	oversized := bytes.Clone(block)
+	binary.BigEndian.PutUint32(oversized[data_length:], uint32(len(p.Data)+1))
+	huge := bytes.Clone(block)
+	binary.BigEndian.PutUint32(huge[4:], 0xffffffff)
+
+	tests := []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{"truncated data", encode(block[:len(block)-1]), "length 18 exceeds the picture block"},
+		{"truncated fields", encode(block[:30]), "picture block is truncated"},
+		{"oversized data length", encode(oversized), "length 19 exceeds the picture block"},
+		{"oversized MIME length", encode(huge), "length 4294967295 exceeds the picture block"},
+		{"empty", "", "picture block is truncated"},
+		{"not base64", "not base64!", "invalid base64 encoding"},
+	}
+	for _, test := range tests {
+		_, err := ParsePicture(test.value)
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: expected error %q, got %v", test.name, test.err, err)
+		}
+	}
+}
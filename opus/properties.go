@@ -0,0 +1,165 @@
+package opus
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// DecodeSampleRate is the sample rate at which Opus is decoded, and in
+// which granule positions are counted. The SampleRate of OpusInfo is only
+// the rate of the original input.
+const DecodeSampleRate = opus_granule_rate
+
+// opus_header_packets is the number of header packets that precede the
+// audio packets of a stream.
+const opus_header_packets = 2
+
+// propertyScanner determines the audio properties of a stream from its
+// packets. The audio ends at the end of the stream, not at the end of the
+// file.
+type propertyScanner struct {
+	info   *OpusInfo
+	serial uint32
+	// packets is the number of packets added so far, including the header
+	// packets.
+	packets int
+	granule int64
+	// page is the sequence number of the last page counted, or of the
+	// page ending the headers, and paged is set once a page is counted.
+	// pending is the audio bytes since the last page that ended with a
+	// granule position.
+	page    uint32
+	known   bool
+	paged   bool
+	pending int64
+	// seconds counts the audio bytes per second of audio for the peak
+	// bitrate, spreading the bytes of a page over the audio it holds.
+	seconds []int64
+}
+
+// scanProperties reads every packet of the stream with the given serial
+// number, from offset start of r, to determine its audio properties.
+func (opts ParseOptions) scanProperties(r io.ReadSeeker, start int64, serial uint32, info *OpusInfo, c *conformance) error {
+	_, err := r.Seek(start, io.SeekStart)
+	if err != nil {
+		return err
+	}
+
+	d := ogg.NewDemuxer(r)
+	opts.recoverPages(d.Pages(), c)
+
+	s := propertyScanner{info: info, serial: serial}
+	return s.scan(d)
+}
+
+// scan reads packets until the end of the stream.
+func (s *propertyScanner) scan(d *ogg.Demuxer) error {
+	var packet ogg.Packet
+	for {
+		err := d.ReadPacket(&packet)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		if s.addPacket(&packet, d.Page()) {
+			break
+		}
+	}
+
+	s.finish()
+	return nil
+}
+
+// addPacket adds a packet, read from page, to the properties. It returns
+// true at the end of the stream.
+func (s *propertyScanner) addPacket(packet *ogg.Packet, page *ogg.Page) bool {
+	if packet.SerialNumber != s.serial {
+		return false
+	}
+
+	s.packets++
+	if s.packets == opus_header_packets {
+		s.page = page.SequenceNumber
+		s.known = true
+	}
+	if s.packets > opus_header_packets {
+		s.info.AudioPackets++
+		s.info.AudioBytes += int64(len(packet.Data))
+		s.pending += int64(len(packet.Data))
+		if !s.paged || page.SequenceNumber != s.page {
+			// The pages since the last one counted hold only parts of
+			// this packet, unless pages are missing.
+			pages := int64(1)
+			if s.known && !packet.Gap && page.SequenceNumber > s.page {
+				pages = int64(page.SequenceNumber - s.page)
+			}
+			s.info.AudioPages += pages
+			s.page = page.SequenceNumber
+			s.known = true
+			s.paged = true
+		}
+	}
+
+	// The last packet ending on a page carries its granule position.
+	if s.packets >= opus_header_packets && packet.GranulePosition != -1 {
+		start := s.granule
+		s.granule = packet.GranulePosition
+		if s.pending > 0 {
+			pre_skip := int64(s.info.PreSkip)
+			s.seconds = spreadBytes(s.seconds, start-pre_skip, s.granule-pre_skip, s.pending)
+			s.pending = 0
+		}
+	}
+
+	return packet.LastPacket
+}
+
+// finish computes the duration and the bitrates.
+func (s *propertyScanner) finish() {
+	info := s.info
+	samples := s.granule - int64(info.PreSkip)
+
+	info.Duration = granuleDuration(samples)
+	if samples > 0 {
+		info.AverageBitrate = int(info.AudioBytes * 8 * int64(opus_granule_rate) / samples)
+	}
+
+	// An incomplete last second does not count, unless the stream is
+	// shorter than a second.
+	seconds := s.seconds
+	if samples%opus_granule_rate != 0 {
+		seconds = seconds[:max(len(seconds)-1, 0)]
+	}
+	for _, bytes := range seconds {
+		info.PeakBitrate = max(info.PeakBitrate, int(bytes*8))
+	}
+	if len(seconds) == 0 {
+		info.PeakBitrate = info.AverageBitrate
+	}
+}
+
+// spreadBytes adds n bytes of audio from sample start to end to the bytes
+// per second, in proportion to the samples in each second.
+func spreadBytes(seconds []int64, start, end, n int64) []int64 {
+	start = max(start, 0)
+	end = max(end, start+1)
+	for int64(len(seconds)) <= (end-1)/opus_granule_rate {
+		seconds = append(seconds, 0)
+	}
+
+	spread := int64(0)
+	for sample := start; sample < end; {
+		second := sample / opus_granule_rate
+		next := min((second+1)*opus_granule_rate, end)
+		part := n * (next - start) / (end - start)
+		seconds[second] += part - spread
+		spread = part
+		sample = next
+	}
+	return seconds
+}
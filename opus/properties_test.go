@@ -0,0 +1,53 @@
+package opus
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+func TestPropertiesAudioPages(t *testing.T) {
+	// A packet spanning three pages, the second of which holds only its
+	// middle, between pages of small packets.
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(testIDHeader), 0)
+	w.Flush()
+	w.WritePacket(testCommentHeader(), 0)
+	w.Flush()
+	w.WritePacket(testAudioPacket, 960)
+	w.Flush()
+	w.WritePacket(append([]byte{0xfc}, make([]byte, 140000)...), 1920)
+	w.WritePacket(testAudioPacket, 2880)
+	err := w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream := buf.Bytes()
+
+	var pages int
+	r := bytes.NewReader(stream)
+	var page ogg.Page
+	for r.Len() > 0 {
+		err := ogg.ParsePage(r, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+	}
+	if pages != 7 {
+		t.Fatalf("stream of %d pages, expected 7", pages)
+	}
+
+	for _, opts := range []ParseOptions{{Properties: true}, {Properties: true, Mode: Strict}} {
+		info, err := opts.ParseInfoReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Every page after the headers holds audio.
+		if len(info.Warnings) > 0 || info.AudioPages != 5 || info.AudioPackets != 3 {
+			t.Fatalf("%d audio pages and %d packets, expected 5 and 3", info.AudioPages, info.AudioPackets)
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package opus
+
+import (
+	"bytes"
+	bin "encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// opus_comment_header_padding is the number of zero bytes added after the
+// user comments when the comment header is rewritten, so that later edits
+// can be done in place.
+const opus_comment_header_padding = 512
+
+// encodeCommentHeader encodes an Opus comment header packet. The invalid
+// comments are written as is after the others. The data is appended after
+// the user comments; it is either the CommentData of a parsed header or
+// empty.
+func encodeCommentHeader(vendor string, comments Comments, invalid [][]byte, data []byte) []byte {
+	size := opus_comment_header_size + len(vendor) + len(data)
+	for _, c := range comments {
+		size += 4 + len(c.Name) + 1 + len(c.Value)
+	}
+	for _, comment := range invalid {
+		size += 4 + len(comment)
+	}
+
+	packet := make([]byte, 0, size)
+	packet = bin.LittleEndian.AppendUint64(packet, opus_comment_header_magic_sig)
+	packet = bin.LittleEndian.AppendUint32(packet, uint32(len(vendor)))
+	packet = append(packet, vendor...)
+	packet = bin.LittleEndian.AppendUint32(packet, uint32(len(comments)+len(invalid)))
+	for _, c := range comments {
+		packet = bin.LittleEndian.AppendUint32(packet, uint32(len(c.Name)+1+len(c.Value)))
+		packet = append(packet, c.Name...)
+		packet = append(packet, '=')
+		packet = append(packet, c.Value...)
+	}
+	for _, comment := range invalid {
+		packet = bin.LittleEndian.AppendUint32(packet, uint32(len(comment)))
+		packet = append(packet, comment...)
+	}
+	packet = append(packet, data...)
+
+	return packet
+}
+
+// tagPage is a page holding (part of) the comment header.
+type tagPage struct {
+	offset int64
+	page   ogg.Page
+}
+
+// tagLayout describes where the comment header of an Opus stream is.
+type tagLayout struct {
+	serial uint32
+	pages  []tagPage
+	packet []byte
+}
+
+// UpdateTags replaces the user comments of the Opus file at path. The
+// vendor string and any binary data that RFC 7845 asks editors to
+// preserve are kept, as are the user comments that are not of the form
+// NAME=value, which Comments cannot hold; they follow the new comments.
+//
+// If the new comment header fits in the space of the old one, taking up
+// its padding, and the old one is on a single page, that page is
+// overwritten in place. Otherwise the file is rewritten, with the comment
+// header repaginated and the pages following it renumbered if it changes
+// size. The new file is written to a temporary file next to the original
+// and renamed over it, so a crash never leaves a partially written file
+// behind.
+func UpdateTags(path string, comments Comments) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	layout, err := findCommentHeader(f)
+	if err != nil {
+		return err
+	}
+
+	var info OpusInfo
+	c := conformance{mode: Lenient}
+	err = parseCommentHeader(bytes.NewReader(layout.packet), &info, &c)
+	if err != nil {
+		return fmt.Errorf("invalid comment header, %v", err)
+	}
+
+	packet := encodeCommentHeader(info.Vendor, comments, info.invalidComments, info.CommentData)
+
+	// Padding can only be added when there is no binary data to preserve
+	// after the comments, since it would otherwise become part of it.
+	if len(packet) == len(layout.packet) || (info.CommentData == nil && len(packet) < len(layout.packet)) {
+		packet = append(packet, make([]byte, len(layout.packet)-len(packet))...)
+		// Pages are overwritten one at a time, a crash in between would
+		// leave a header of old and new pages.
+		if len(layout.pages) == 1 {
+			return writeTagsInPlace(f, layout, packet)
+		}
+		return writeTagsRewrite(f, path, layout, packet)
+	}
+
+	if info.CommentData == nil {
+		packet = append(packet, make([]byte, opus_comment_header_padding)...)
+	}
+	return writeTagsRewrite(f, path, layout, packet)
+}
+
+// findCommentHeader locates the pages of the comment header of the first
+// Opus stream. The comment header must start on the page after the
+// identification header and finish the page on which it ends.
+func findCommentHeader(r io.Reader) (*tagLayout, error) {
+	pr := ogg.NewPageReader(r)
+
+	var layout *tagLayout
+	var page ogg.Page
+	for {
+		err := pr.ReadPage(&page)
+		if err == io.EOF {
+			return nil, errors.New("no Opus comment header found")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		if layout == nil {
+			if page.FirstPage && bytes.HasPrefix(page.Body, []byte("OpusHead")) {
+				if countPackets(&page) != 1 || !page.Complete {
+					return nil, errors.New("identification header is not alone on its page")
+				}
+				layout = &tagLayout{serial: page.SerialNumber}
+			}
+			continue
+		}
+
+		if page.SerialNumber != layout.serial {
+			continue
+		}
+
+		if len(layout.pages) == 0 && page.Continued {
+			return nil, errors.New("comment header does not start on its own page")
+		}
+
+		// The page is only valid until the next read, keep a copy.
+		copied := page
+		copied.Segments = bytes.Clone(page.Segments)
+		copied.Body = bytes.Clone(page.Body)
+		layout.pages = append(layout.pages, tagPage{offset: pr.Offset(), page: copied})
+		layout.packet = append(layout.packet, page.Body...)
+
+		packets := countPackets(&page)
+		if packets == 0 {
+			continue
+		}
+		if packets > 1 || !page.Complete {
+			return nil, errors.New("comment header does not finish its page")
+		}
+
+		return layout, nil
+	}
+}
+
+// writeTagsInPlace overwrites the comment header page with a packet of
+// the same size.
+func writeTagsInPlace(f *os.File, layout *tagLayout, packet []byte) error {
+	tp := layout.pages[0]
+	page := tp.page
+	page.Body = packet
+
+	var buf bytes.Buffer
+	err := ogg.WritePage(&buf, &page)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteAt(buf.Bytes(), tp.offset)
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// writeTagsRewrite writes a copy of the file with a new comment header to
+// a temporary file, which then replaces the original.
+func writeTagsRewrite(f *os.File, path string, layout *tagLayout, packet []byte) (err error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	err = rewriteTags(f, tmp, layout, packet)
+	if err != nil {
+		return err
+	}
+
+	err = tmp.Chmod(stat.Mode())
+	if err != nil {
+		return err
+	}
+	err = tmp.Sync()
+	if err != nil {
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// rewriteTags copies the stream from r to w, replacing the comment header
+// pages by pages holding the new packet. Pages of the Opus stream after
+// the comment header are renumbered, pages of other streams are copied.
+func rewriteTags(r io.ReadSeeker, w io.Writer, layout *tagLayout, packet []byte) error {
+	first := layout.pages[0]
+
+	_, err := r.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	_, err = io.CopyN(w, r, first.offset)
+	if err != nil {
+		return err
+	}
+
+	// Write the new comment header pages, starting with the sequence
+	// number of the first old one.
+	ow := ogg.NewWriter(w, layout.serial)
+	ow.SetSequenceNumber(first.page.SequenceNumber)
+	err = ow.WritePacket(packet, 0)
+	if err != nil {
+		return err
+	}
+	err = ow.Flush()
+	if err != nil {
+		return err
+	}
+	delta := ow.SequenceNumber() - first.page.SequenceNumber - uint32(len(layout.pages))
+
+	// Copy the remaining pages, skipping the old comment header pages.
+	_, err = r.Seek(first.offset, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	pr := ogg.NewPageReader(r)
+	pr.Reset(r, first.offset)
+
+	old := 0
+	var page ogg.Page
+	for {
+		err := pr.ReadPage(&page)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid OGG stream, %v", err)
+		}
+
+		if page.SerialNumber == layout.serial {
+			if old < len(layout.pages) {
+				old++
+				continue
+			}
+			page.SequenceNumber += delta
+		}
+
+		err = ogg.WritePage(w, &page)
+		if err != nil {
+			return err
+		}
+	}
+}
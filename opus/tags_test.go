@@ -0,0 +1,113 @@
+package opus
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// updateTestTags writes stream to a file, replaces its comments and
+// returns the comments read back, and whether the file was replaced
+// rather than overwritten in place.
+func updateTestTags(t *testing.T, stream []byte, comments Comments) (OpusInfo, bool) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.opus")
+	err := os.WriteFile(path, stream, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UpdateTags(path, comments)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ParseOptions{Mode: Strict, Properties: true}.ParseInfo(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.AudioPackets != 20 || !reflect.DeepEqual(info.Comments, comments) {
+		t.Fatalf("read back %d packets and comments %q", info.AudioPackets, info.Comments)
+	}
+	return info, !os.SameFile(before, after)
+}
+
+func TestUpdateTags(t *testing.T) {
+	comments := Comments{{"TITLE", "a"}, {"ARTIST", "b"}, {"ARTIST", "c"}}
+	padded := append(testCommentHeader("TITLE=x"), make([]byte, 200)...)
+	large := strings.Repeat("x", 70000)
+
+	tests := []struct {
+		name     string
+		header   []byte
+		comments Comments
+		replaced bool
+	}{
+		{"into padding", padded, comments, false},
+		{"grow", testCommentHeader("TITLE=x"), comments, true},
+		// The header spans two pages, which are not overwritten in place.
+		{"several pages", append(testCommentHeader("TITLE="+large), make([]byte, 200)...), comments, true},
+		{"to several pages", padded, Comments{{"TITLE", large}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			stream := writeTestStream(t, []byte(testIDHeader), test.header, 20)
+			_, replaced := updateTestTags(t, stream, test.comments)
+			if replaced != test.replaced {
+				t.Fatalf("file replaced %v, expected %v", replaced, test.replaced)
+			}
+		})
+	}
+}
+
+func TestUpdateTagsCommentData(t *testing.T) {
+	// Binary data after the comments is kept.
+	header := append(testCommentHeader("TITLE=x"), 0x01, 0x02, 0x03)
+	stream := writeTestStream(t, []byte(testIDHeader), header, 20)
+	info, _ := updateTestTags(t, stream, Comments{{"TITLE", "a"}})
+	if !bytes.Equal(info.CommentData, []byte{0x01, 0x02, 0x03}) {
+		t.Fatalf("comment data %x", info.CommentData)
+	}
+}
+
+func TestUpdateTagsInvalidComments(t *testing.T) {
+	// Comments that are not of the form NAME=value are kept after the new
+	// comments.
+	header := testCommentHeader("no separator", "TITLE=x")
+	stream := writeTestStream(t, []byte(testIDHeader), header, 20)
+	path := filepath.Join(t.TempDir(), "test.opus")
+	err := os.WriteFile(path, stream, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = UpdateTags(path, Comments{{"TITLE", "a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	layout, err := findCommentHeader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := testCommentHeader("TITLE=a", "no separator")
+	if !bytes.HasPrefix(layout.packet, expected) {
+		t.Fatalf("comment header %q, expected %q", layout.packet, expected)
+	}
+}
@@ -0,0 +1,202 @@
+package opus
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// Problem is a conformance problem found in an Ogg Opus stream.
+type Problem struct {
+	// Offset is the byte offset of the page (or damaged region) where the
+	// problem was found.
+	Offset       int64  `json:"offset"`
+	SerialNumber uint32 `json:"serial"`
+	Message      string `json:"message"`
+}
+
+func (p Problem) String() string {
+	return fmt.Sprintf("offset %d, stream %08x: %s", p.Offset, p.SerialNumber, p.Message)
+}
+
+// VerifyReport is the result of verifying an Ogg Opus stream.
+type VerifyReport struct {
+	Pages    int       `json:"pages"`
+	Streams  int       `json:"streams"`
+	Problems []Problem `json:"problems"`
+}
+
+// OK reports whether no problems were found.
+func (r *VerifyReport) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// verifyStream is the state kept for every logical bitstream.
+type verifyStream struct {
+	opus          bool
+	pages         int
+	headerPackets int
+	sequence      uint32
+	granule       int64
+	inPacket      bool
+	ended         bool
+	offset        int64
+}
+
+// Verify checks the conformance of the Ogg Opus file at path.
+func Verify(path string) (VerifyReport, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	defer f.Close()
+
+	return VerifyReader(f)
+}
+
+// VerifyReader walks every page of an Ogg Opus stream and reports problems:
+// checksum failures, damaged regions, page sequence gaps, granule positions
+// going backward, missing "Beginning Of Stream" or "End Of Stream" pages,
+// headers not on their own pages (RFC 7845, section 3), streams ending in
+// the middle of a packet, and the lack of an Opus stream. An error is only
+// returned if reading fails.
+func VerifyReader(r io.Reader) (VerifyReport, error) {
+	var report VerifyReport
+
+	pr := ogg.NewPageReader(r)
+	streams := make(map[uint32]*verifyStream)
+	var order []uint32
+
+	problem := func(offset int64, serial uint32, format string, args ...any) {
+		report.Problems = append(report.Problems, Problem{
+			Offset:       offset,
+			SerialNumber: serial,
+			Message:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	var page ogg.Page
+	for {
+		err := pr.ReadPage(&page)
+		if err != nil && err != io.EOF && !errors.Is(err, ogg.ErrChecksum) {
+			// Not a page, skip ahead to the next valid one.
+			pr.Recover = true
+			err = pr.ReadPage(&page)
+			pr.Recover = false
+			if pr.Skipped() > 0 {
+				problem(pr.Offset()-pr.Skipped(), 0, "skipped %d bytes of invalid data", pr.Skipped())
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if errors.Is(err, ogg.ErrChecksum) {
+			problem(pr.Offset(), page.SerialNumber, "%v", err)
+		} else if err != nil {
+			return report, err
+		}
+
+		report.Pages++
+		offset := pr.Offset()
+		serial := page.SerialNumber
+
+		s, found := streams[serial]
+		if !found || (s.ended && page.FirstPage) {
+			if !page.FirstPage {
+				problem(offset, serial, "stream does not start with a beginning of stream page")
+			}
+			s = &verifyStream{granule: -1}
+			s.opus = page.FirstPage && bytes.HasPrefix(page.Body, []byte("OpusHead"))
+			streams[serial] = s
+			if !found {
+				order = append(order, serial)
+			}
+		} else {
+			if page.FirstPage {
+				problem(offset, serial, "beginning of stream flag on page %d of the stream", s.pages)
+			}
+			if s.ended {
+				problem(offset, serial, "page after the end of stream page")
+			}
+			if page.SequenceNumber != s.sequence+1 {
+				problem(offset, serial, "page sequence number %d, expected %d", page.SequenceNumber, s.sequence+1)
+			}
+		}
+
+		if page.GranulePosition != -1 {
+			if page.GranulePosition < s.granule {
+				problem(offset, serial, "granule position %d goes backward from %d", page.GranulePosition, s.granule)
+			}
+			s.granule = page.GranulePosition
+		}
+
+		if page.Continued && !s.inPacket {
+			problem(offset, serial, "page continues a packet that was not started")
+		} else if !page.Continued && s.inPacket {
+			problem(offset, serial, "page does not continue the unfinished packet of the previous page")
+		}
+
+		packets := countPackets(&page)
+
+		if s.opus && s.headerPackets < 2 {
+			verifyHeaderPage(&page, s, packets, func(format string, args ...any) {
+				problem(offset, serial, format, args...)
+			})
+		}
+
+		s.pages++
+		s.sequence = page.SequenceNumber
+		if len(page.Segments) > 0 {
+			s.inPacket = page.Segments[len(page.Segments)-1] == 255
+		}
+		s.ended = s.ended || page.LastPage
+		s.offset = offset
+	}
+
+	found := false
+	for _, serial := range order {
+		s := streams[serial]
+		found = found || s.opus
+		if s.inPacket {
+			problem(s.offset, serial, "stream ends in the middle of a packet")
+		}
+		if !s.ended {
+			problem(s.offset, serial, "stream has no end of stream page")
+		}
+	}
+	if !found {
+		problem(0, 0, "no Opus stream found")
+	}
+	report.Streams = len(order)
+
+	return report, nil
+}
+
+// verifyHeaderPage checks that the identification and comment headers of
+// an Opus stream are on pages of their own. The identification header
+// must be the only packet on the first page, and the comment header must
+// finish its last page.
+func verifyHeaderPage(page *ogg.Page, s *verifyStream, packets int, problem func(format string, args ...any)) {
+	complete := len(page.Segments) > 0 && page.Segments[len(page.Segments)-1] < 255
+
+	switch s.headerPackets {
+	case 0:
+		if packets != 1 || !complete {
+			problem("identification header is not alone on the first page")
+		}
+	case 1:
+		if packets > 1 || (packets == 1 && !complete) {
+			problem("comment header is not alone on its last page")
+		}
+	}
+
+	if page.GranulePosition != 0 && packets > 0 {
+		problem("header page has granule position %d, expected 0", page.GranulePosition)
+	}
+
+	s.headerPackets += packets
+}
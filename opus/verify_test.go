@@ -0,0 +1,184 @@
+package opus
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/steabert/gopus/ogg"
+)
+
+// readTestPages returns the pages of a stream.
+func readTestPages(t *testing.T, stream []byte) []ogg.Page {
+	t.Helper()
+	var pages []ogg.Page
+	r := bytes.NewReader(stream)
+	for r.Len() > 0 {
+		var page ogg.Page
+		err := ogg.ParsePage(r, &page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+// writeTestPages returns the stream of pages and the offset of every page.
+func writeTestPages(t *testing.T, pages []ogg.Page) ([]byte, []int64) {
+	t.Helper()
+	var buf bytes.Buffer
+	var offsets []int64
+	for i := range pages {
+		offsets = append(offsets, int64(buf.Len()))
+		err := ogg.WritePage(&buf, &pages[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes(), offsets
+}
+
+// verifyTestPages verifies the stream of pages, expecting exactly one
+// problem containing msg at the offset of page n.
+func verifyTestPages(t *testing.T, pages []ogg.Page, n int, msg string) {
+	t.Helper()
+	stream, offsets := writeTestPages(t, pages)
+	report, err := VerifyReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 1 {
+		t.Fatalf("expected one problem, got %v", report.Problems)
+	}
+	problem := report.Problems[0]
+	if problem.Offset != offsets[n] || !strings.Contains(problem.Message, msg) {
+		t.Fatalf("problem %v, expected %q at offset %d", problem, msg, offsets[n])
+	}
+}
+
+func TestVerifyReader(t *testing.T) {
+	stream := writeTestStream(t, []byte(testIDHeader), testCommentHeader("TITLE=a"), 50)
+	report, err := VerifyReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.OK() || report.Pages != 8 || report.Streams != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+}
+
+func TestVerifyReaderProblems(t *testing.T) {
+	stream := writeTestStream(t, []byte(testIDHeader), testCommentHeader("TITLE=a"), 50)
+	pages := readTestPages(t, stream)
+	last := len(pages) - 1
+
+	t.Run("granule backward", func(t *testing.T) {
+		pages := slices.Clone(pages)
+		pages[4].GranulePosition = 100
+		verifyTestPages(t, pages, 4, "granule position 100 goes backward from 19200")
+	})
+	t.Run("sequence gap", func(t *testing.T) {
+		pages := slices.Delete(slices.Clone(pages), 3, 4)
+		verifyTestPages(t, pages, 3, "page sequence number 4, expected 3")
+	})
+	t.Run("missing BOS", func(t *testing.T) {
+		pages := slices.Clone(pages)
+		pages[0].FirstPage = false
+		stream, _ := writeTestPages(t, pages)
+		report, err := VerifyReader(bytes.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Without its first page, the stream is not known to be Opus.
+		expected := []Problem{
+			{0, 1, "stream does not start with a beginning of stream page"},
+			{0, 0, "no Opus stream found"},
+		}
+		if !slices.Equal(report.Problems, expected) {
+			t.Fatalf("problems %v, expected %v", report.Problems, expected)
+		}
+	})
+	t.Run("missing EOS", func(t *testing.T) {
+		pages := slices.Clone(pages)
+		pages[last].LastPage = false
+		verifyTestPages(t, pages, last, "stream has no end of stream page")
+	})
+	t.Run("header granule", func(t *testing.T) {
+		pages := slices.Clone(pages)
+		pages[1].GranulePosition = 5
+		verifyTestPages(t, pages, 1, "header page has granule position 5, expected 0")
+	})
+	t.Run("ends mid-packet", func(t *testing.T) {
+		pages := slices.Clone(pages)
+		pages[last].Segments = append(slices.Clone(pages[last].Segments), 255)
+		pages[last].Body = append(slices.Clone(pages[last].Body), make([]byte, 255)...)
+		verifyTestPages(t, pages, last, "stream ends in the middle of a packet")
+	})
+}
+
+func TestVerifyReaderHeaderLayout(t *testing.T) {
+	// Both headers on the first page.
+	var buf bytes.Buffer
+	w := ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(testIDHeader), 0)
+	w.WritePacket(testCommentHeader(), 0)
+	w.Flush()
+	w.WritePacket(testAudioPacket, 960)
+	w.Close()
+	verifyTestPages(t, readTestPages(t, buf.Bytes()), 0, "identification header is not alone on the first page")
+
+	// The comment header shares its page with audio.
+	buf.Reset()
+	w = ogg.NewWriter(&buf, 1)
+	w.WritePacket([]byte(testIDHeader), 0)
+	w.Flush()
+	w.WritePacket(testCommentHeader(), 0)
+	w.WritePacket(testAudioPacket, 0)
+	w.Close()
+	verifyTestPages(t, readTestPages(t, buf.Bytes()), 1, "comment header is not alone on its last page")
+}
+
+func TestVerifyReaderDamage(t *testing.T) {
+	stream := writeTestStream(t, []byte(testIDHeader), testCommentHeader("TITLE=a"), 50)
+	_, offsets := writeTestPages(t, readTestPages(t, stream))
+
+	// A page failing its checksum is reported at its offset.
+	report, err := VerifyReader(bytes.NewReader(corruptPage(t, stream, 3)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Problems) != 1 || report.Problems[0].Offset != offsets[3] ||
+		!strings.Contains(report.Problems[0].Message, "checksum mismatch") {
+		t.Fatalf("problems %v, expected a checksum mismatch at offset %d", report.Problems, offsets[3])
+	}
+
+	// Junk between pages is skipped and reported at its offset.
+	junk := slices.Concat(stream[:offsets[4]], []byte("junk data!"), stream[offsets[4]:])
+	report, err = VerifyReader(bytes.NewReader(junk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Problem{{offsets[4], 0, "skipped 10 bytes of invalid data"}}
+	if !slices.Equal(report.Problems, expected) || report.Pages != len(offsets) {
+		t.Fatalf("problems %v in %d pages, expected %v", report.Problems, report.Pages, expected)
+	}
+}
+
+func TestVerifyReaderNoOpus(t *testing.T) {
+	vorbis := writeTestStream(t, []byte("\x01vorbis\x00\x00\x00\x00\x02\x44\xac\x00\x00"), []byte("\x03vorbis"), 10)
+	report, err := VerifyReader(bytes.NewReader(vorbis))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Problem{{0, 0, "no Opus stream found"}}
+	if !slices.Equal(report.Problems, expected) {
+		t.Fatalf("problems %v, expected %v", report.Problems, expected)
+	}
+
+	report, err = VerifyReader(bytes.NewReader(nil))
+	if err != nil || !slices.Equal(report.Problems, expected) {
+		t.Fatalf("problems %v, %v for an empty stream, expected %v", report.Problems, err, expected)
+	}
+}
@@ -0,0 +1,114 @@
+package rds
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// migrations upgrade the schema of an existing database, migrations[i]
+// from version i to version i+1. The version is kept in the user_version
+// pragma, schema.sql creates the latest version.
+var migrations = []string{
+	// Chained files have a recording per link, SQLite cannot change the
+	// primary key of a table so it is copied.
+	`CREATE TABLE recording_v1 (
+    path   TEXT NOT NULL,
+    song   TEXT NOT NULL,
+    artist TEXT NOT NULL,
+    album  TEXT NOT NULL,
+    cddb   TEXT NOT NULL,
+    track  INTEGER NOT NULL,
+    link   INTEGER NOT NULL DEFAULT 0,
+    start_offset INTEGER NOT NULL DEFAULT 0,
+
+    CONSTRAINT PK 
+        PRIMARY KEY ( path, link )
+
+    CONSTRAINT artist_recorded_song_on_album_fk
+        FOREIGN KEY       ( artist )
+        REFERENCES artist ( name )
+
+    CONSTRAINT album_contains_artist_song_fk
+        FOREIGN KEY      ( album )
+        REFERENCES album ( title )
+
+    CONSTRAINT song_recorded_by_artist_on_album_fk
+        FOREIGN KEY     ( song )
+        REFERENCES song ( title )
+);
+INSERT INTO recording_v1 ( path, song, artist, album, cddb, track )
+    SELECT path, song, artist, album, cddb, track FROM recording;
+DROP TABLE recording;
+ALTER TABLE recording_v1 RENAME TO recording;`,
+}
+
+// schemaVersion returns the version of the schema of a database, or -1 for
+// an empty database. Databases from before the version was kept have
+// version 0 unless they already have the columns of version 1.
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+	if err != nil || version > 0 {
+		return version, err
+	}
+
+	var tables int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'recording'").Scan(&tables)
+	if err != nil {
+		return 0, err
+	}
+	if tables == 0 {
+		return -1, nil
+	}
+
+	var columns int
+	err = db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pragma_table_info('recording') WHERE name = 'link'").Scan(&columns)
+	if err != nil {
+		return 0, err
+	}
+	return columns, nil
+}
+
+// migrate upgrades the schema of a database to the latest version. An
+// empty database is left to schema.sql.
+func migrate(ctx context.Context, db *sql.DB, readOnly bool) error {
+	version, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version == len(migrations) {
+		return nil
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("schema version %d is newer than version %d", version, len(migrations))
+	}
+	if readOnly {
+		if version < 0 {
+			return nil
+		}
+		return fmt.Errorf("schema version %d is out of date, scan to upgrade it", version)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if version >= 0 {
+		for i, migration := range migrations[version:] {
+			_, err = tx.ExecContext(ctx, migration)
+			if err != nil {
+				return fmt.Errorf("failed to upgrade schema to version %d, %v", version+i+1, err)
+			}
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(migrations)))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
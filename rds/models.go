@@ -14,15 +14,22 @@ type Artist struct {
 }
 
 type Recording struct {
-	Path       string
-	Song       string
-	Artist     string
-	Album      string
-	Cddb       string
-	Track      int64
-	Constraint interface{}
+	Path        string
+	Song        string
+	Artist      string
+	Album       string
+	Cddb        string
+	Track       int64
+	Link        int64
+	StartOffset int64
+	Constraint  interface{}
 }
 
 type Song struct {
 	Title string
 }
+
+type Warning struct {
+	Path    string
+	Message string
+}
@@ -30,6 +30,10 @@ func Open(mode string) error {
 		panic(fmt.Errorf("unable to open database, %v", err))
 	}
 
+	if err := migrate(ctx, db, mode == "ro"); err != nil {
+		return fmt.Errorf("failed to upgrade database, %v", err)
+	}
+
 	if _, err := db.ExecContext(ctx, ddl); err != nil {
 		return fmt.Errorf("failed to access database, %v", err)
 	}
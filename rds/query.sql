@@ -9,15 +9,21 @@ INSERT INTO album ( title, artist ) VALUES ( ?, ? );
 
 -- name: AddRecording :exec
 INSERT INTO recording
-  ( path, song, artist, album, cddb, track ) 
+  ( path, song, artist, album, cddb, track, link, start_offset ) 
 VALUES
-  ( ?, ?, ?, ?, ?, ?);
+  ( ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: ListRecordingsMatchingSong :many
-SELECT path, song, album, track FROM recording WHERE song LIKE ? ORDER BY album, track;
+SELECT path, link, song, album, track FROM recording WHERE song LIKE ? ORDER BY album, track, link;
 
 -- name: ListRecordingsMatchingAlbum :many
-SELECT path, song, album, track FROM recording WHERE album LIKE ? ORDER BY album, track;
+SELECT path, link, song, album, track FROM recording WHERE album LIKE ? ORDER BY album, track, link;
 
 -- name: ListRecordingsMatchingArtist :many
-SELECT path, song, album, track FROM recording WHERE artist LIKE ? ORDER BY album, track;
+SELECT path, link, song, album, track FROM recording WHERE artist LIKE ? ORDER BY album, track, link;
+
+-- name: AddWarning :exec
+INSERT OR IGNORE INTO warning ( path, message ) VALUES ( ?, ? );
+
+-- name: ListWarnings :many
+SELECT path, message FROM warning ORDER BY path;
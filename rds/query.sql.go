@@ -34,18 +34,20 @@ func (q *Queries) AddArtist(ctx context.Context, name string) error {
 
 const addRecording = `-- name: AddRecording :exec
 INSERT INTO recording
-  ( path, song, artist, album, cddb, track ) 
+  ( path, song, artist, album, cddb, track, link, start_offset ) 
 VALUES
-  ( ?, ?, ?, ?, ?, ?)
+  ( ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type AddRecordingParams struct {
-	Path   string
-	Song   string
-	Artist string
-	Album  string
-	Cddb   string
-	Track  int64
+	Path        string
+	Song        string
+	Artist      string
+	Album       string
+	Cddb        string
+	Track       int64
+	Link        int64
+	StartOffset int64
 }
 
 func (q *Queries) AddRecording(ctx context.Context, arg AddRecordingParams) error {
@@ -56,6 +58,8 @@ func (q *Queries) AddRecording(ctx context.Context, arg AddRecordingParams) erro
 		arg.Album,
 		arg.Cddb,
 		arg.Track,
+		arg.Link,
+		arg.StartOffset,
 	)
 	return err
 }
@@ -69,12 +73,27 @@ func (q *Queries) AddSong(ctx context.Context, title string) error {
 	return err
 }
 
+const addWarning = `-- name: AddWarning :exec
+INSERT OR IGNORE INTO warning ( path, message ) VALUES ( ?, ? )
+`
+
+type AddWarningParams struct {
+	Path    string
+	Message string
+}
+
+func (q *Queries) AddWarning(ctx context.Context, arg AddWarningParams) error {
+	_, err := q.db.ExecContext(ctx, addWarning, arg.Path, arg.Message)
+	return err
+}
+
 const listRecordingsMatchingAlbum = `-- name: ListRecordingsMatchingAlbum :many
-SELECT path, song, album, track FROM recording WHERE album LIKE ? ORDER BY album, track
+SELECT path, link, song, album, track FROM recording WHERE album LIKE ? ORDER BY album, track, link
 `
 
 type ListRecordingsMatchingAlbumRow struct {
 	Path  string
+	Link  int64
 	Song  string
 	Album string
 	Track int64
@@ -91,6 +110,7 @@ func (q *Queries) ListRecordingsMatchingAlbum(ctx context.Context, album string)
 		var i ListRecordingsMatchingAlbumRow
 		if err := rows.Scan(
 			&i.Path,
+			&i.Link,
 			&i.Song,
 			&i.Album,
 			&i.Track,
@@ -109,11 +129,12 @@ func (q *Queries) ListRecordingsMatchingAlbum(ctx context.Context, album string)
 }
 
 const listRecordingsMatchingArtist = `-- name: ListRecordingsMatchingArtist :many
-SELECT path, song, album, track FROM recording WHERE artist LIKE ? ORDER BY album, track
+SELECT path, link, song, album, track FROM recording WHERE artist LIKE ? ORDER BY album, track, link
 `
 
 type ListRecordingsMatchingArtistRow struct {
 	Path  string
+	Link  int64
 	Song  string
 	Album string
 	Track int64
@@ -130,6 +151,7 @@ func (q *Queries) ListRecordingsMatchingArtist(ctx context.Context, artist strin
 		var i ListRecordingsMatchingArtistRow
 		if err := rows.Scan(
 			&i.Path,
+			&i.Link,
 			&i.Song,
 			&i.Album,
 			&i.Track,
@@ -148,11 +170,12 @@ func (q *Queries) ListRecordingsMatchingArtist(ctx context.Context, artist strin
 }
 
 const listRecordingsMatchingSong = `-- name: ListRecordingsMatchingSong :many
-SELECT path, song, album, track FROM recording WHERE song LIKE ? ORDER BY album, track
+SELECT path, link, song, album, track FROM recording WHERE song LIKE ? ORDER BY album, track, link
 `
 
 type ListRecordingsMatchingSongRow struct {
 	Path  string
+	Link  int64
 	Song  string
 	Album string
 	Track int64
@@ -169,6 +192,7 @@ func (q *Queries) ListRecordingsMatchingSong(ctx context.Context, song string) (
 		var i ListRecordingsMatchingSongRow
 		if err := rows.Scan(
 			&i.Path,
+			&i.Link,
 			&i.Song,
 			&i.Album,
 			&i.Track,
@@ -185,3 +209,30 @@ func (q *Queries) ListRecordingsMatchingSong(ctx context.Context, song string) (
 	}
 	return items, nil
 }
+
+const listWarnings = `-- name: ListWarnings :many
+SELECT path, message FROM warning ORDER BY path
+`
+
+func (q *Queries) ListWarnings(ctx context.Context) ([]Warning, error) {
+	rows, err := q.db.QueryContext(ctx, listWarnings)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Warning
+	for rows.Next() {
+		var i Warning
+		if err := rows.Scan(&i.Path, &i.Message); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
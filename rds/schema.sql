@@ -1,3 +1,6 @@
+-- Changes to existing tables also need a step in migrate.go, which
+-- upgrades databases created with an earlier schema.
+
 CREATE TABLE IF NOT EXISTS song (
     title  TEXT NOT NULL,
 
@@ -27,9 +30,11 @@ CREATE TABLE IF NOT EXISTS recording (
     album  TEXT NOT NULL,
     cddb   TEXT NOT NULL,
     track  INTEGER NOT NULL,
+    link   INTEGER NOT NULL DEFAULT 0,
+    start_offset INTEGER NOT NULL DEFAULT 0,
 
     CONSTRAINT PK 
-        PRIMARY KEY ( path )
+        PRIMARY KEY ( path, link )
 
     CONSTRAINT artist_recorded_song_on_album_fk
         FOREIGN KEY       ( artist )
@@ -43,3 +48,11 @@ CREATE TABLE IF NOT EXISTS recording (
         FOREIGN KEY     ( song )
         REFERENCES song ( title )
 );
+
+CREATE TABLE IF NOT EXISTS warning (
+    path    TEXT NOT NULL,
+    message TEXT NOT NULL,
+
+    CONSTRAINT PK
+        PRIMARY KEY ( path, message )
+);
@@ -11,35 +11,74 @@ import (
 
 // InsertSongFromPath adds a song from an .opus file to the database.
 func InsertSongFromPath(path string) error {
-	ctx := context.Background()
-	info, err := opus.ParseInfo(path)
+	info, err := opus.ParseOptions{Recover: true}.ParseInfo(path)
 	if err != nil {
 		return fmt.Errorf("failed to read Opus info, %v", err)
 	}
 
-	err = rds.Database.AddSong(ctx, info.Comments["TITLE"])
+	return insertRecording(path, 0, 0, &info)
+}
+
+// InsertLinksFromPath adds every link of a chained .opus file to the
+// database as a recording of its own. A file that is not chained is added
+// as a single recording.
+func InsertLinksFromPath(path string) error {
+	links, err := opus.ParseOptions{Recover: true}.ParseLinks(path)
+	if err != nil {
+		return fmt.Errorf("failed to read Opus links, %v", err)
+	}
+
+	for i, link := range links {
+		err = insertRecording(path, i, link.Offset, &link.Info)
+		if err != nil {
+			return fmt.Errorf("link %d, %v", i, err)
+		}
+	}
+
+	return nil
+}
+
+func insertRecording(path string, link int, offset int64, info *opus.OpusInfo) error {
+	ctx := context.Background()
+
+	err := rds.Database.AddSong(ctx, info.Comments.Value("TITLE"))
 	err = rds.Database.AddAlbum(ctx, rds.AddAlbumParams{
-		Title:  info.Comments["ALBUM"],
-		Artist: info.Comments["ALBUMARTIST"],
+		Title:  info.Comments.Value("ALBUM"),
+		Artist: info.Comments.Value("ALBUMARTIST"),
 	})
-	err = rds.Database.AddArtist(ctx, info.Comments["ARTIST"])
+	err = rds.Database.AddArtist(ctx, info.Comments.Value("ARTIST"))
 
-	track, err := strconv.Atoi(info.Comments["TRACKNUMBER"])
+	track, err := strconv.Atoi(info.Comments.Value("TRACKNUMBER"))
 	if err != nil {
 		return fmt.Errorf("invalid track number, %v", err)
 	}
 	err = rds.Database.AddRecording(ctx, rds.AddRecordingParams{
-		Path:   path,
-		Song:   info.Comments["TITLE"],
-		Artist: info.Comments["ARTIST"],
-		Album:  info.Comments["ALBUM"],
-		Cddb:   info.Comments["CDDB"],
-		Track:  int64(track),
+		Path:        path,
+		Song:        info.Comments.Value("TITLE"),
+		Artist:      info.Comments.Value("ARTIST"),
+		Album:       info.Comments.Value("ALBUM"),
+		Cddb:        info.Comments.Value("CDDB"),
+		Track:       int64(track),
+		Link:        int64(link),
+		StartOffset: offset,
 	})
 
 	if err != nil {
 		return fmt.Errorf("failed to add song to database, %v", err)
 	}
 
+	// Record the ways in which the file does not conform to the
+	// specification, so they can be looked up later.
+	for _, warning := range info.Warnings {
+		fmt.Printf("[WARN] %s, %s\n", path, warning)
+		err = rds.Database.AddWarning(ctx, rds.AddWarningParams{
+			Path:    path,
+			Message: warning,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add warning to database, %v", err)
+		}
+	}
+
 	return nil
 }
@@ -43,3 +43,15 @@ func MatchArtist(pattern string) ([]rds.ListRecordingsMatchingArtistRow, error)
 
 	return recordings, nil
 }
+
+// ListWarnings returns the conformance warnings recorded when the files
+// were scanned, ordered by path.
+func ListWarnings() ([]rds.Warning, error) {
+	ctx := context.Background()
+	warnings, err := rds.Database.ListWarnings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve warnings, %v", err)
+	}
+
+	return warnings, nil
+}
@@ -6,7 +6,9 @@ import (
 	"path/filepath"
 )
 
-func WalkDirInsert(dir string) error {
+// WalkDirInsert adds the .opus files in dir to the database. With chained
+// set, every link of a chained file is added as a recording of its own.
+func WalkDirInsert(dir string, chained bool) error {
 	fmt.Printf("scanning %s for Opus files to add to the database...\n", dir)
 
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
@@ -14,7 +16,11 @@ func WalkDirInsert(dir string) error {
 			return nil
 		}
 
-		err = InsertSongFromPath(path)
+		if chained {
+			err = InsertLinksFromPath(path)
+		} else {
+			err = InsertSongFromPath(path)
+		}
 		if err != nil {
 			fmt.Printf("[ERROR] failed to add %s, %v\n", path, err)
 		} else {